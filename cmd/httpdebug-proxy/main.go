@@ -0,0 +1,32 @@
+// httpdebug-proxy is an HTTP forward proxy that logs every proxied
+// request as its `curl` equivalent, so that third-party binaries can be
+// debugged without modifying their code:
+//
+//	$ httpdebug-proxy -addr localhost:8080 &
+//	$ HTTP_PROXY=http://localhost:8080 some-tool ...
+//
+// HTTPS requests tunneled with CONNECT are passed through and logged
+// as tunnels only, since their contents are encrypted.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	dbg "github.com/gmlewis/go-httpdebug/httpdebug"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	json := flag.Bool("json", false, "log requests as JSON instead of curl")
+	flag.Parse()
+
+	var opts []dbg.CurlTransportOption
+	if *json {
+		opts = append(opts, dbg.WithFormat(dbg.FormatJSON))
+	}
+
+	log.Printf("httpdebug-proxy listening on %v", *addr)
+	log.Fatal(http.ListenAndServe(*addr, dbg.NewForwardProxy(opts...)))
+}
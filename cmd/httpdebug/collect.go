@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	dbg "github.com/gmlewis/go-httpdebug/httpdebug"
+)
+
+var collectCmd = &command{
+	name:  "collect",
+	usage: "aggregate entries streamed from several processes",
+	run:   runCollect,
+}
+
+func runCollect(args []string) error {
+	fs := flag.NewFlagSet("collect", flag.ExitOnError)
+	network := fs.String("network", "unix", "network to listen on: unix, unixgram, tcp, or udp")
+	addr := fs.String("addr", "/tmp/httpdebug.sock", "address to listen on")
+	format := fs.String("format", "text", "output format: text or json")
+	window := positiveDurationFlag(fs, "window", time.Second, "how long to hold entries so that late arrivals are ordered correctly")
+	fs.Parse(args)
+
+	write, err := entryWriter(os.Stdout, *format)
+	if err != nil {
+		return err
+	}
+
+	c := &dbg.Collector{}
+	closer, err := listen(c, *network, *addr)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	ticker := time.NewTicker(max(*window/2, 1))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, e := range c.Drain(time.Now().Add(-*window)) {
+				write(e)
+			}
+		case <-sig:
+			for _, e := range c.Drain(time.Time{}) {
+				write(e)
+			}
+			return nil
+		}
+	}
+}
+
+// listen starts serving c on the provided network and address.
+func listen(c *dbg.Collector, network, addr string) (io.Closer, error) {
+	if strings.HasPrefix(network, "unix") {
+		// Remove a socket left behind by an earlier run, but nothing
+		// else that addr might name by mistake.
+		if fi, err := os.Lstat(addr); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(addr)
+		}
+	}
+
+	switch network {
+	case "unix", "tcp", "tcp4", "tcp6":
+		l, err := net.Listen(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		go c.Serve(l)
+		return l, nil
+	case "unixgram", "udp", "udp4", "udp6":
+		pc, err := net.ListenPacket(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		go c.ServePacket(pc)
+		return pc, nil
+	}
+	return nil, fmt.Errorf("unsupported network %q", network)
+}
+
+// entryWriter returns a func that writes entries to w in the named format.
+func entryWriter(w io.Writer, format string) (func(*dbg.Entry), error) {
+	switch format {
+	case "text":
+		return func(e *dbg.Entry) { fmt.Fprintf(w, "%v\n\n", e) }, nil
+	case "json":
+		enc := json.NewEncoder(w)
+		return func(e *dbg.Entry) { enc.Encode(e) }, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
@@ -0,0 +1,94 @@
+// httpdebug is a command-line companion to the httpdebug package.
+//
+// Usage:
+//
+//	httpdebug <command> [flags]
+//
+// The commands are:
+//
+//	collect   aggregate entries streamed from several processes
+//	openapi   generate a draft OpenAPI document from a HAR file, cassette, or capture archive
+//	replay    re-issue the requests in a HAR file, optionally diffing the responses
+//	scrub     apply redaction rules to a HAR file, cassette, or capture archive
+//	selftest  check that the generated curl commands replay requests faithfully
+//	tui       browse captured exchanges in an interactive terminal UI
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// command is a single httpdebug subcommand.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []*command{
+	collectCmd,
+	openAPICmd,
+	replayCmd,
+	scrubCmd,
+	selfTestCmd,
+	tuiCmd,
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("httpdebug: ")
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	usage()
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: httpdebug <command> [flags]\n\nThe commands are:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-10v%v\n", cmd.name, cmd.usage)
+	}
+	os.Exit(2)
+}
+
+// positiveDuration is a flag.Value for a time.Duration that must be
+// greater than zero, such as the period of a ticker.
+type positiveDuration time.Duration
+
+func (d *positiveDuration) String() string { return time.Duration(*d).String() }
+
+func (d *positiveDuration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if v <= 0 {
+		return errors.New("must be positive")
+	}
+	*d = positiveDuration(v)
+	return nil
+}
+
+// positiveDurationFlag defines a positiveDuration flag in fs with the
+// given name, default value, and usage, and returns its value.
+func positiveDurationFlag(fs *flag.FlagSet, name string, value time.Duration, usage string) *time.Duration {
+	d := value
+	fs.Var((*positiveDuration)(&d), name, usage)
+	return &d
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	dbg "github.com/gmlewis/go-httpdebug/httpdebug"
+)
+
+var openAPICmd = &command{
+	name:  "openapi",
+	usage: "generate a draft OpenAPI document from a HAR file, cassette, or capture archive",
+	run:   runOpenAPI,
+}
+
+func runOpenAPI(args []string) error {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	title := fs.String("title", "Captured API", "title of the generated document")
+	out := fs.String("o", "", "file to write the document to (default standard output)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: httpdebug openapi [flags] file.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	buf, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	interactions, err := readInteractions(buf)
+	if err != nil {
+		return fmt.Errorf("%v: %w", fs.Arg(0), err)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return dbg.GenerateOpenAPI(*title, interactions).WriteJSON(w)
+}
+
+// readInteractions returns the interactions in buf, which holds a HAR
+// file, a cassette, or the JSON array of exchanges written by
+// Capture.Archive.
+func readInteractions(buf []byte) ([]*dbg.Interaction, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte("[")) {
+		var exchanges []dbg.Exchange
+		if err := json.Unmarshal(buf, &exchanges); err != nil {
+			return nil, err
+		}
+		var interactions []*dbg.Interaction
+		for _, x := range exchanges {
+			if i := x.Interaction(); i != nil {
+				interactions = append(interactions, i)
+			}
+		}
+		return interactions, nil
+	}
+
+	var doc struct {
+		Log          *dbg.HARLog        `json:"log"`
+		Interactions []*dbg.Interaction `json:"interactions"`
+	}
+	if err := json.Unmarshal(buf, &doc); err != nil {
+		return nil, err
+	}
+	switch {
+	case doc.Log != nil:
+		return (&dbg.HAR{Log: *doc.Log}).Interactions()
+	case doc.Interactions != nil:
+		return doc.Interactions, nil
+	}
+	return nil, errors.New("neither a HAR file, a cassette, nor a capture archive")
+}
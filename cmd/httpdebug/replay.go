@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	dbg "github.com/gmlewis/go-httpdebug/httpdebug"
+)
+
+var replayCmd = &command{
+	name:  "replay",
+	usage: "re-issue the requests in a HAR file, optionally diffing the responses",
+	run:   runReplay,
+}
+
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	diff := fs.String("diff", "", "HAR file whose requests are re-issued and whose responses are compared with the new ones")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout for each request")
+	raw := fs.Bool("raw", false, "compare responses without the default normalizers")
+	var normalizers []dbg.Normalizer
+	fs.Func("ignore-header", "additional response header to ignore when diffing (repeatable)", func(name string) error {
+		normalizers = append(normalizers, dbg.IgnoreHeaders(name))
+		return nil
+	})
+	fs.Func("mask", "additional regexp whose matches in bodies are masked when diffing (repeatable)", func(expr string) error {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return err
+		}
+		normalizers = append(normalizers, dbg.MaskBody(re, "<MASKED>"))
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: httpdebug replay [flags] -diff old.har\n       httpdebug replay [flags] file.har")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if !*raw {
+		normalizers = slices.Concat(dbg.DefaultNormalizers, normalizers)
+	}
+
+	path, compare := *diff, true
+	if path == "" {
+		if fs.NArg() != 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		path, compare = fs.Arg(0), false
+	}
+
+	har, err := dbg.ReadHAR(path)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout: *timeout,
+		// Each redirect is recorded as a separate entry.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	var failed int
+	for _, e := range har.Log.Entries {
+		diffs, err := replay(client, &e, compare, normalizers)
+		label := fmt.Sprintf("%v %v", e.Request.Method, e.Request.URL)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("%v: %v\n", label, err)
+		case len(diffs) > 0:
+			failed++
+			fmt.Printf("%v: %v differences\n", label, len(diffs))
+			for _, d := range diffs {
+				fmt.Printf("    %v\n", d)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%v of %v requests failed or differ", failed, len(har.Log.Entries))
+	}
+	if compare {
+		fmt.Printf("%v requests replayed, no differences\n", len(har.Log.Entries))
+	}
+	return nil
+}
+
+// replay re-issues the request of e and, if compare is true, returns the
+// differences between the recorded and the new response after applying
+// the normalizers.
+func replay(client *http.Client, e *dbg.HAREntry, compare bool, normalizers []dbg.Normalizer) ([]string, error) {
+	req, err := e.Request.NewRequest(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	got, err := dbg.ReadCassetteResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if !compare {
+		fmt.Printf("%v %v -> %v\n", e.Request.Method, e.Request.URL, got.Status)
+		return nil, nil
+	}
+
+	recorded, err := e.Response.CassetteResponse()
+	if err != nil {
+		return nil, err
+	}
+	return dbg.DiffResponse(recorded, got, normalizers...), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	dbg "github.com/gmlewis/go-httpdebug/httpdebug"
+)
+
+var scrubCmd = &command{
+	name:  "scrub",
+	usage: "apply redaction rules to a HAR file, cassette, or capture archive",
+	run:   runScrub,
+}
+
+func runScrub(args []string) error {
+	fs := flag.NewFlagSet("scrub", flag.ExitOnError)
+	rules := fs.String("rules", "", "YAML file of redaction rules (default the rules of httpdebug.New)")
+	keepJWT := fs.Bool("keep-jwt-claims", false, "redact only the signatures of JWTs instead of the entire tokens")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: httpdebug scrub [flags] in.json out.json")
+		fs.PrintDefaults()
+	}
+	// Flags may follow the file names, as in "scrub in.har out.har -rules rules.yaml".
+	var files []string
+	for fs.Parse(args); fs.NArg() > 0; fs.Parse(args) {
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(files) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	r, err := dbg.ParseRules(nil)
+	if *rules != "" {
+		r, err = dbg.ReadRules(*rules)
+	}
+	if err != nil {
+		return err
+	}
+	if !*keepJWT {
+		r.RedactEntireJWT = true
+	}
+
+	buf, err := os.ReadFile(files[0])
+	if err != nil {
+		return err
+	}
+	scrubbed, err := r.Scrub(buf)
+	if err != nil {
+		return fmt.Errorf("%v: %w", files[0], err)
+	}
+	return os.WriteFile(files[1], scrubbed, 0o600)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	dbg "github.com/gmlewis/go-httpdebug/httpdebug"
+)
+
+var selfTestCmd = &command{
+	name:  "selftest",
+	usage: "check that the generated curl commands replay requests faithfully",
+	run:   runSelfTest,
+}
+
+func runSelfTest(args []string) error {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	singleLine := fs.Bool("single-line", false, "check the commands generated with httpdebug.WithSingleLine")
+	verbose := fs.Bool("v", false, "print the dumps of the requests")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: httpdebug selftest [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var opts []dbg.CurlTransportOption
+	if *singleLine {
+		opts = append(opts, dbg.WithSingleLine())
+	}
+	if !*verbose {
+		// The dumps are logged like any others.
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+	results, err := dbg.SelfTest(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Printf("FAIL %v: %v\n%v\n", r.Name, strings.ReplaceAll(r.Err.Error(), "\n", "\n    "), r.Curl)
+		default:
+			fmt.Printf("ok   %v\n", r.Name)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%v of %v commands failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	dbg "github.com/gmlewis/go-httpdebug/httpdebug"
+	"github.com/gmlewis/go-httpdebug/httpdebug/tui"
+)
+
+var tuiCmd = &command{
+	name:  "tui",
+	usage: "browse captured exchanges in an interactive terminal UI",
+	run:   runTUI,
+}
+
+func runTUI(args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	captureURL := fs.String("url", "", "URL of a capture handler to poll (e.g. http://localhost:6060/debug/httpdebug); if empty, listen for streamed entries like collect")
+	interval := positiveDurationFlag(fs, "interval", time.Second, "how often to poll -url")
+	network := fs.String("network", "unix", "network to listen on: unix, unixgram, tcp, or udp")
+	addr := fs.String("addr", "/tmp/httpdebug.sock", "address to listen on")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	v := tui.New()
+	if *captureURL != "" {
+		// Markers are inserted into the capture itself, so that they
+		// appear in its exports, and show up at the next poll.
+		v.OnMark = func(name string) {
+			if resp, err := http.PostForm(*captureURL, url.Values{"marker": {name}}); err == nil {
+				resp.Body.Close()
+			}
+		}
+		go poll(ctx, v, *captureURL, *interval)
+	} else {
+		c := &dbg.Collector{}
+		closer, err := listen(c, *network, *addr)
+		if err != nil {
+			return err
+		}
+		defer closer.Close()
+		go drain(ctx, v, c, *interval)
+	}
+
+	return v.Run(ctx, os.Stdin, os.Stdout)
+}
+
+// poll replaces the exchanges in v with those served as JSON by the
+// capture handler at captureURL every interval.
+func poll(ctx context.Context, v *tui.Viewer, captureURL string, interval time.Duration) {
+	u, err := url.Parse(captureURL)
+	if err != nil {
+		return
+	}
+	q := u.Query()
+	q.Set("format", "json")
+	u.RawQuery = q.Encode()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if exchanges, err := fetchExchanges(ctx, u.String()); err == nil {
+			// The handler serves the newest exchange first.
+			slices.Reverse(exchanges)
+			v.Set(exchanges)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchExchanges returns the exchanges served as JSON at u.
+func fetchExchanges(ctx context.Context, u string) ([]dbg.Exchange, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %v: %v", u, resp.Status)
+	}
+	var exchanges []dbg.Exchange
+	err = json.NewDecoder(resp.Body).Decode(&exchanges)
+	return exchanges, err
+}
+
+// drain adds the entries received by c to v every interval. Streamed
+// entries carry requests only, so they are shown without responses.
+func drain(ctx context.Context, v *tui.Viewer, c *dbg.Collector, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var exchanges []dbg.Exchange
+			for _, e := range c.Drain(time.Now().Add(-interval)) {
+				exchanges = append(exchanges, dbg.Exchange{Request: e})
+			}
+			if len(exchanges) > 0 {
+				v.Add(exchanges...)
+			}
+		}
+	}
+}
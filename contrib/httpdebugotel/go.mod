@@ -0,0 +1,31 @@
+module github.com/gmlewis/go-httpdebug/contrib/httpdebugotel
+
+go 1.22.0
+
+require (
+	github.com/gmlewis/go-httpdebug v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/oauth2 v0.17.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/term v0.27.0 // indirect
+	google.golang.org/appengine v1.6.7 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The version required above is a placeholder until the first release
+// of github.com/gmlewis/go-httpdebug is tagged, after which it is bumped
+// to that tag (see "Releasing" in the README). Within the repository,
+// the module builds against the enclosing checkout.
+replace github.com/gmlewis/go-httpdebug => ../..
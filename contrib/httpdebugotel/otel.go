@@ -0,0 +1,74 @@
+// Package httpdebugotel attaches the requests dumped by an
+// httpdebug.CurlTransport to OpenTelemetry traces, so that the curl
+// command of an exchange can be found from its span:
+//
+//	ct := httpdebug.New(httpdebugotel.WithSpanEvents())
+//	client := &http.Client{Transport: otelhttp.NewTransport(ct)}
+//
+// It is a separate module so that the httpdebug package itself does not
+// depend on OpenTelemetry.
+package httpdebugotel
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gmlewis/go-httpdebug/httpdebug"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EventName is the name of the span events added by SpanEvents.
+const EventName = "httpdebug.request"
+
+// SpanEvents is an httpdebug.Observer that adds an event named EventName
+// to the span in the context of each request (typically the client span
+// of an otelhttp.Transport wrapping the CurlTransport), with these
+// attributes, all already redacted by the transport:
+//
+//	http.request.method        the request method
+//	url.full                   the sanitized URL
+//	http.response.status_code  the status code, if a response arrived
+//	httpdebug.curl             the curl command
+//	httpdebug.elapsed          the round-trip time, in seconds
+//	httpdebug.seq              the sequence number, if any
+//	httpdebug.id               the request ID, if any
+//	httpdebug.error            the error, if the round trip failed
+//
+// Requests without a recording span are ignored.
+type SpanEvents struct{}
+
+// WithSpanEvents is an httpdebug.CurlTransportOption that adds SpanEvents
+// to the transport.
+func WithSpanEvents() func(*httpdebug.CurlTransport) {
+	return httpdebug.WithObserver(SpanEvents{})
+}
+
+// ObserveRoundTrip implements the httpdebug.Observer interface.
+func (SpanEvents) ObserveRoundTrip(e *httpdebug.Entry, req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	span := trace.SpanFromContext(req.Context())
+	if !span.IsRecording() {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", e.Method),
+		attribute.String("url.full", e.URL),
+	}
+	if resp != nil {
+		attrs = append(attrs, attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	attrs = append(attrs,
+		attribute.String("httpdebug.curl", e.Curl),
+		attribute.Float64("httpdebug.elapsed", elapsed.Seconds()),
+	)
+	if e.Seq != 0 {
+		attrs = append(attrs, attribute.Int64("httpdebug.seq", int64(e.Seq)))
+	}
+	if e.ID != "" {
+		attrs = append(attrs, attribute.String("httpdebug.id", e.ID))
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("httpdebug.error", err.Error()))
+	}
+	span.AddEvent(EventName, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,107 @@
+package httpdebugotel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-httpdebug/httpdebug"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// errTransport is an http.RoundTripper that always fails.
+type errTransport struct {
+	err error
+}
+
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// attributes returns the attributes of the only event of span.
+func attributes(t *testing.T, span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	t.Helper()
+	events := span.Events()
+	if len(events) != 1 || events[0].Name != EventName {
+		t.Fatalf("span events = %v, want one %q", events, EventName)
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range events[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestSpanEvents(t *testing.T) {
+	w := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(w)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	ct := httpdebug.New(WithSpanEvents(), httpdebug.WithSequence())
+	ctx, span := tracer.Start(context.Background(), "create")
+	req, _ := http.NewRequestWithContext(ctx, "POST", server.URL+"/items?client_secret=s3cr3t", strings.NewReader("{}"))
+	resp, err := ct.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip = %v", err)
+	}
+	resp.Body.Close()
+	span.End()
+
+	failing := httpdebug.New(WithSpanEvents(), httpdebug.WithTransport(errTransport{err: errors.New("boom")}))
+	ctx, span = tracer.Start(context.Background(), "fail")
+	req, _ = http.NewRequestWithContext(ctx, "GET", "http://example.com/", nil)
+	if _, err := failing.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip = nil error, want error")
+	}
+	span.End()
+
+	// A request without a span is ignored.
+	req, _ = http.NewRequest("GET", server.URL, nil)
+	if resp, err := ct.RoundTrip(req); err == nil {
+		resp.Body.Close()
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended %v spans, want 2", len(spans))
+	}
+	attrs := attributes(t, spans[0])
+	if got := attrs["http.request.method"].AsString(); got != "POST" {
+		t.Errorf("http.request.method = %q, want POST", got)
+	}
+	if got, want := attrs["url.full"].AsString(), server.URL+"/items?client_secret=REDACTED"; got != want {
+		t.Errorf("url.full = %q, want %q", got, want)
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != http.StatusCreated {
+		t.Errorf("http.response.status_code = %v, want %v", got, http.StatusCreated)
+	}
+	if got := attrs["httpdebug.seq"].AsInt64(); got != 1 {
+		t.Errorf("httpdebug.seq = %v, want 1", got)
+	}
+	if curl := attrs["httpdebug.curl"].AsString(); !strings.HasPrefix(curl, "curl -X POST") || strings.Contains(curl, "s3cr3t") {
+		t.Errorf("httpdebug.curl = %q, want a redacted curl command", curl)
+	}
+
+	attrs = attributes(t, spans[1])
+	if got := attrs["httpdebug.error"].AsString(); got != "boom" {
+		t.Errorf("httpdebug.error = %q, want boom", got)
+	}
+	if _, ok := attrs["http.response.status_code"]; ok {
+		t.Error("failed request has a status code")
+	}
+}
@@ -0,0 +1,31 @@
+module github.com/gmlewis/go-httpdebug/contrib/httpdebugprom
+
+go 1.22.0
+
+require (
+	github.com/gmlewis/go-httpdebug v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.19.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/oauth2 v0.17.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/term v0.27.0 // indirect
+	google.golang.org/appengine v1.6.7 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The version required above is a placeholder until the first release
+// of github.com/gmlewis/go-httpdebug is tagged, after which it is bumped
+// to that tag (see "Releasing" in the README). Within the repository,
+// the module builds against the enclosing checkout.
+replace github.com/gmlewis/go-httpdebug => ../..
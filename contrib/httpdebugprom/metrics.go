@@ -0,0 +1,162 @@
+// Package httpdebugprom exports Prometheus metrics about the requests
+// dumped by an httpdebug.CurlTransport:
+//
+//	ct := httpdebug.New(httpdebugprom.WithMetrics(prometheus.DefaultRegisterer))
+//
+// It is a separate module so that the httpdebug package itself does not
+// depend on the Prometheus client library. (CurlTransport.WriteMetrics
+// exports the same metrics without it.)
+package httpdebugprom
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gmlewis/go-httpdebug/httpdebug"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is an httpdebug.Observer that updates Prometheus collectors.
+// The same Metrics may be shared by several transports.
+type Metrics struct {
+	requests     *prometheus.CounterVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	duration     *prometheus.HistogramVec
+}
+
+// NewMetrics returns a new set of collectors registered with reg.
+// Collectors that are already registered with reg (for example, by
+// another transport) are reused. NewMetrics panics if registration
+// fails for any other reason, like prometheus.MustRegister.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	labels := []string{"host", "method"}
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "httpdebug_requests_total",
+			Help: "Total number of HTTP requests by host, method, and status code.",
+		}, []string{"host", "method", "code"}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "httpdebug_request_size_bytes",
+			Help:    "Size of HTTP request bodies.",
+			Buckets: sizeBuckets,
+		}, labels),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "httpdebug_response_size_bytes",
+			Help:    "Size of HTTP response bodies.",
+			Buckets: sizeBuckets,
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "httpdebug_request_duration_seconds",
+			Help:    "Round-trip latency of HTTP requests.",
+			Buckets: durationBuckets,
+		}, labels),
+	}
+	m.requests = register(reg, m.requests)
+	m.requestSize = register(reg, m.requestSize)
+	m.responseSize = register(reg, m.responseSize)
+	m.duration = register(reg, m.duration)
+	return m
+}
+
+// register registers c with reg, returning the already-registered
+// collector if there is one.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) C {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+// durationBuckets are the histogram buckets, in seconds, for latencies.
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// sizeBuckets are the histogram buckets, in bytes, for body sizes.
+var sizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+
+// WithMetrics is an httpdebug.CurlTransportOption that exports Prometheus counters
+// and histograms to reg: requests by host, method, and status code,
+// request and response body sizes, and round-trip latency.
+// Requests that fail without a response are counted with the code "error".
+// A nil reg is ignored.
+func WithMetrics(reg prometheus.Registerer) func(*httpdebug.CurlTransport) {
+	return func(ct *httpdebug.CurlTransport) {
+		if reg != nil {
+			httpdebug.WithObserver(NewMetrics(reg))(ct)
+		}
+	}
+}
+
+// ObserveRoundTrip implements the httpdebug.Observer interface.
+func (m *Metrics) ObserveRoundTrip(e *httpdebug.Entry, req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	// e.Host is limited in cardinality by httpdebug.WithMaxHosts.
+	host, method := e.Host, req.Method
+	if host == "" {
+		host = req.URL.Host
+	}
+	m.requests.WithLabelValues(host, method, statusLabel(resp)).Inc()
+	m.duration.WithLabelValues(host, method).Observe(elapsed.Seconds())
+	if req.ContentLength >= 0 {
+		m.requestSize.WithLabelValues(host, method).Observe(float64(req.ContentLength))
+	}
+	if resp != nil {
+		observeResponseSize(resp, m.responseSize.WithLabelValues(host, method).Observe)
+	}
+}
+
+// statusLabel returns the status code of resp as a metric label value,
+// or "error" if there is no response.
+func statusLabel(resp *http.Response) string {
+	if resp == nil {
+		return "error"
+	}
+	return strconv.Itoa(resp.StatusCode)
+}
+
+// observeResponseSize passes the size of the body of resp to observe,
+// either immediately if it is known from the Content-Length or once the
+// body has been read or closed.
+func observeResponseSize(resp *http.Response, observe func(float64)) {
+	if resp.ContentLength >= 0 || resp.Body == nil {
+		observe(float64(max(resp.ContentLength, 0)))
+		return
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, observe: observe}
+}
+
+// countingBody counts the bytes read from a response body of unknown
+// length and reports the total when the body is exhausted or closed.
+type countingBody struct {
+	io.ReadCloser
+	observe func(float64)
+	n       int64
+	once    sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.report()
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.report()
+	return b.ReadCloser.Close()
+}
+
+func (b *countingBody) report() {
+	b.once.Do(func() { b.observe(float64(b.n)) })
+}
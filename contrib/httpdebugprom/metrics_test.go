@@ -0,0 +1,162 @@
+package httpdebugprom
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-httpdebug/httpdebug"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// quietLog discards the log output of the transports while a test runs.
+func quietLog(t *testing.T) {
+	w := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(w) })
+}
+
+// errTransport is an http.RoundTripper that always fails.
+type errTransport struct {
+	err error
+}
+
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// metrics returns the Metrics observing ct.
+func metrics(t *testing.T, ct *httpdebug.CurlTransport) *Metrics {
+	t.Helper()
+	if len(ct.Observers) != 1 {
+		t.Fatalf("Observers = %v, want one", ct.Observers)
+	}
+	m, ok := ct.Observers[0].(*Metrics)
+	if !ok {
+		t.Fatalf("Observers[0] = %T, want *Metrics", ct.Observers[0])
+	}
+	return m
+}
+
+func TestWithMetrics(t *testing.T) {
+	if got := httpdebug.New(WithMetrics(nil)); got.Observers != nil {
+		t.Errorf("WithMetrics(nil) set Observers = %v, want nil", got.Observers)
+	}
+	metrics(t, httpdebug.New(WithMetrics(prometheus.NewRegistry())))
+}
+
+func TestNewMetrics_AlreadyRegistered(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	a := NewMetrics(reg)
+	b := NewMetrics(reg)
+	if a.requests != b.requests || a.duration != b.duration {
+		t.Error("NewMetrics did not reuse the already-registered collectors")
+	}
+}
+
+func TestRoundTrip_Metrics(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL := server.URL
+	mux.HandleFunc("/known", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	})
+	mux.HandleFunc("/chunked", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "abc")
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, "def")
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+
+	quietLog(t)
+
+	reg := prometheus.NewRegistry()
+	ct := httpdebug.New(WithMetrics(reg))
+	client := &http.Client{Transport: ct}
+
+	for _, path := range []string{"/known", "/chunked", "/missing"} {
+		resp, err := client.Post(serverURL+path, "text/plain", strings.NewReader("body"))
+		if err != nil {
+			t.Fatalf("client.Post = %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	u, _ := url.Parse(serverURL)
+	m := metrics(t, ct)
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(u.Host, "POST", "200")); got != 2 {
+		t.Errorf("requests{code=200} = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(u.Host, "POST", "404")); got != 1 {
+		t.Errorf("requests{code=404} = %v, want 1", got)
+	}
+
+	want := fmt.Sprintf(`
+# HELP httpdebug_request_size_bytes Size of HTTP request bodies.
+# TYPE httpdebug_request_size_bytes histogram
+httpdebug_request_size_bytes_bucket{host=%[1]q,method="POST",le="64"} 3
+httpdebug_request_size_bytes_bucket{host=%[1]q,method="POST",le="256"} 3
+httpdebug_request_size_bytes_bucket{host=%[1]q,method="POST",le="1024"} 3
+httpdebug_request_size_bytes_bucket{host=%[1]q,method="POST",le="4096"} 3
+httpdebug_request_size_bytes_bucket{host=%[1]q,method="POST",le="16384"} 3
+httpdebug_request_size_bytes_bucket{host=%[1]q,method="POST",le="65536"} 3
+httpdebug_request_size_bytes_bucket{host=%[1]q,method="POST",le="262144"} 3
+httpdebug_request_size_bytes_bucket{host=%[1]q,method="POST",le="1.048576e+06"} 3
+httpdebug_request_size_bytes_bucket{host=%[1]q,method="POST",le="+Inf"} 3
+httpdebug_request_size_bytes_sum{host=%[1]q,method="POST"} 12
+httpdebug_request_size_bytes_count{host=%[1]q,method="POST"} 3
+`, u.Host)
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "httpdebug_request_size_bytes"); err != nil {
+		t.Error(err)
+	}
+
+	// 5 (known) + 6 (chunked) + 19 ("404 page not found\n").
+	if got, want := histogramSum(t, reg, "httpdebug_response_size_bytes"), 30.0; got != want {
+		t.Errorf("response size sum = %v, want %v", got, want)
+	}
+	if got := testutil.CollectAndCount(m.duration); got != 1 {
+		t.Errorf("duration series = %v, want 1", got)
+	}
+}
+
+func TestRoundTrip_MetricsError(t *testing.T) {
+	quietLog(t)
+
+	reg := prometheus.NewRegistry()
+	ct := httpdebug.New(WithMetrics(reg), httpdebug.WithTransport(errTransport{err: errors.New("boom")}))
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if _, err := ct.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip = nil error, want error")
+	}
+	if got := testutil.ToFloat64(metrics(t, ct).requests.WithLabelValues("example.com", "GET", "error")); got != 1 {
+		t.Errorf("requests{code=error} = %v, want 1", got)
+	}
+}
+
+// histogramSum returns the sum of all observations of the named histogram.
+func histogramSum(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather = %v", err)
+	}
+	var sum float64
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			sum += m.GetHistogram().GetSampleSum()
+		}
+	}
+	return sum
+}
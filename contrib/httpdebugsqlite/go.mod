@@ -0,0 +1,37 @@
+module github.com/gmlewis/go-httpdebug/contrib/httpdebugsqlite
+
+go 1.22.0
+
+require (
+	github.com/gmlewis/go-httpdebug v0.0.0-00010101000000-000000000000
+	modernc.org/sqlite v1.30.1
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/oauth2 v0.17.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/term v0.27.0 // indirect
+	google.golang.org/appengine v1.6.7 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+	modernc.org/gc/v3 v3.0.0-20240107210532-573471604cb6 // indirect
+	modernc.org/libc v1.52.1 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+	modernc.org/strutil v1.2.0 // indirect
+	modernc.org/token v1.1.0 // indirect
+)
+
+// The version required above is a placeholder until the first release
+// of github.com/gmlewis/go-httpdebug is tagged, after which it is bumped
+// to that tag (see "Releasing" in the README). Within the repository,
+// the module builds against the enclosing checkout.
+replace github.com/gmlewis/go-httpdebug => ../..
@@ -0,0 +1,133 @@
+// Package httpdebugsqlite records the requests dumped by an
+// httpdebug.CurlTransport in a SQLite database, so that a long session
+// can be queried afterwards with plain SQL:
+//
+//	store, err := httpdebugsqlite.Open("requests.db")
+//	...
+//	defer store.Close()
+//	ct := httpdebug.New(httpdebugsqlite.WithStore(store))
+//
+// It is a separate module so that the httpdebug package itself does not
+// depend on a SQLite driver. The driver used by Open, modernc.org/sqlite,
+// does not need cgo.
+package httpdebugsqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gmlewis/go-httpdebug/httpdebug"
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// Schema creates the table in which a Store records exchanges, one row
+// per request. All values are already redacted by the transport.
+const Schema = `CREATE TABLE IF NOT EXISTS httpdebug_requests (
+	id              INTEGER PRIMARY KEY,
+	time            TEXT NOT NULL,
+	source          TEXT NOT NULL,
+	prefix          TEXT NOT NULL,
+	seq             INTEGER NOT NULL,
+	request_id      TEXT NOT NULL,
+	attempt         INTEGER NOT NULL,
+	method          TEXT NOT NULL,
+	url             TEXT NOT NULL,
+	curl            TEXT NOT NULL,
+	status          INTEGER,
+	elapsed_seconds REAL NOT NULL,
+	error           TEXT,
+	metadata        TEXT
+)`
+
+const insert = `INSERT INTO httpdebug_requests
+	(time, source, prefix, seq, request_id, attempt, method, url, curl, status, elapsed_seconds, error, metadata)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// Store is an httpdebug.Observer that inserts a row into the
+// httpdebug_requests table (see Schema) for every exchange. Failed
+// inserts are logged rather than returned so that they never break the
+// request. It is safe for concurrent use.
+type Store struct {
+	db     *sql.DB
+	insert *sql.Stmt
+	owned  bool
+}
+
+// Open opens (creating if necessary) the SQLite database at path and
+// returns a Store recording to it.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("httpdebugsqlite: %w", err)
+	}
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.owned = true
+	return s, nil
+}
+
+// New returns a Store recording to db, which may use any SQLite driver,
+// creating the table if necessary.
+func New(db *sql.DB) (*Store, error) {
+	if _, err := db.Exec(Schema); err != nil {
+		return nil, fmt.Errorf("httpdebugsqlite: creating table: %w", err)
+	}
+	stmt, err := db.Prepare(insert)
+	if err != nil {
+		return nil, fmt.Errorf("httpdebugsqlite: %w", err)
+	}
+	return &Store{db: db, insert: stmt}, nil
+}
+
+// Close releases the Store's resources, closing the database if it was
+// opened by Open.
+func (s *Store) Close() error {
+	err := s.insert.Close()
+	if s.owned {
+		if closeErr := s.db.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// WithStore is an httpdebug.CurlTransportOption that records every
+// dumped request in s. A nil s is ignored.
+func WithStore(s *Store) func(*httpdebug.CurlTransport) {
+	return func(ct *httpdebug.CurlTransport) {
+		if s != nil {
+			httpdebug.WithObserver(s)(ct)
+		}
+	}
+}
+
+// ObserveRoundTrip implements the httpdebug.Observer interface.
+func (s *Store) ObserveRoundTrip(e *httpdebug.Entry, req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	var status, errMsg, metadata interface{}
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if len(e.Metadata) > 0 {
+		buf, _ := json.Marshal(e.Metadata)
+		metadata = string(buf)
+	}
+	// The request's context may already be canceled by the time the
+	// response has arrived, which must not lose the row.
+	_, insertErr := s.insert.ExecContext(context.WithoutCancel(req.Context()),
+		e.Time.UTC().Format(time.RFC3339Nano), e.Source, e.Prefix, int64(e.Seq), e.ID, e.Attempt,
+		e.Method, e.URL, e.Curl, status, elapsed.Seconds(), errMsg, metadata)
+	if insertErr != nil {
+		log.Printf("# httpdebug: sqlite store error: %v", insertErr)
+	}
+}
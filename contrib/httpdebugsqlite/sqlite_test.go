@@ -0,0 +1,103 @@
+package httpdebugsqlite
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-httpdebug/httpdebug"
+)
+
+// errTransport is an http.RoundTripper that always fails.
+type errTransport struct {
+	err error
+}
+
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+func TestWithStore(t *testing.T) {
+	if got := httpdebug.New(WithStore(nil)); got.Observers != nil {
+		t.Errorf("WithStore(nil) set Observers = %v, want nil", got.Observers)
+	}
+}
+
+func TestStore(t *testing.T) {
+	w := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(w)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "requests.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open = %v", err)
+	}
+	enrich := func(e *httpdebug.Entry) { e.Metadata = map[string]string{"pod": "web-1"} }
+	ct := httpdebug.New(WithStore(store), httpdebug.WithSequence(), httpdebug.WithEnricher(enrich))
+	resp, err := (&http.Client{Transport: ct}).Get(server.URL + "/?client_secret=s3cr3t")
+	if err != nil {
+		t.Fatalf("Get = %v", err)
+	}
+	resp.Body.Close()
+
+	failing := httpdebug.New(WithStore(store), httpdebug.WithTransport(errTransport{err: errors.New("boom")}))
+	req, _ := http.NewRequest("DELETE", "http://example.com/", nil)
+	if _, err := failing.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip = nil error, want error")
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+
+	// The rows can be read back from the database.
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query("SELECT seq, method, url, curl, status, error, metadata FROM httpdebug_requests ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query = %v", err)
+	}
+	defer rows.Close()
+	type row struct {
+		seq               int64
+		method, url, curl string
+		status            sql.NullInt64
+		errMsg, metadata  sql.NullString
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.seq, &r.method, &r.url, &r.curl, &r.status, &r.errMsg, &r.metadata); err != nil {
+			t.Fatalf("Scan = %v", err)
+		}
+		got = append(got, r)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %v rows, want 2", len(got))
+	}
+	if r, wantURL := got[0], server.URL+"/?client_secret=REDACTED"; r.seq != 1 || r.method != "GET" || r.url != wantURL || r.status.Int64 != 404 || r.errMsg.Valid || r.metadata.String != `{"pod":"web-1"}` {
+		t.Errorf("row 1 = %+v, want seq 1, GET %v, status 404, no error, pod metadata", r, wantURL)
+	}
+	if strings.Contains(got[0].curl, "s3cr3t") || !strings.HasPrefix(got[0].curl, "curl") {
+		t.Errorf("curl = %q, want a redacted curl command", got[0].curl)
+	}
+	if r := got[1]; r.method != "DELETE" || r.status.Valid || r.errMsg.String != "boom" || r.metadata.Valid {
+		t.Errorf("row 2 = %+v, want the failed DELETE", r)
+	}
+}
@@ -0,0 +1,26 @@
+module github.com/gmlewis/go-httpdebug/contrib/httpdebugzap
+
+go 1.22.0
+
+require (
+	github.com/gmlewis/go-httpdebug v0.0.0-00010101000000-000000000000
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	golang.org/x/oauth2 v0.17.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/term v0.27.0 // indirect
+	google.golang.org/appengine v1.6.7 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The version required above is a placeholder until the first release
+// of github.com/gmlewis/go-httpdebug is tagged, after which it is bumped
+// to that tag (see "Releasing" in the README). Within the repository,
+// the module builds against the enclosing checkout.
+replace github.com/gmlewis/go-httpdebug => ../..
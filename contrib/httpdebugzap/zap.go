@@ -0,0 +1,96 @@
+// Package httpdebugzap logs the requests dumped by an
+// httpdebug.CurlTransport to a zap.Logger as structured records:
+//
+//	ct := httpdebug.New(httpdebugzap.WithLogger(logger))
+//
+// It is a separate module so that the httpdebug package itself does not
+// depend on zap.
+package httpdebugzap
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gmlewis/go-httpdebug/httpdebug"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logger is an httpdebug.Observer that logs one record per exchange:
+// at debug level if a response arrived and at warn level if the round
+// trip failed. All values are already redacted by the transport.
+type Logger struct {
+	logger *zap.Logger
+}
+
+// NewLogger returns a Logger that writes to logger.
+func NewLogger(logger *zap.Logger) *Logger {
+	return &Logger{logger: logger}
+}
+
+// WithLogger is an httpdebug.CurlTransportOption that logs every dumped
+// request to logger, in addition to the transport's own log output
+// (which can be discarded with log.SetOutput(io.Discard)).
+// A nil logger is ignored.
+func WithLogger(logger *zap.Logger) func(*httpdebug.CurlTransport) {
+	return func(ct *httpdebug.CurlTransport) {
+		if logger != nil {
+			httpdebug.WithObserver(NewLogger(logger))(ct)
+		}
+	}
+}
+
+// ObserveRoundTrip implements the httpdebug.Observer interface.
+func (l *Logger) ObserveRoundTrip(e *httpdebug.Entry, req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	level := zapcore.DebugLevel
+	if err != nil {
+		level = zapcore.WarnLevel
+	}
+	ce := l.logger.Check(level, "httpdebug request")
+	if ce == nil {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("method", e.Method),
+		zap.String("url", e.URL),
+	}
+	if resp != nil {
+		fields = append(fields, zap.Int("status", resp.StatusCode))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	fields = append(fields, zap.Duration("elapsed", elapsed), zap.String("curl", e.Curl))
+	if e.Source != "" {
+		fields = append(fields, zap.String("source", e.Source))
+	}
+	if e.Seq != 0 {
+		fields = append(fields, zap.Uint64("seq", e.Seq))
+	}
+	if e.ID != "" {
+		fields = append(fields, zap.String("id", e.ID))
+	}
+	if e.Attempt != 0 {
+		fields = append(fields, zap.Int("attempt", e.Attempt))
+	}
+	if len(e.Metadata) > 0 {
+		fields = append(fields, zap.Object("metadata", metadata(e.Metadata)))
+	}
+	ce.Write(fields...)
+}
+
+// metadata marshals Entry.Metadata as a zap object with sorted keys.
+type metadata map[string]string
+
+func (m metadata) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		enc.AddString(k, m[k])
+	}
+	return nil
+}
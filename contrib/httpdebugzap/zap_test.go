@@ -0,0 +1,86 @@
+package httpdebugzap
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-httpdebug/httpdebug"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// errTransport is an http.RoundTripper that always fails.
+type errTransport struct {
+	err error
+}
+
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+func TestWithLogger(t *testing.T) {
+	if got := httpdebug.New(WithLogger(nil)); got.Observers != nil {
+		t.Errorf("WithLogger(nil) set Observers = %v, want nil", got.Observers)
+	}
+	if got := httpdebug.New(WithLogger(zap.NewNop())); len(got.Observers) != 1 {
+		t.Errorf("Observers = %v, want one", got.Observers)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	w := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(w)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	enrich := func(e *httpdebug.Entry) { e.Metadata = map[string]string{"pod": "web-1"} }
+	ct := httpdebug.New(WithLogger(zap.New(core)), httpdebug.WithSequence(), httpdebug.WithEnricher(enrich))
+	client := &http.Client{Transport: ct}
+	resp, err := client.Get(server.URL + "/?client_secret=s3cr3t")
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	failing := httpdebug.New(WithLogger(zap.New(core)), httpdebug.WithTransport(errTransport{err: errors.New("boom")}))
+	req, _ := http.NewRequest("DELETE", "http://example.com/", nil)
+	if _, err := failing.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip = nil error, want error")
+	}
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("logged %v entries, want 2", len(entries))
+	}
+	got := entries[0].ContextMap()
+	if entries[0].Level != zapcore.DebugLevel || entries[0].Message != "httpdebug request" {
+		t.Errorf("entry = %v %q, want debug \"httpdebug request\"", entries[0].Level, entries[0].Message)
+	}
+	wantURL := server.URL + "/?client_secret=REDACTED"
+	if got["method"] != "GET" || got["url"] != wantURL || got["status"] != int64(404) || got["seq"] != uint64(1) {
+		t.Errorf("fields = %v, want GET %v with status 404 and seq 1", got, wantURL)
+	}
+	if curl, _ := got["curl"].(string); !strings.HasPrefix(curl, "curl") || strings.Contains(curl, "s3cr3t") {
+		t.Errorf("curl = %q, want a redacted curl command", curl)
+	}
+	if md, _ := got["metadata"].(map[string]interface{}); md["pod"] != "web-1" {
+		t.Errorf("metadata = %v, want pod=web-1", got["metadata"])
+	}
+
+	got = entries[1].ContextMap()
+	if entries[1].Level != zapcore.WarnLevel || got["error"] != "boom" || got["method"] != "DELETE" {
+		t.Errorf("entry = %v %v, want a warning for the failed DELETE", entries[1].Level, got)
+	}
+	if _, ok := got["status"]; ok {
+		t.Errorf("status = %v for a failed request, want none", got["status"])
+	}
+}
@@ -2,10 +2,16 @@ module github.com/gmlewis/go-httpdebug
 
 go 1.22.0
 
-require golang.org/x/oauth2 v0.17.0
+require (
+	golang.org/x/oauth2 v0.17.0
+	golang.org/x/sys v0.28.0
+	golang.org/x/term v0.27.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/google/go-cmp v0.6.0 // indirect
 	golang.org/x/net v0.33.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/protobuf v1.33.0 // indirect
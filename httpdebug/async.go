@@ -0,0 +1,243 @@
+package httpdebug
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BackpressurePolicy determines what an asynchronous CurlTransport does
+// when its output buffer is full.
+type BackpressurePolicy int
+
+const (
+	// DropOnFull discards new output when the buffer is full.
+	// Discarded entries are counted and reported. This is the default.
+	DropOnFull BackpressurePolicy = iota
+	// BlockOnFull blocks the request until there is room in the buffer.
+	BlockOnFull
+)
+
+// dropNoticeInterval is how often the count of dropped entries is reported.
+var dropNoticeInterval = 10 * time.Second
+
+// WithAsync is a CurlTransportOption that causes output to be written by
+// a background goroutine through a buffer of bufferSize entries,
+// keeping logging off of the request path.
+//
+// When the buffer is full, new output is dropped by default: the request
+// proceeds without waiting, the number of dropped entries is counted (see
+// Dropped), and a "# N entries dropped due to backpressure" line is
+// logged every 10 seconds and on Close. Use WithBackpressure(BlockOnFull)
+// to make requests wait for room in the buffer instead.
+//
+// Flush waits for buffered output to be written, and Close should be
+// called when the transport is no longer needed.
+// A bufferSize of zero or less leaves the transport synchronous.
+func WithAsync(bufferSize int) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.AsyncBufferSize = bufferSize
+	}
+}
+
+// WithBackpressure is a CurlTransportOption that selects what happens
+// when the async buffer is full.
+func WithBackpressure(policy BackpressurePolicy) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Backpressure = policy
+	}
+}
+
+// Dropped returns the total number of entries that were discarded
+// because the async buffer was full.
+func (t *CurlTransport) Dropped() uint64 {
+	t.asyncMu.Lock()
+	a := t.async
+	t.asyncMu.Unlock()
+	if a == nil {
+		return 0
+	}
+	return a.dropped.Load()
+}
+
+// Flush blocks until all output buffered by WithAsync before the call
+// has been written, after reporting any pending repeats counted by
+// WithDedup and waiting for the certificate chains being saved by
+// WithCertChains. Flush is otherwise a no-op for a synchronous or closed
+// transport.
+func (t *CurlTransport) Flush() {
+	t.flushDedup()
+	t.root().certWrites.Wait()
+	t.asyncMu.Lock()
+	a := t.async
+	t.asyncMu.Unlock()
+	if a != nil {
+		a.flush()
+	}
+}
+
+// Close reports any pending repeats counted by WithDedup and requests
+// still suppressed by WithRateLimit, waits for the certificate chains
+// being saved by WithCertChains, drains any buffered output, reports any
+// remaining dropped entries, and stops the background goroutine started
+// by WithAsync. Output after Close is written synchronously. A named
+// transport is also removed from the registry (see Transports).
+func (t *CurlTransport) Close() error {
+	unregisterTransport(t)
+	t.flushDedup()
+	for _, s := range t.root().limiter.summaries(time.Now()) {
+		t.log(s)
+	}
+	t.root().certWrites.Wait()
+	t.asyncMu.Lock()
+	t.asyncClosed = true
+	a := t.async
+	t.asyncMu.Unlock()
+	if a != nil {
+		a.close()
+	}
+	return nil
+}
+
+// flushDedup logs the summary of any pending repeats counted by WithDedup.
+func (t *CurlTransport) flushDedup() {
+	if s := t.dedup.summary(); s != "" {
+		t.log(s)
+	}
+}
+
+// log writes s, with the transport's Prefix at the start of each of its
+// lines, to the logger, either directly or via the async buffer.
+func (t *CurlTransport) log(s string) {
+	if t.Color && t.Format == FormatCurl && colorTerminal() {
+		s = colorize(s)
+	}
+	if t.Prefix != "" {
+		s = t.Prefix + strings.ReplaceAll(s, "\n", "\n"+t.Prefix)
+	}
+	if t.AsyncBufferSize > 0 {
+		if a := t.asyncLogger(); a != nil && a.send(s) {
+			return
+		}
+	}
+	logger(s)
+}
+
+// asyncLogger returns the transport's asyncLogger, starting it if
+// necessary, or nil if the transport has been closed.
+func (t *CurlTransport) asyncLogger() *asyncLogger {
+	t = t.root()
+	t.asyncMu.Lock()
+	defer t.asyncMu.Unlock()
+	if t.async == nil && !t.asyncClosed {
+		t.async = newAsyncLogger(t.AsyncBufferSize, t.Backpressure)
+	}
+	return t.async
+}
+
+// asyncItem is either a line of output or, if flushed is non-nil,
+// a request to be notified once all preceding output has been written.
+type asyncItem struct {
+	s       string
+	flushed chan struct{}
+}
+
+// asyncLogger feeds the logger from a bounded channel.
+type asyncLogger struct {
+	mu     sync.RWMutex
+	closed bool
+	ch     chan asyncItem
+	done   chan struct{}
+	policy BackpressurePolicy
+
+	dropped    atomic.Uint64 // total entries dropped
+	unreported atomic.Uint64 // entries dropped since the last notice
+}
+
+func newAsyncLogger(bufferSize int, policy BackpressurePolicy) *asyncLogger {
+	a := &asyncLogger{
+		ch:     make(chan asyncItem, bufferSize),
+		done:   make(chan struct{}),
+		policy: policy,
+	}
+	go a.run()
+	return a
+}
+
+// send queues s for output. It returns false if the logger is closed.
+func (a *asyncLogger) send(s string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return false
+	}
+
+	if a.policy == BlockOnFull {
+		a.ch <- asyncItem{s: s}
+		return true
+	}
+
+	select {
+	case a.ch <- asyncItem{s: s}:
+	default:
+		a.dropped.Add(1)
+		a.unreported.Add(1)
+	}
+	return true
+}
+
+func (a *asyncLogger) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(dropNoticeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case item, ok := <-a.ch:
+			if !ok {
+				a.reportDropped()
+				return
+			}
+			if item.flushed != nil {
+				a.reportDropped()
+				close(item.flushed)
+				continue
+			}
+			logger(item.s)
+		case <-ticker.C:
+			a.reportDropped()
+		}
+	}
+}
+
+func (a *asyncLogger) reportDropped() {
+	if n := a.unreported.Swap(0); n > 0 {
+		logger(fmt.Sprintf("# %v entries dropped due to backpressure", n))
+	}
+}
+
+// flush waits until all output queued before the call has been written.
+// The flush request is never dropped, regardless of the policy.
+func (a *asyncLogger) flush() {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	a.ch <- asyncItem{flushed: flushed}
+	a.mu.RUnlock()
+	<-flushed
+}
+
+func (a *asyncLogger) close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.ch)
+	}
+	a.mu.Unlock()
+	<-a.done
+}
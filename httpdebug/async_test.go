@@ -0,0 +1,169 @@
+package httpdebug
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWithAsync(t *testing.T) {
+	ct := New(WithAsync(10), WithBackpressure(BlockOnFull))
+	if ct.AsyncBufferSize != 10 {
+		t.Errorf("AsyncBufferSize = %v, want 10", ct.AsyncBufferSize)
+	}
+	if ct.Backpressure != BlockOnFull {
+		t.Errorf("Backpressure = %v, want BlockOnFull", ct.Backpressure)
+	}
+}
+
+// blockingLogger returns a logger that records its output and blocks
+// on each call until release is closed. entered receives a value each
+// time the logger is called.
+func blockingLogger(got *[]string, entered chan<- struct{}, release <-chan struct{}) func(v ...interface{}) {
+	return func(v ...interface{}) {
+		entered <- struct{}{}
+		<-release
+		*got = append(*got, fmt.Sprint(v...))
+	}
+}
+
+func TestAsync_DropOnFull(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+
+	var got []string
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	logger = blockingLogger(&got, entered, release)
+
+	ct := New(WithAsync(1))
+	ct.log("a")
+	<-entered // worker is now blocked on "a"
+	ct.log("b")
+	ct.log("c")
+	ct.log("d")
+	close(release)
+	if err := ct.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+
+	if got, want := ct.Dropped(), uint64(2); got != want {
+		t.Errorf("Dropped = %v, want %v", got, want)
+	}
+	want := []string{"a", "b", "# 2 entries dropped due to backpressure"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("output = %#v, want %#v", got, want)
+	}
+}
+
+func TestAsync_BlockOnFull(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+
+	var got []string
+	logger = func(v ...interface{}) { got = append(got, fmt.Sprint(v...)) }
+
+	ct := New(WithAsync(1), WithBackpressure(BlockOnFull))
+	want := []string{"a", "b", "c", "d"}
+	for _, s := range want {
+		ct.log(s)
+	}
+	if err := ct.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+
+	if got := ct.Dropped(); got != 0 {
+		t.Errorf("Dropped = %v, want 0", got)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("output = %#v, want %#v", got, want)
+	}
+}
+
+func TestAsync_LogAfterClose(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+
+	var got []string
+	logger = func(v ...interface{}) { got = append(got, fmt.Sprint(v...)) }
+
+	ct := New(WithAsync(1))
+	if err := ct.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	ct.log("a")
+
+	if want := []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("output = %#v, want %#v", got, want)
+	}
+}
+
+func TestClose_Synchronous(t *testing.T) {
+	ct := New()
+	if err := ct.Close(); err != nil {
+		t.Errorf("Close = %v, want nil", err)
+	}
+	if got := ct.Dropped(); got != 0 {
+		t.Errorf("Dropped = %v, want 0", got)
+	}
+}
+
+func TestAsync_CloseBeforeLog(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+
+	var got []string
+	logger = func(v ...interface{}) { got = append(got, fmt.Sprint(v...)) }
+
+	ct := New(WithAsync(1))
+	if err := ct.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	ct.log("a")
+
+	if want := []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("output = %#v, want %#v", got, want)
+	}
+}
+
+func TestAsync_Flush(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+
+	var got []string
+	entered := make(chan struct{}, 10)
+	release := make(chan struct{})
+	logger = blockingLogger(&got, entered, release)
+
+	ct := New(WithAsync(4))
+	defer ct.Close()
+	ct.log("a")
+	ct.log("b")
+
+	flushed := make(chan struct{})
+	go func() {
+		ct.Flush()
+		close(flushed)
+	}()
+
+	<-entered // worker is now blocked on "a"
+	select {
+	case <-flushed:
+		t.Fatal("Flush returned before buffered output was written")
+	default:
+	}
+	close(release)
+	<-flushed
+
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("output = %#v, want %#v", got, want)
+	}
+}
+
+func TestFlush_SynchronousAndClosed(t *testing.T) {
+	New().Flush()
+
+	ct := New(WithAsync(1))
+	ct.Close()
+	ct.Flush()
+}
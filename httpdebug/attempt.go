@@ -0,0 +1,26 @@
+package httpdebug
+
+import "context"
+
+// attemptKey is the context key set by WithAttempt.
+type attemptKey struct{}
+
+// WithAttempt returns a copy of ctx that marks a request made with it as
+// attempt n (starting at 1) of a retried call, so that a retrying
+// transport wrapping a CurlTransport can have each dump annotated with
+// "attempt=n". For example:
+//
+//	for n := 1; n <= maxAttempts; n++ {
+//		req = req.WithContext(httpdebug.WithAttempt(ctx, n))
+//		resp, err = ct.RoundTrip(req)
+//		...
+//	}
+func WithAttempt(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, n)
+}
+
+// attempt returns the attempt number set by WithAttempt, or zero.
+func attempt(ctx context.Context) int {
+	n, _ := ctx.Value(attemptKey{}).(int)
+	return n
+}
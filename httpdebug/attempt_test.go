@@ -0,0 +1,61 @@
+package httpdebug
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithAttempt(t *testing.T) {
+	if got := attempt(context.Background()); got != 0 {
+		t.Errorf("attempt(Background) = %v, want 0", got)
+	}
+	if got := attempt(WithAttempt(context.Background(), 3)); got != 3 {
+		t.Errorf("attempt = %v, want 3", got)
+	}
+}
+
+func TestRoundTrip_Attempt(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	var calls int
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New()
+	for n := 1; n <= 2; n++ {
+		req, _ := http.NewRequestWithContext(WithAttempt(context.Background(), n), "GET", url, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("client.Do = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(logged) != 4 {
+		t.Fatalf("logged = %#v, want 4 lines", logged)
+	}
+	if want := "# request attempt=1\ncurl"; !strings.HasPrefix(logged[0], want) {
+		t.Errorf("logged[0] = %q, want prefix %q", logged[0], want)
+	}
+	if want := "# response attempt=1: 503 Service Unavailable in "; !strings.HasPrefix(logged[1], want) {
+		t.Errorf("logged[1] = %q, want prefix %q", logged[1], want)
+	}
+	if want := "# request attempt=2\ncurl"; !strings.HasPrefix(logged[2], want) {
+		t.Errorf("logged[2] = %q, want prefix %q", logged[2], want)
+	}
+	if want := "# response attempt=2: 200 OK in "; !strings.HasPrefix(logged[3], want) {
+		t.Errorf("logged[3] = %q, want prefix %q", logged[3], want)
+	}
+}
@@ -0,0 +1,190 @@
+package httpdebug
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+)
+
+// DefaultMaxBodySize is the number of bytes of a request body that are
+// dumped when MaxBodySize is zero.
+const DefaultMaxBodySize = 1 << 20
+
+// WithMaxBodySize is a CurlTransportOption that limits the number of
+// bytes read from a request body to dump it (see MaxBodySize). A negative
+// n removes the limit.
+func WithMaxBodySize(n int64) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.MaxBodySize = n
+	}
+}
+
+// WithoutBody is a CurlTransportOption that never reads request bodies,
+// so that none are logged (or delayed, as large uploads would be), while
+// the URL and headers still are. The curl command is then followed by a
+// "# request body: omitted" line giving the length of the body, if known.
+// Where a body is wanted for something else, such as a Cassette, it is
+// still read and used there.
+func WithoutBody() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.OmitBody = true
+	}
+}
+
+// maxBodySize returns the effective MaxBodySize.
+func (t *CurlTransport) maxBodySize() int64 {
+	switch {
+	case t.MaxBodySize == 0:
+		return DefaultMaxBodySize
+	case t.MaxBodySize < 0:
+		return math.MaxInt64 - 1
+	}
+	return t.MaxBodySize
+}
+
+// requestBody is the part of a request body that is dumped.
+type requestBody struct {
+	data []byte
+	// truncated reports whether data is only the start of the body.
+	truncated bool
+	// omitted reports whether the body was not read at all (see
+	// WithoutBody).
+	omitted bool
+	// unsent reports whether data, from a streamed body (see teeBody),
+	// is all that had been sent when the response arrived.
+	unsent bool
+}
+
+// teeBody returns a shallow copy of req whose body records its start, up
+// to the MaxBodySize, as the transport sends it, along with the
+// recording, if req has a body but no GetBody to read a fresh copy of it
+// from. Otherwise it returns req and nil. Unlike peekBody, it reads
+// nothing ahead of the transport, so that streaming and full-duplex
+// uploads proceed at once; the body is dumped after the round trip.
+// Since a fail-closed transport must not send a request that it cannot
+// dump, it reads ahead with peekBody instead.
+func (t *CurlTransport) teeBody(req *http.Request) (*http.Request, *bodyTee) {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil || t.OmitBody || t.FailClosed {
+		return req, nil
+	}
+	tee := &bodyTee{ReadCloser: req.Body, limit: t.maxBodySize()}
+	sent := *req
+	sent.Body = tee
+	return &sent, tee
+}
+
+// bodyTee is a request body that records the start of what is read from
+// it. It is safe for concurrent use.
+type bodyTee struct {
+	io.ReadCloser
+	limit int64
+
+	mu   sync.Mutex
+	buf  []byte
+	done bool
+	// err is the error other than io.EOF returned by a read, if any.
+	err error
+}
+
+func (b *bodyTee) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit + 1 - int64(len(b.buf)); room > 0 {
+		b.buf = append(b.buf, p[:min(int64(n), room)]...)
+	}
+	if err != nil {
+		b.done = true
+		if !errors.Is(err, io.EOF) && b.err == nil {
+			b.err = err
+		}
+	}
+	return n, err
+}
+
+// body returns the requestBody for what has been read so far, or the
+// error that reading the body failed with.
+func (b *bodyTee) body() (requestBody, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return requestBody{}, b.err
+	}
+	body := newRequestBody(bytes.Clone(b.buf), b.limit)
+	body.unsent = !b.done && !body.truncated
+	return body, nil
+}
+
+// peekBody returns the start of the body of req, up to the MaxBodySize,
+// without buffering the rest: if req has a GetBody, the start is read from
+// a fresh copy of the body and req is returned unchanged; otherwise it is
+// read from req.Body and the returned request, a shallow copy of req,
+// sends it ahead of the rest of the body. Either way, the request that
+// is sent and its GetBody are left as the caller made them.
+//
+// On error, the returned request sends what was read followed by the
+// error, so that its round trip fails the same way.
+func (t *CurlTransport) peekBody(req *http.Request) (*http.Request, requestBody, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, requestBody{}, nil
+	}
+	if t.OmitBody {
+		return req, requestBody{omitted: true}, nil
+	}
+	limit := t.maxBodySize()
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			defer body.Close()
+			buf, err := io.ReadAll(io.LimitReader(body, limit+1))
+			if err == nil {
+				return req, newRequestBody(buf, limit), nil
+			}
+		}
+		// Fall back to reading req.Body itself.
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	sent := *req
+	rest := req.Body
+	if err != nil {
+		rest = io.NopCloser(errReader{err})
+	}
+	sent.Body = &prefixedBody{Reader: io.MultiReader(bytes.NewReader(buf), rest), Closer: req.Body}
+	return &sent, newRequestBody(buf, limit), err
+}
+
+// newRequestBody returns the requestBody for buf, the start of a body
+// that was read up to limit+1 bytes.
+func newRequestBody(buf []byte, limit int64) requestBody {
+	if int64(len(buf)) > limit {
+		return requestBody{data: buf[:limit], truncated: true}
+	}
+	return requestBody{data: buf}
+}
+
+// prefixedBody is a request body whose start has already been read from
+// the Closer and is replayed by the Reader.
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// truncatedBodyAnnotation returns the comment line noting that only the
+// start of the body of req is dumped, or none of it, or "" if all of it is.
+func (t *CurlTransport) truncatedBodyAnnotation(label string, req *http.Request, body requestBody) string {
+	switch {
+	case body.truncated:
+		return fmt.Sprintf("# request body%v: truncated to the first %v", label, t.size(int64(len(body.data))))
+	case body.unsent:
+		return fmt.Sprintf("# request body%v: only the first %v had been sent when the response arrived", label, t.size(int64(len(body.data))))
+	case body.omitted && req.ContentLength > 0:
+		return fmt.Sprintf("# request body%v: omitted (%v)", label, t.size(req.ContentLength))
+	case body.omitted:
+		return fmt.Sprintf("# request body%v: omitted", label)
+	}
+	return ""
+}
@@ -0,0 +1,237 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestCurlTransport_maxBodySize(t *testing.T) {
+	tests := []struct {
+		opts []CurlTransportOption
+		want int64
+	}{
+		{nil, DefaultMaxBodySize},
+		{[]CurlTransportOption{WithMaxBodySize(10)}, 10},
+		{[]CurlTransportOption{WithMaxBodySize(-1)}, 1<<63 - 2},
+	}
+	for _, tt := range tests {
+		if got := New(tt.opts...).maxBodySize(); got != tt.want {
+			t.Errorf("maxBodySize = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestCurlTransport_peekBody(t *testing.T) {
+	ct := New(WithMaxBodySize(4))
+
+	// A body that GetBody can recreate is read from a copy.
+	req, _ := http.NewRequest("POST", "http://example.com/", strings.NewReader("hello world"))
+	orig := req.Body
+	sent, body, err := ct.peekBody(req)
+	if err != nil || sent != req || req.Body != orig {
+		t.Errorf("peekBody with GetBody = %v, %v, want req unchanged", sent, err)
+	}
+	if string(body.data) != "hell" || !body.truncated {
+		t.Errorf("body = %q, truncated %v, want \"hell\", true", body.data, body.truncated)
+	}
+
+	// Otherwise no more than the limit (plus one byte to detect
+	// truncation) is read ahead, and the rest streams through.
+	src := &countingReader{r: strings.NewReader("hello world")}
+	req, _ = http.NewRequest("POST", "http://example.com/", io.NopCloser(src))
+	orig = req.Body
+	sent, body, err = ct.peekBody(req)
+	if err != nil || sent == req || req.Body != orig || sent.GetBody != nil {
+		t.Fatalf("peekBody = %v, want a copy of req sending the whole body", err)
+	}
+	if src.n > 5 {
+		t.Errorf("peekBody read %v bytes ahead, want at most 5", src.n)
+	}
+	if string(body.data) != "hell" || !body.truncated {
+		t.Errorf("body = %q, truncated %v, want \"hell\", true", body.data, body.truncated)
+	}
+	if all, _ := io.ReadAll(sent.Body); string(all) != "hello world" {
+		t.Errorf("sent body = %q, want the whole body", all)
+	}
+
+	// A body within the limit is not truncated.
+	req, _ = http.NewRequest("POST", "http://example.com/", io.NopCloser(strings.NewReader("hi")))
+	if _, body, _ := ct.peekBody(req); string(body.data) != "hi" || body.truncated {
+		t.Errorf("body = %q, truncated %v, want \"hi\", false", body.data, body.truncated)
+	}
+
+	// A read error is replayed after what was read.
+	boom := errors.New("boom")
+	req, _ = http.NewRequest("POST", "http://example.com/", io.NopCloser(io.MultiReader(strings.NewReader("ab"), errReader{boom})))
+	sent, _, err = ct.peekBody(req)
+	if !errors.Is(err, boom) {
+		t.Fatalf("peekBody = %v, want boom", err)
+	}
+	if all, err := io.ReadAll(sent.Body); string(all) != "ab" || !errors.Is(err, boom) {
+		t.Errorf("sent body = %q, %v, want \"ab\", boom", all, err)
+	}
+}
+
+func TestRoundTrip_StreamingBody(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	var received string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		received = string(buf)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(pw, "line %03d\n", i)
+		}
+		pw.Close()
+	}()
+
+	client.Transport = New(WithMaxBodySize(9))
+	req, _ := http.NewRequest("PUT", url, pr)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("client.Do = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(received) != 900 {
+		t.Errorf("server received %v bytes, want 900", len(received))
+	}
+	if len(logged) != 2 || !strings.HasSuffix(logged[0], "--data-raw 'line 000\n'") || logged[1] != "# request body: truncated to the first 9 B" {
+		t.Errorf("logged = %#v, want the start of the body and a truncation note", logged)
+	}
+}
+
+func TestRoundTrip_StreamingBodyEarlyResponse(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	// The response arrives after the first chunk, while the rest of the
+	// body is still to be written, as with a full-duplex upload.
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go io.WriteString(pw, "hello ")
+	ct := New(WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		buf := make([]byte, 6)
+		if _, err := io.ReadFull(req.Body, buf); err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})))
+	req, _ := http.NewRequest("PUT", "http://example.com/", pr)
+	resp, err := ct.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(logged) != 2 || !strings.HasSuffix(logged[0], "--data-raw 'hello '") || logged[1] != "# request body: only the first 6 B had been sent when the response arrived" {
+		t.Errorf("logged = %#v, want the sent part of the body and a note", logged)
+	}
+}
+
+func TestWithoutBody(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	var received string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		received = string(buf)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithoutBody())
+	tests := []struct {
+		body io.Reader
+		want string
+	}{
+		{strings.NewReader(`{"ssn":"123-45-6789"}`), "# request body: omitted (21 B)"},
+		{io.NopCloser(strings.NewReader(`{"ssn":"123-45-6789"}`)), "# request body: omitted"},
+	}
+	for _, tt := range tests {
+		logged = nil
+		req, _ := http.NewRequest("POST", url, tt.body)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("client.Do = %v", err)
+		}
+		resp.Body.Close()
+
+		if received != `{"ssn":"123-45-6789"}` {
+			t.Errorf("server received %q, want the whole body", received)
+		}
+		wantCurl := fmt.Sprintf("curl -X POST \\\n  %v \\\n  -H 'Content-Type: application/json'", url)
+		if len(logged) != 2 || logged[0] != wantCurl || logged[1] != tt.want {
+			t.Errorf("logged = %#v, want %q and %q", logged, wantCurl, tt.want)
+		}
+	}
+}
+
+func TestRoundTrip_TruncatedBodyRedaction(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	// The body is cut short in the middle of the secret, so that it
+	// cannot be parsed to redact it.
+	const secret = `{"user":"alice","password":"REQSECRETREQSECRET"}`
+	bodies := map[string]func() io.Reader{
+		"with GetBody": func() io.Reader { return strings.NewReader(secret) },
+		"streamed":     func() io.Reader { return io.NopCloser(strings.NewReader(secret)) },
+	}
+	for name, body := range bodies {
+		logged = nil
+		ct := New(WithSecretBodyField("password"), WithMaxBodySize(34), WithVerbosity(3), WithCapture(1),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				io.Copy(io.Discard, req.Body)
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			})))
+		req, _ := http.NewRequest("POST", "http://example.com/", body())
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ct.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("%v: RoundTrip = %v", name, err)
+		}
+		resp.Body.Close()
+
+		all := strings.Join(logged, "\n")
+		if strings.Contains(all, "REQSEC") || !strings.Contains(all, "--data-raw '"+unredactableBody+"'") {
+			t.Errorf("%v: logged = %#v, want the body omitted", name, logged)
+		}
+		if x, ok := ct.Last(); !ok || x.RequestBody != nil {
+			t.Errorf("%v: captured request body = %q, want none", name, x.RequestBody)
+		}
+	}
+}
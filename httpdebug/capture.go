@@ -0,0 +1,286 @@
+package httpdebug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxCaptureBody is the maximum number of response body bytes
+// retained for each captured Exchange.
+const maxCaptureBody = 64 << 10
+
+// Exchange is a captured request/response pair, or a Marker.
+// All values are already redacted.
+type Exchange struct {
+	// Marker, if non-nil, makes this a marker inserted by Mark rather
+	// than an exchange, and the other fields are empty.
+	Marker *Marker `json:"marker,omitempty"`
+	// Request is the dumped request.
+	Request *Entry `json:"request,omitempty"`
+	// RequestHeader holds the request headers.
+	RequestHeader http.Header `json:"request_header,omitempty"`
+	// RequestBody holds the request body, unless it was truncated (see
+	// WithMaxBodySize), not yet sent in full when the response arrived,
+	// omitted (see WithoutBody), JSON that could not be redacted, or
+	// longer than the bytes retained of response bodies.
+	RequestBody []byte `json:"request_body,omitempty"`
+	// StatusCode is the response status code, or zero if the round
+	// trip failed.
+	StatusCode int `json:"status_code,omitempty"`
+	// Status is the response status line, e.g. "200 OK".
+	Status string `json:"status,omitempty"`
+	// Header holds the response headers.
+	Header http.Header `json:"header,omitempty"`
+	// Body holds the first bytes of the response body, with any
+	// secrets redacted, once the caller has read it to the end or
+	// closed it. A JSON body that cannot be parsed, e.g. because it was
+	// truncated, is not retained if SecretBodyFields or a RedactFunc
+	// might apply to it.
+	Body []byte `json:"body,omitempty"`
+	// BodyTruncated reports whether Body was truncated.
+	BodyTruncated bool `json:"body_truncated,omitempty"`
+	// Duration is the time until the response headers were received.
+	Duration time.Duration `json:"duration"`
+	// Err is the error returned by the round trip, if any.
+	Err string `json:"error,omitempty"`
+}
+
+// Capture keeps the most recent exchanges made through a CurlTransport
+// in memory. It is safe for concurrent use.
+type Capture struct {
+	mu        sync.Mutex
+	exchanges []*Exchange
+	next      int
+	full      bool
+}
+
+// NewCapture returns a Capture that retains the last size exchanges.
+func NewCapture(size int) *Capture {
+	if size < 1 {
+		size = 1
+	}
+	return &Capture{exchanges: make([]*Exchange, size)}
+}
+
+// size returns the number of exchanges that c retains.
+func (c *Capture) size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.exchanges)
+}
+
+// WithCapture is a CurlTransportOption that keeps the last size
+// request/response pairs in memory. See CurlTransport.Captured.
+func WithCapture(size int) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Capture = NewCapture(size)
+	}
+}
+
+// Captured returns copies of the retained exchanges, oldest first.
+func (c *Capture) Captured() []Exchange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var result []Exchange
+	if c.full {
+		for _, x := range c.exchanges[c.next:] {
+			result = append(result, *x)
+		}
+	}
+	for _, x := range c.exchanges[:c.next] {
+		result = append(result, *x)
+	}
+	return result
+}
+
+// Last returns a copy of the most recent exchange, if any.
+func (c *Capture) Last() (Exchange, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := c.next - 1
+	if i < 0 {
+		if !c.full {
+			return Exchange{}, false
+		}
+		i = len(c.exchanges) - 1
+	}
+	return *c.exchanges[i], true
+}
+
+// Clear discards all retained exchanges.
+func (c *Capture) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.exchanges {
+		c.exchanges[i] = nil
+	}
+	c.next = 0
+	c.full = false
+}
+
+// Archive writes the retained exchanges to s under name as a JSON array,
+// oldest first, e.g. to keep the traffic that led to a CI failure.
+func (c *Capture) Archive(ctx context.Context, s Storage, name string) error {
+	exchanges := c.Captured()
+	if exchanges == nil {
+		exchanges = []Exchange{}
+	}
+	buf, err := json.MarshalIndent(exchanges, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.WriteFile(ctx, name, append(buf, '\n'))
+}
+
+// add retains x, evicting the oldest exchange if necessary.
+func (c *Capture) add(x *Exchange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exchanges[c.next] = x
+	c.next++
+	if c.next == len(c.exchanges) {
+		c.next = 0
+		c.full = true
+	}
+}
+
+// appendBody appends buf to the body of x, up to maxCaptureBody bytes.
+func (c *Capture) appendBody(x *Exchange, buf []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if room := maxCaptureBody - len(x.Body); len(buf) > room {
+		buf = buf[:room]
+		x.BodyTruncated = true
+	}
+	x.Body = append(x.Body, buf...)
+}
+
+// setBody sets the body of x to the redacted body.
+func (c *Capture) setBody(x *Exchange, body []byte, truncated bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	x.Body = body
+	x.BodyTruncated = truncated
+}
+
+// captureBody records the response body as it is read, and stores it,
+// redacted, into an Exchange once it has been read in full or closed.
+type captureBody struct {
+	io.ReadCloser
+	c           *Capture
+	x           *Exchange
+	rules       *Redactor
+	contentType string
+
+	mu   sync.Mutex
+	raw  Exchange
+	done bool
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	if n > 0 && !b.done {
+		b.c.appendBody(&b.raw, p[:n])
+	}
+	b.mu.Unlock()
+	if errors.Is(err, io.EOF) {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *captureBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+// finish stores the redacted body into the Exchange, if it has not been
+// already.
+func (b *captureBody) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return
+	}
+	b.done = true
+	body, ok := b.rules.retainedBody(b.contentType, b.raw.Body)
+	b.c.setBody(b.x, body, b.raw.BodyTruncated || !ok)
+}
+
+// capture records the exchange for e, the dump of req with the body, in
+// the transport's Capture. The response body is recorded as it is read by
+// the caller.
+func (t *CurlTransport) capture(e *Entry, req *http.Request, body requestBody, resp *http.Response, err error, elapsed time.Duration) {
+	x := &Exchange{Request: e, RequestHeader: t.redactHeaders(req.Header), Duration: elapsed}
+	if !body.truncated && !body.unsent && len(body.data) > 0 && len(body.data) <= maxCaptureBody {
+		x.RequestBody, _ = t.rules().retainedBody(req.Header.Get("Content-Type"), body.data)
+	}
+	if err != nil {
+		x.Err = err.Error()
+	}
+	if resp != nil {
+		x.StatusCode = resp.StatusCode
+		x.Status = resp.Status
+		x.Header = t.redactHeaders(resp.Header)
+		if resp.Body != nil {
+			resp.Body = &captureBody{ReadCloser: resp.Body, c: t.Capture, x: x, rules: t.rules(), contentType: resp.Header.Get("Content-Type")}
+		}
+	}
+	t.Capture.add(x)
+}
+
+// Interaction returns the exchange in the form used by cassettes, or nil
+// if it is a marker or its round trip failed.
+func (x *Exchange) Interaction() *Interaction {
+	if x.Request == nil || x.Marker != nil || x.StatusCode == 0 {
+		return nil
+	}
+	return &Interaction{
+		Request: CassetteRequest{
+			Method: x.Request.Method,
+			URL:    x.Request.URL,
+			Header: x.RequestHeader,
+			Body:   x.RequestBody,
+		},
+		Response: CassetteResponse{
+			StatusCode: x.StatusCode,
+			Status:     x.Status,
+			Header:     x.Header,
+			Body:       x.Body,
+		},
+		Duration: x.Duration,
+	}
+}
+
+// Captured returns the exchanges retained by WithCapture, oldest first.
+// It returns nil if capturing is not enabled.
+func (t *CurlTransport) Captured() []Exchange {
+	if t.Capture == nil {
+		return nil
+	}
+	return t.Capture.Captured()
+}
+
+// Last returns the most recent exchange retained by WithCapture, if any.
+func (t *CurlTransport) Last() (Exchange, bool) {
+	if t.Capture == nil {
+		return Exchange{}, false
+	}
+	return t.Capture.Last()
+}
+
+// Clear discards all exchanges retained by WithCapture.
+func (t *CurlTransport) Clear() {
+	if t.Capture != nil {
+		t.Capture.Clear()
+	}
+}
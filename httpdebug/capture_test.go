@@ -0,0 +1,198 @@
+package httpdebug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCapture_Ring(t *testing.T) {
+	c := NewCapture(2)
+	if _, ok := c.Last(); ok {
+		t.Error("Last on empty capture returned ok")
+	}
+	if got := c.Captured(); len(got) != 0 {
+		t.Errorf("Captured = %v, want empty", got)
+	}
+
+	add := func(url string) { c.add(&Exchange{Request: &Entry{URL: url}}) }
+
+	tests := []struct {
+		add      string
+		wantAll  string
+		wantLast string
+	}{
+		{add: "/a", wantAll: "/a", wantLast: "/a"},
+		{add: "/b", wantAll: "/a,/b", wantLast: "/b"},
+		{add: "/c", wantAll: "/b,/c", wantLast: "/c"},
+		{add: "/d", wantAll: "/c,/d", wantLast: "/d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.add, func(t *testing.T) {
+			add(tt.add)
+			var got []string
+			for _, x := range c.Captured() {
+				got = append(got, x.Request.URL)
+			}
+			if got := strings.Join(got, ","); got != tt.wantAll {
+				t.Errorf("Captured = %v, want %v", got, tt.wantAll)
+			}
+			if last, ok := c.Last(); !ok || last.Request.URL != tt.wantLast {
+				t.Errorf("Last = %v, %v, want %v", last.Request, ok, tt.wantLast)
+			}
+		})
+	}
+
+	c.Clear()
+	if _, ok := c.Last(); ok {
+		t.Error("Last after Clear returned ok")
+	}
+	if got := c.Captured(); len(got) != 0 {
+		t.Errorf("Captured after Clear = %v, want empty", got)
+	}
+}
+
+func TestCapture_appendBody(t *testing.T) {
+	c := NewCapture(1)
+	x := &Exchange{}
+	c.appendBody(x, []byte("hello"))
+	if string(x.Body) != "hello" || x.BodyTruncated {
+		t.Errorf("Body = %q, truncated = %v", x.Body, x.BodyTruncated)
+	}
+
+	c.appendBody(x, make([]byte, maxCaptureBody))
+	if len(x.Body) != maxCaptureBody || !x.BodyTruncated {
+		t.Errorf("len(Body) = %v, truncated = %v, want %v, true", len(x.Body), x.BodyTruncated, maxCaptureBody)
+	}
+}
+
+func TestCurlTransport_CaptureDisabled(t *testing.T) {
+	ct := New()
+	ct.Clear()
+	if got := ct.Captured(); got != nil {
+		t.Errorf("Captured = %v, want nil", got)
+	}
+	if _, ok := ct.Last(); ok {
+		t.Error("Last returned ok")
+	}
+}
+
+func TestRoundTrip_Capture(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Authorization", "echoed secret")
+		w.WriteHeader(http.StatusTeapot)
+		fmt.Fprint(w, "short and stout")
+	})
+
+	ct := New(WithCapture(5))
+	client.Transport = ct
+	resp, err := client.Get(url + "/pot")
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("ReadAll = %v", err)
+	}
+	resp.Body.Close()
+
+	x, ok := ct.Last()
+	if !ok {
+		t.Fatal("Last returned no exchange")
+	}
+	if x.Request.URL != url+"/pot" || x.StatusCode != http.StatusTeapot || x.Status != "418 I'm a teapot" {
+		t.Errorf("exchange = %v %v %v", x.Request.URL, x.StatusCode, x.Status)
+	}
+	if got := x.Header.Get("Authorization"); got != "<REDACTED>" {
+		t.Errorf("Authorization = %q, want <REDACTED>", got)
+	}
+	if got := string(x.Body); got != "short and stout" {
+		t.Errorf("Body = %q", got)
+	}
+	if x.Duration <= 0 || x.Duration > time.Minute {
+		t.Errorf("Duration = %v", x.Duration)
+	}
+}
+
+func TestRoundTrip_CaptureRedactsResponseBody(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"s3cr3t","expires_in":3600}`)
+	})
+	mux.HandleFunc("/partial", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"s3cr3t","more":"`+strings.Repeat("x", 4096)+`"}`)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	ct := New(WithCapture(5), WithSecretBodyField("access_token"))
+	client.Transport = ct
+	resp, err := client.Get(url + "/token")
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+	// A body closed after its first bytes cannot be redacted and is
+	// dropped.
+	resp, err = client.Get(url + "/partial")
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	io.ReadFull(resp.Body, make([]byte, 32))
+	resp.Body.Close()
+
+	exchanges := ct.Captured()
+	if len(exchanges) != 2 {
+		t.Fatalf("got %v exchanges, want 2", len(exchanges))
+	}
+	if got := string(exchanges[0].Body); strings.Contains(got, "s3cr3t") || !strings.Contains(got, `"expires_in":3600`) {
+		t.Errorf("Body = %q, want access_token redacted", got)
+	}
+	if x := exchanges[1]; len(x.Body) != 0 || !x.BodyTruncated {
+		t.Errorf("partial Body = %q, truncated = %v, want none, true", x.Body, x.BodyTruncated)
+	}
+
+	rec := httptest.NewRecorder()
+	ct.Capture.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/?format=json", nil))
+	var served []Exchange
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil {
+		t.Fatalf("handler served %q: %v", rec.Body, err)
+	}
+	for _, x := range served {
+		if strings.Contains(string(x.Body), "s3cr3t") {
+			t.Errorf("handler served Body %q, want access_token redacted", x.Body)
+		}
+	}
+}
+
+// errTransport is an http.RoundTripper that always fails.
+type errTransport struct{ err error }
+
+func (e errTransport) RoundTrip(*http.Request) (*http.Response, error) { return nil, e.err }
+
+func TestRoundTrip_CaptureError(t *testing.T) {
+	ct := New(WithCapture(1), WithTransport(errTransport{err: errors.New("no route")}))
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if _, err := ct.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip expected error")
+	}
+
+	x, ok := ct.Last()
+	if !ok || x.Err != "no route" || x.StatusCode != 0 {
+		t.Errorf("exchange = %+v, want error", x)
+	}
+}
@@ -0,0 +1,102 @@
+package httpdebug
+
+import (
+	"net"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxHosts is the number of distinct hosts summarized in the
+// metrics of a transport whose MaxHosts is zero.
+const DefaultMaxHosts = 1000
+
+// OtherHosts is the host under which requests to hosts beyond MaxHosts
+// are summarized.
+const OtherHosts = "other"
+
+// WithMaxHosts is a CurlTransportOption that limits the number of
+// distinct hosts in the metrics (see WriteMetrics and Entry.Host) to n,
+// after any HostAggregation, so that a long-running process calling an
+// API with a subdomain per tenant or a wildcard DNS name does not keep
+// a series per host forever. Requests to further hosts are summarized
+// under OtherHosts. A negative n removes the limit; zero restores
+// DefaultMaxHosts.
+func WithMaxHosts(n int) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.MaxHosts = n
+	}
+}
+
+// WithHostAggregation is a CurlTransportOption that summarizes each
+// request in the metrics under the host that aggregate maps its host
+// to, e.g. DomainSuffix(2) to count "tenant-42.api.example.com" as
+// "*.example.com". A nil aggregate is ignored.
+func WithHostAggregation(aggregate func(host string) string) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if aggregate != nil {
+			ct.HostAggregation = aggregate
+		}
+	}
+}
+
+// DomainSuffix returns a HostAggregation that keeps the last n labels of
+// each domain name, replacing the others with "*", e.g. "*.example.com"
+// for "a.b.example.com" and n = 2. Ports are kept, and IP addresses and
+// names of at most n labels are left alone.
+func DomainSuffix(n int) func(host string) string {
+	return func(host string) string {
+		name, port, err := net.SplitHostPort(host)
+		if err != nil {
+			name, port = host, ""
+		}
+		if net.ParseIP(strings.Trim(name, "[]")) != nil {
+			return host
+		}
+		labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+		if n < 1 || len(labels) <= n {
+			return host
+		}
+		name = "*." + strings.Join(labels[len(labels)-n:], ".")
+		if port != "" {
+			return net.JoinHostPort(name, port)
+		}
+		return name
+	}
+}
+
+// summaryHosts is the set of hosts summarized in the metrics of a
+// transport. It is safe for concurrent use.
+type summaryHosts struct {
+	mu    sync.Mutex
+	hosts map[string]bool
+}
+
+// summaryHost returns the host under which a request to host is
+// summarized: host after any HostAggregation, or OtherHosts once
+// MaxHosts others have been seen.
+func (t *CurlTransport) summaryHost(host string) string {
+	if t.HostAggregation != nil {
+		host = t.HostAggregation(host)
+	}
+	max := t.MaxHosts
+	if max == 0 {
+		max = DefaultMaxHosts
+	}
+	if max < 0 {
+		return host
+	}
+	s := &t.root().summaryHosts
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hosts[host] {
+		return host
+	}
+	if len(s.hosts) >= max {
+		return OtherHosts
+	}
+	if s.hosts == nil {
+		s.hosts = map[string]bool{}
+	}
+	s.hosts[host] = true
+	return host
+}
@@ -0,0 +1,106 @@
+package httpdebug
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDomainSuffix(t *testing.T) {
+	tests := []struct {
+		n    int
+		host string
+		want string
+	}{
+		{n: 2, host: "tenant-42.api.example.com", want: "*.example.com"},
+		{n: 3, host: "tenant-42.api.example.com", want: "*.api.example.com"},
+		{n: 2, host: "a.example.com:8443", want: "*.example.com:8443"},
+		{n: 2, host: "a.example.com.", want: "*.example.com"},
+		{n: 2, host: "example.com", want: "example.com"},
+		{n: 2, host: "localhost:8080", want: "localhost:8080"},
+		{n: 2, host: "10.0.0.1:8080", want: "10.0.0.1:8080"},
+		{n: 2, host: "[2001:db8::1]:443", want: "[2001:db8::1]:443"},
+		{n: 0, host: "a.example.com", want: "a.example.com"},
+	}
+	for _, tt := range tests {
+		if got := DomainSuffix(tt.n)(tt.host); got != tt.want {
+			t.Errorf("DomainSuffix(%v)(%q) = %q, want %q", tt.n, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestCurlTransport_summaryHost(t *testing.T) {
+	ct := New(WithMaxHosts(2))
+	for _, tt := range []struct{ host, want string }{
+		{"a.example.com", "a.example.com"},
+		{"b.example.com", "b.example.com"},
+		{"c.example.com", OtherHosts},
+		{"a.example.com", "a.example.com"},
+	} {
+		if got := ct.summaryHost(tt.host); got != tt.want {
+			t.Errorf("summaryHost(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+
+	if got := New(WithMaxHosts(-1)).summaryHost("a.example.com"); got != "a.example.com" {
+		t.Errorf("summaryHost without a limit = %q, want a.example.com", got)
+	}
+	derived := ct.withOptions([]CurlTransportOption{WithPrefix("[x] ")})
+	if got := derived.summaryHost("d.example.com"); got != OtherHosts {
+		t.Errorf("summaryHost of a derived transport = %q, want %q", got, OtherHosts)
+	}
+}
+
+func TestRoundTrip_HostCardinality(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	// Send the requests for every host to the test server.
+	tr := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, ts.Listener.Addr().String())
+	}}
+	r := &entryRecorder{}
+	ct := New(WithTransport(tr), WithHostAggregation(DomainSuffix(2)), WithMaxHosts(2), WithEntrySink(r))
+	client := &http.Client{Transport: ct}
+	for _, u := range []string{
+		"http://tenant-1.api.example.com/",
+		"http://tenant-2.api.example.com/",
+		"http://status.example.org/",
+		"http://10.0.0.1/",
+	} {
+		resp, err := client.Get(u)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	var hosts []string
+	for _, e := range r.entries {
+		hosts = append(hosts, e.Host)
+	}
+	if got, want := strings.Join(hosts, " "), "*.example.com *.example.com *.example.org other"; got != want {
+		t.Errorf("entry hosts = %v, want %v", got, want)
+	}
+
+	var b strings.Builder
+	if err := ct.WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics = %v", err)
+	}
+	for _, want := range []string{
+		`httpdebug_requests_total{host="*.example.com",method="GET",code="200"} 2`,
+		`httpdebug_requests_total{host="*.example.org",method="GET",code="200"} 1`,
+		`httpdebug_requests_total{host="other",method="GET",code="200"} 1`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WriteMetrics =\n%v\nwant line %v", b.String(), want)
+		}
+	}
+}
@@ -0,0 +1,490 @@
+package httpdebug
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// CassetteMode determines how a CurlTransport uses its Cassette.
+type CassetteMode int
+
+const (
+	// CassetteRecord makes real requests and records each request/response
+	// pair into the cassette, which is saved after every interaction.
+	// Secrets are scrubbed according to the transport's redaction rules
+	// before anything is saved (see WithCassettePlaceholder).
+	CassetteRecord CassetteMode = iota
+	// CassetteReplay serves responses from the cassette without touching
+	// the network. When several interactions match a request, they are
+	// served in the order in which they were recorded, and the last one
+	// is repeated once they have all been served; this replays retry and
+	// backoff scenarios exactly. Requests without a matching interaction
+	// fail with ErrCassetteMiss.
+	CassetteReplay
+	// CassetteNewEpisodes replays matching interactions like
+	// CassetteReplay, but sends requests without a matching interaction
+	// to the network and appends them to the cassette like
+	// CassetteRecord, so that fixtures grow as new code paths are tested.
+	CassetteNewEpisodes
+	// CassetteCompare sends every request to the network and compares
+	// the live response, scrubbed like a recorded one, with the response
+	// of the matching interaction (see CassetteReplay), logging the
+	// differences as found by DiffResponse, e.g.
+	//
+	//	# drift: GET https://api.example.com/v1/users/1
+	//	#   status: 200 OK -> 404 Not Found
+	//
+	// so that upstream API changes show up while debugging. Requests
+	// without a matching interaction are logged too. The cassette is left
+	// unchanged.
+	CassetteCompare
+)
+
+// CassetteEnvVar is the name of the environment variable that New
+// consults to override the CassetteMode of a transport with a Cassette,
+// so that refreshing fixtures needs no code changes. Its recognized
+// values are:
+//
+//	record         CassetteRecord
+//	replay         CassetteReplay
+//	new_episodes   CassetteNewEpisodes
+//	compare        CassetteCompare
+//
+// Any other value, or an unset variable, leaves the mode unchanged.
+// For example:
+//
+//	HTTPDEBUG_CASSETTE=new_episodes go test ./...
+const CassetteEnvVar = "HTTPDEBUG_CASSETTE"
+
+// parseCassetteMode returns the CassetteMode named by s, as in
+// CassetteEnvVar.
+func parseCassetteMode(s string) (CassetteMode, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "record":
+		return CassetteRecord, true
+	case "replay":
+		return CassetteReplay, true
+	case "new_episodes", "new-episodes":
+		return CassetteNewEpisodes, true
+	case "compare":
+		return CassetteCompare, true
+	}
+	return 0, false
+}
+
+// ErrCassetteMiss is returned in CassetteReplay mode when no recorded
+// interaction matches a request.
+var ErrCassetteMiss = errors.New("httpdebug: no matching cassette interaction")
+
+// Cassette is a set of recorded HTTP interactions that can be persisted
+// as a JSON file and replayed later, turning real traffic into test
+// fixtures. It is safe for concurrent use.
+type Cassette struct {
+	// Path is the file the cassette is loaded from and saved to.
+	Path string `json:"-"`
+	// Storage, when non-nil, stores the cassette under the name Path
+	// instead of on the local filesystem.
+	Storage Storage `json:"-"`
+	// Interactions are the recorded request/response pairs, oldest first.
+	Interactions []*Interaction `json:"interactions"`
+
+	mu     sync.Mutex
+	served map[*Interaction]bool // interactions already replayed
+}
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+	// Duration is the time until the response headers were received.
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// CassetteRequest is a recorded request.
+type CassetteRequest struct {
+	Method string       `json:"method"`
+	URL    string       `json:"url"`
+	Header http.Header  `json:"header,omitempty"`
+	Body   CassetteBody `json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded response.
+type CassetteResponse struct {
+	StatusCode int          `json:"status_code"`
+	Status     string       `json:"status"`
+	Header     http.Header  `json:"header,omitempty"`
+	Body       CassetteBody `json:"body,omitempty"`
+}
+
+// CassetteBody is a recorded body. It is stored in the cassette file as
+// a plain string when it is valid UTF-8 and as "base64:"-prefixed
+// base64 otherwise, keeping text fixtures readable.
+type CassetteBody []byte
+
+// MarshalJSON implements the json.Marshaler interface.
+func (b CassetteBody) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) && !bytes.HasPrefix(b, []byte("base64:")) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal("base64:" + base64.StdEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (b *CassetteBody) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if encoded, ok := strings.CutPrefix(s, "base64:"); ok {
+		buf, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return err
+		}
+		*b = buf
+		return nil
+	}
+	*b = []byte(s)
+	return nil
+}
+
+// NewCassette returns an empty cassette that is saved to path.
+func NewCassette(path string) *Cassette {
+	return &Cassette{Path: path}
+}
+
+// LoadCassette reads the cassette saved at path.
+func LoadCassette(path string) (*Cassette, error) {
+	return LoadCassetteFrom(context.Background(), nil, path)
+}
+
+// LoadCassetteFrom reads the cassette saved in s under name. The cassette
+// is saved back to s. A nil s reads from the local filesystem.
+func LoadCassetteFrom(ctx context.Context, s Storage, name string) (*Cassette, error) {
+	c := &Cassette{Path: name, Storage: s}
+	buf, err := c.storage().ReadFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, c); err != nil {
+		return nil, fmt.Errorf("httpdebug: cassette %v: %w", name, err)
+	}
+	return c, nil
+}
+
+// OpenCassette reads the cassette saved at path, or returns an empty
+// cassette that is saved to path if the file does not exist yet. It is
+// convenient with CassetteNewEpisodes, which creates missing fixtures.
+func OpenCassette(path string) (*Cassette, error) {
+	c, err := LoadCassette(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewCassette(path), nil
+	}
+	return c, err
+}
+
+// storage returns the Storage of the cassette, defaulting to the
+// local filesystem.
+func (c *Cassette) storage() Storage {
+	if c.Storage != nil {
+		return c.Storage
+	}
+	return Dir("")
+}
+
+// Save writes the cassette to its Path.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save()
+}
+
+// save writes the cassette to its Path. The caller must hold c.mu.
+func (c *Cassette) save() error {
+	buf, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return c.storage().WriteFile(context.Background(), c.Path, append(buf, '\n'))
+}
+
+// record appends x to the cassette and saves it.
+func (c *Cassette) record(x *Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, x)
+	return c.save()
+}
+
+// find returns the first interaction whose request matches that has not
+// been served yet, or the last matching interaction if they all have.
+func (c *Cassette) find(match func(*CassetteRequest) bool) (*Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var last *Interaction
+	for _, x := range c.Interactions {
+		if !match(&x.Request) {
+			continue
+		}
+		if !c.served[x] {
+			if c.served == nil {
+				c.served = map[*Interaction]bool{}
+			}
+			c.served[x] = true
+			return x, true
+		}
+		last = x
+	}
+	return last, last != nil
+}
+
+// WithCassette is a CurlTransportOption that records requests into, or
+// replays responses from, the provided cassette. For example:
+//
+//	c, err := httpdebug.LoadCassette("testdata/github.json")
+//	...
+//	ct := httpdebug.New(httpdebug.WithCassette(c, httpdebug.CassetteReplay))
+func WithCassette(cassette *Cassette, mode CassetteMode) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Cassette = cassette
+		ct.CassetteMode = mode
+	}
+}
+
+// WithCassettePlaceholder is a CurlTransportOption that stores secrets in
+// recorded cassettes as placeholder instead of the default "REDACTED"
+// markers, e.g. a dummy token that is syntactically valid for the code
+// under test. Secrets are scrubbed from cassettes before they are saved
+// according to the same rules as the dump output, and requests are
+// matched after scrubbing, so a replayed cassette accepts requests that
+// carry either placeholder or real credentials.
+// Empty placeholder is ignored.
+func WithCassettePlaceholder(placeholder string) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if placeholder != "" {
+			ct.CassettePlaceholder = placeholder
+		}
+	}
+}
+
+// WithReplayLatency is a CurlTransportOption that delays each response
+// replayed from the cassette by its recorded duration multiplied by
+// factor (e.g. 0.5 to replay twice as fast), so that latency-sensitive
+// code behaves realistically in offline tests. A factor of zero or less
+// replays responses immediately.
+func WithReplayLatency(factor float64) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.ReplayLatency = factor
+	}
+}
+
+// WithDriftNormalizers is a CurlTransportOption that sets the
+// normalizers applied to both responses before they are compared in
+// CassetteCompare mode, instead of the DefaultNormalizers. Pass them
+// along with DefaultNormalizers to extend rather than replace them;
+// passing none restores the defaults.
+func WithDriftNormalizers(normalizers ...Normalizer) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.DriftNormalizers = normalizers
+	}
+}
+
+// logDrift logs how the live response to req differs from the recorded
+// one, or that none was recorded.
+func (t *CurlTransport) logDrift(req *http.Request, diffs []string, recorded bool) {
+	line := fmt.Sprintf("# drift: %v %v", req.Method, t.sanitizeURL(req.URL))
+	if !recorded {
+		t.log(line + ": no recorded interaction")
+		return
+	}
+	lines := []string{line}
+	for _, diff := range diffs {
+		lines = append(lines, "#   "+oneLine(diff))
+	}
+	t.log(strings.Join(lines, "\n"))
+}
+
+// cassetteTransport is the http.RoundTripper that records to, or
+// replays from, a Cassette.
+type cassetteTransport struct {
+	cassette *Cassette
+	mode     CassetteMode
+	latency  float64
+	redactor *Redactor
+	base     http.RoundTripper
+	// drift, in CassetteCompare mode, reports how the live response to
+	// req differs from the recorded one, or that none was recorded if
+	// recorded is false.
+	drift       func(req *http.Request, diffs []string, recorded bool)
+	normalizers []Normalizer
+}
+
+func (c *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	scrubbed := c.scrubRequest(req, body)
+	if c.mode == CassetteCompare {
+		return c.compare(req, &scrubbed)
+	}
+	if c.mode != CassetteRecord {
+		contentType := req.Header.Get("Content-Type")
+		match := func(r *CassetteRequest) bool { return c.matches(r, &scrubbed, contentType) }
+		if x, ok := c.cassette.find(match); ok {
+			return c.replay(req, x)
+		}
+		if c.mode == CassetteReplay {
+			return nil, fmt.Errorf("%w for %v %v", ErrCassetteMiss, req.Method, req.URL)
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+
+	recorded, err := ReadCassetteResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &Interaction{
+		Request:  scrubbed,
+		Response: c.scrubResponse(recorded),
+		Duration: elapsed,
+	}
+	if err := c.cassette.record(x); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("httpdebug: recording cassette: %w", err)
+	}
+	return resp, nil
+}
+
+// compare sends req, which is scrubbed as recorded, to the network and
+// reports how its response differs from the recorded one.
+func (c *cassetteTransport) compare(req *http.Request, scrubbed *CassetteRequest) (*http.Response, error) {
+	contentType := req.Header.Get("Content-Type")
+	x, found := c.cassette.find(func(r *CassetteRequest) bool { return c.matches(r, scrubbed, contentType) })
+
+	resp, err := c.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		c.drift(req, nil, false)
+		return resp, nil
+	}
+	live, err := ReadCassetteResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	got := c.scrubResponse(live)
+	if diffs := DiffResponse(&x.Response, &got, c.normalizers...); len(diffs) > 0 {
+		c.drift(req, diffs, true)
+	}
+	return resp, nil
+}
+
+// scrubRequest returns req with the given body as it is recorded, with
+// any secrets scrubbed.
+func (c *cassetteTransport) scrubRequest(req *http.Request, body []byte) CassetteRequest {
+	return CassetteRequest{
+		Method: req.Method,
+		URL:    c.redactor.URL(req.URL),
+		Header: c.redactor.scrubHeader(req.Header),
+		Body:   c.redactor.Body(req.Header.Get("Content-Type"), body),
+	}
+}
+
+// scrubResponse returns r as it is recorded, with any secrets scrubbed.
+func (c *cassetteTransport) scrubResponse(r *CassetteResponse) CassetteResponse {
+	scrubbed := *r
+	scrubbed.Header = c.redactor.scrubHeader(r.Header)
+	scrubbed.Body = c.redactor.Body(r.Header.Get("Content-Type"), r.Body)
+	return scrubbed
+}
+
+// matches reports whether the recorded request r matches the scrubbed
+// live request, whose body has the given contentType. The recorded URL
+// and body are scrubbed again, so that cassettes recorded without
+// scrubbing (or with other placeholders) still match.
+func (c *cassetteTransport) matches(r, scrubbed *CassetteRequest, contentType string) bool {
+	if r.Method != scrubbed.Method {
+		return false
+	}
+	if u, err := url.Parse(r.URL); err != nil || c.redactor.URL(u) != scrubbed.URL {
+		return false
+	}
+	return bytes.Equal(c.redactor.Body(contentType, r.Body), scrubbed.Body)
+}
+
+// replay returns the recorded response of x for req, after the replay
+// latency (if any).
+func (c *cassetteTransport) replay(req *http.Request, x *Interaction) (*http.Response, error) {
+	if c.latency > 0 && x.Duration > 0 {
+		timer := time.NewTimer(time.Duration(float64(x.Duration) * c.latency))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return x.Response.response(req), nil
+}
+
+// response returns a new http.Response for req built from r.
+func (r *CassetteResponse) response(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	status := r.Status
+	if status == "" {
+		status = strconv.Itoa(r.StatusCode) + " " + http.StatusText(r.StatusCode)
+	}
+	return &http.Response{
+		Status:        status,
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// readBody reads and closes the body of req, and returns it along with a
+// shallow copy of req whose body yields it again, since a RoundTripper
+// must not modify req.
+func readBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil {
+		return req, nil, nil
+	}
+	buf, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	sent := *req
+	sent.Body = io.NopCloser(bytes.NewReader(buf))
+	return &sent, buf, nil
+}
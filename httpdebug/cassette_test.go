@@ -0,0 +1,499 @@
+package httpdebug
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCassetteBody_JSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body CassetteBody
+		want string
+	}{
+		{name: "text", body: CassetteBody(`{"a":1}`), want: `"{\"a\":1}"`},
+		{name: "binary", body: CassetteBody{0xff, 0x00}, want: `"base64:/wA="`},
+		{name: "text that looks encoded", body: CassetteBody("base64:abc"), want: `"base64:YmFzZTY0OmFiYw=="`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(buf) != tt.want {
+				t.Errorf("Marshal = %s, want %s", buf, tt.want)
+			}
+
+			var got CassetteBody
+			if err := json.Unmarshal(buf, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.body) {
+				t.Errorf("Unmarshal = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestLoadCassette_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadCassette(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("LoadCassette of missing file expected error")
+	}
+
+	path := filepath.Join(dir, "empty.json")
+	if err := NewCassette(path).Save(); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := LoadCassette(path); err != nil || len(c.Interactions) != 0 {
+		t.Errorf("LoadCassette = %v, %v, want empty cassette", c, err)
+	}
+}
+
+func TestCassette_RecordAndReplay(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+
+	var hits int
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Hits", fmt.Sprint(hits))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%v %s", r.Method, body)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	post := func(t *testing.T, ct *CurlTransport, body string) (*http.Response, string) {
+		t.Helper()
+		client.Transport = ct
+		resp, err := client.Post(url+"/echo", "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("client.Post = %v", err)
+		}
+		defer resp.Body.Close()
+		got, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(got)
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := New(WithCassette(NewCassette(path), CassetteRecord))
+	if _, got := post(t, recorder, "one"); got != "POST one" {
+		t.Errorf("recorded body = %q", got)
+	}
+	post(t, recorder, "two")
+
+	c, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette = %v", err)
+	}
+	if len(c.Interactions) != 2 {
+		t.Fatalf("got %v interactions, want 2", len(c.Interactions))
+	}
+	if c.Interactions[0].Duration <= 0 {
+		t.Errorf("recorded Duration = %v, want > 0", c.Interactions[0].Duration)
+	}
+
+	player := New(WithCassette(c, CassetteReplay))
+	resp, got := post(t, player, "two")
+	if got != "POST two" || resp.StatusCode != http.StatusCreated || resp.Header.Get("X-Hits") != "2" {
+		t.Errorf("replayed %v %q X-Hits=%v", resp.Status, got, resp.Header.Get("X-Hits"))
+	}
+	if hits != 2 {
+		t.Errorf("server hits = %v, want 2", hits)
+	}
+
+	client.Transport = player
+	_, err = client.Post(url+"/echo", "text/plain", strings.NewReader("three"))
+	if !errors.Is(err, ErrCassetteMiss) {
+		t.Errorf("unmatched request error = %v, want ErrCassetteMiss", err)
+	}
+}
+
+func TestCassette_RecordError(t *testing.T) {
+	c := NewCassette("/nonexistent-dir/cassette.json")
+	ct := New(WithCassette(c, CassetteRecord), WithTransport(errTransport{err: errors.New("boom")}))
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	if _, err := ct.RoundTrip(req); err == nil || err.Error() != "boom" {
+		t.Errorf("RoundTrip = %v, want boom", err)
+	}
+}
+
+func TestCassette_RequestUnmodified(t *testing.T) {
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body)), Request: req}, nil
+	})
+	rt := New(WithCassette(NewCassette(filepath.Join(t.TempDir(), "cassette.json")), CassetteRecord), WithTransport(base)).transport()
+
+	req, _ := http.NewRequest("POST", "http://example.com/", io.NopCloser(strings.NewReader("payload")))
+	body := req.Body
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip = %v", err)
+	}
+	if got, _ := io.ReadAll(resp.Body); string(got) != "payload" {
+		t.Errorf("sent body = %q, want %q", got, "payload")
+	}
+	if req.Body != body {
+		t.Error("RoundTrip replaced the body of the request")
+	}
+}
+
+func TestCassette_SequentialReplay(t *testing.T) {
+	ok := &Interaction{
+		Request:  CassetteRequest{Method: "GET", URL: "http://example.com/flaky"},
+		Response: CassetteResponse{StatusCode: http.StatusOK, Body: CassetteBody("ok")},
+	}
+	c := &Cassette{Interactions: []*Interaction{
+		{
+			Request:  CassetteRequest{Method: "GET", URL: "http://example.com/flaky"},
+			Response: CassetteResponse{StatusCode: http.StatusInternalServerError},
+		},
+		{
+			Request:  CassetteRequest{Method: "GET", URL: "http://example.com/other"},
+			Response: CassetteResponse{StatusCode: http.StatusNotFound},
+		},
+		ok,
+	}}
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	ct := New(WithCassette(c, CassetteReplay))
+	var got []int
+	for i := 0; i < 4; i++ {
+		req, _ := http.NewRequest("GET", "http://example.com/flaky", nil)
+		resp, err := ct.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip = %v", err)
+		}
+		resp.Body.Close()
+		got = append(got, resp.StatusCode)
+	}
+
+	if want := []int{500, 200, 200, 200}; !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed status codes = %v, want %v", got, want)
+	}
+}
+
+func TestCassette_ReplayLatency(t *testing.T) {
+	c := &Cassette{Interactions: []*Interaction{{
+		Request:  CassetteRequest{Method: "GET", URL: "http://example.com/slow"},
+		Response: CassetteResponse{StatusCode: http.StatusOK},
+		Duration: 200 * time.Millisecond,
+	}}}
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	tests := []struct {
+		name    string
+		factor  float64
+		min     time.Duration
+		max     time.Duration
+		timeout time.Duration
+		wantErr error
+	}{
+		{name: "immediate", max: 100 * time.Millisecond},
+		{name: "scaled", factor: 0.5, min: 100 * time.Millisecond, max: 190 * time.Millisecond},
+		{name: "canceled", factor: 10, max: 500 * time.Millisecond, timeout: 20 * time.Millisecond, wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			ct := New(WithCassette(c, CassetteReplay), WithReplayLatency(tt.factor))
+			req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/slow", nil)
+
+			start := time.Now()
+			resp, err := ct.RoundTrip(req)
+			elapsed := time.Since(start)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RoundTrip = %v, want %v", err, tt.wantErr)
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if elapsed < tt.min || elapsed > tt.max {
+				t.Errorf("RoundTrip took %v, want between %v and %v", elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestCassette_NewEpisodes(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+
+	var hits int
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		fmt.Fprintf(w, "live %v", r.URL.Path)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c, err := OpenCassette(path)
+	if err != nil {
+		t.Fatalf("OpenCassette = %v", err)
+	}
+	c.Interactions = []*Interaction{{
+		Request:  CassetteRequest{Method: "GET", URL: url + "/old"},
+		Response: CassetteResponse{StatusCode: http.StatusOK, Body: CassetteBody("recorded /old")},
+	}}
+	client.Transport = New(WithCassette(c, CassetteNewEpisodes))
+
+	var got []string
+	for _, p := range []string{"/old", "/new", "/new"} {
+		resp, err := client.Get(url + p)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		got = append(got, string(body))
+	}
+
+	if want := []string{"recorded /old", "live /new", "live /new"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bodies = %q, want %q", got, want)
+	}
+	if hits != 1 {
+		t.Errorf("server hits = %v, want 1", hits)
+	}
+	saved, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette = %v", err)
+	}
+	if len(saved.Interactions) != 2 || saved.Interactions[1].Request.URL != url+"/new" {
+		t.Errorf("saved interactions = %+v, want /old and /new", saved.Interactions)
+	}
+}
+
+func TestRoundTrip_CassetteCompare(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "live-id")
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprint(w, `{"id":1,"name":"live","token":"s3cr3t"}`)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	jsonHeader := http.Header{"Content-Type": {"application/json"}, "X-Request-Id": {"recorded-id"}}
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c := NewCassette(path)
+	c.Interactions = []*Interaction{
+		{
+			Request:  CassetteRequest{Method: "GET", URL: url + "/same"},
+			Response: CassetteResponse{StatusCode: 200, Status: "200 OK", Header: jsonHeader, Body: CassetteBody(`{"name": "live", "id": 1, "token": "REDACTED"}`)},
+		},
+		{
+			Request:  CassetteRequest{Method: "GET", URL: url + "/gone"},
+			Response: CassetteResponse{StatusCode: 200, Status: "200 OK", Header: jsonHeader, Body: CassetteBody(`{"id":1,"name":"recorded","token":"REDACTED"}`)},
+		},
+	}
+	ct := New(WithCassette(c, CassetteCompare), WithSecretBodyField("token"), WithVerbosity(int(VerbosityLine)), WithTransport(&http.Transport{}))
+	client.Transport = ct
+
+	for _, p := range []string{"/same", "/gone", "/new"} {
+		resp, err := client.Get(url + p)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if want := `{"id":1,"name":"live","token":"s3cr3t"}`; string(body) != want {
+			t.Errorf("GET %v body = %q, want the live body %q", p, body, want)
+		}
+	}
+
+	var drifts []string
+	for _, line := range logged {
+		if strings.HasPrefix(line, "# drift") {
+			drifts = append(drifts, line)
+		}
+	}
+	want := []string{
+		"# drift: GET " + url + "/gone\n#   status: 200 OK -> 404 Not Found\n#   body $.name: \"recorded\" -> \"live\"",
+		"# drift: GET " + url + "/new: no recorded interaction",
+	}
+	if !reflect.DeepEqual(drifts, want) {
+		t.Errorf("drifts =\n%v\nwant:\n%v", strings.Join(drifts, "\n"), strings.Join(want, "\n"))
+	}
+	if len(c.Interactions) != 2 {
+		t.Errorf("Interactions = %v, want the cassette unchanged", c.Interactions)
+	}
+
+	// Custom normalizers replace the defaults, so request IDs differ.
+	logged = nil
+	ct = New(WithCassette(c, CassetteCompare), WithSecretBodyField("token"), WithVerbosity(int(VerbosityLine)), WithDriftNormalizers(IgnoreHeaders("Content-Length", "Date")), WithTransport(&http.Transport{}))
+	client.Transport = ct
+	resp, err := client.Get(url + "/same")
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+	if len(logged) != 2 || !strings.Contains(logged[1], "X-Request-Id") {
+		t.Errorf("logged = %q, want a drift in X-Request-Id", logged)
+	}
+}
+
+func TestNew_CassetteEnvVar(t *testing.T) {
+	tests := []struct {
+		value string
+		want  CassetteMode
+	}{
+		{value: "", want: CassetteReplay},
+		{value: "bogus", want: CassetteReplay},
+		{value: "record", want: CassetteRecord},
+		{value: "new_episodes", want: CassetteNewEpisodes},
+		{value: " New-Episodes ", want: CassetteNewEpisodes},
+		{value: "compare", want: CassetteCompare},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(CassetteEnvVar, tt.value)
+			ct := New(WithCassette(NewCassette("unused.json"), CassetteReplay))
+			if ct.CassetteMode != tt.want {
+				t.Errorf("CassetteMode = %v, want %v", ct.CassetteMode, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenCassette_Existing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c := NewCassette(path)
+	c.Interactions = []*Interaction{{Request: CassetteRequest{Method: "GET", URL: "http://example.com/"}}}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := OpenCassette(path)
+	if err != nil || len(got.Interactions) != 1 {
+		t.Errorf("OpenCassette = %v, %v, want 1 interaction", got, err)
+	}
+}
+
+func TestCassette_Scrubbing(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+
+	var hits int
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Set-Cookie", "session=s3cr3t")
+		w.Header().Add("Set-Cookie", "theme=dark")
+		fmt.Fprint(w, `{"token":"t0k3n","user":"me"}`)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	login := func(t *testing.T, ct *CurlTransport, secret, password string) string {
+		t.Helper()
+		client.Transport = ct
+		req, _ := http.NewRequest("POST", url+"/login?client_secret="+secret, strings.NewReader(`{"user":"me","password":"`+password+`"}`))
+		req.Header.Set("Authorization", "Bearer "+secret)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("client.Do = %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	opts := []CurlTransportOption{
+		WithSecretHeader("Set-Cookie"),
+		WithSecretBodyField("password"),
+		WithSecretBodyField("token"),
+		WithCassettePlaceholder("PLACEHOLDER"),
+	}
+	recorder := New(append(opts, WithCassette(NewCassette(path), CassetteRecord))...)
+	if got, want := login(t, recorder, "abc.def.ghi", "hunter2"), `{"token":"t0k3n","user":"me"}`; got != want {
+		t.Errorf("live body = %v, want %v", got, want)
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"abc", "def", "hunter2", "s3cr3t", "t0k3n"} {
+		if strings.Contains(string(buf), secret) {
+			t.Errorf("cassette contains secret %q:\n%s", secret, buf)
+		}
+	}
+
+	c, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette = %v", err)
+	}
+	x := c.Interactions[0]
+	if got, want := x.Request.URL, url+"/login?client_secret=PLACEHOLDER"; got != want {
+		t.Errorf("recorded URL = %v, want %v", got, want)
+	}
+	if got, want := x.Request.Header.Get("Authorization"), "PLACEHOLDER"; got != want {
+		t.Errorf("recorded Authorization = %v, want %v", got, want)
+	}
+	if got, want := x.Response.Header.Values("Set-Cookie"), []string{"PLACEHOLDER", "PLACEHOLDER"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded Set-Cookie = %v, want %v", got, want)
+	}
+
+	// The replayed cassette accepts both placeholder and other credentials.
+	player := New(append(opts, WithCassette(c, CassetteReplay))...)
+	for _, secret := range []string{"PLACEHOLDER", "xyz"} {
+		if got, want := login(t, player, secret, secret), `{"token":"PLACEHOLDER","user":"me"}`; got != want {
+			t.Errorf("replayed body with credentials %q = %v, want %v", secret, got, want)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("server hits = %v, want 1", hits)
+	}
+}
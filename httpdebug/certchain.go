@@ -0,0 +1,78 @@
+package httpdebug
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WithCertChains is a CurlTransportOption that saves the certificate
+// chain presented by each distinct TLS server contacted (up to 1024 of
+// them) to s, for analyzing trust issues offline, e.g. with
+// "openssl verify". Each chain is written once per transport, as
+// "HOST.pem" (or "HOST_PORT.pem" for a port other than 443), the
+// certificates in the order the server sent them, each preceded by a
+// comment line giving its subject, issuer, and expiry. A nil s is
+// ignored.
+//
+// Chains are written in the background, so that a slow Storage, such as
+// an ObjectStorage uploading to a bucket, does not delay the response.
+// The line reporting each write is logged once it completes, and Flush
+// and Close wait for any writes still pending.
+func WithCertChains(s Storage) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if s != nil {
+			ct.CertChains = s
+		}
+	}
+}
+
+// saveCertChain saves the certificate chain of resp, the response to
+// req, to the CertChains in the background if it is from a host not seen
+// before, and then logs what was done.
+func (t *CurlTransport) saveCertChain(req *http.Request, label string, resp *http.Response) {
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return
+	}
+	host := req.URL.Host
+	if !t.root().certHosts.add(host) {
+		return
+	}
+	name := certChainName(host)
+	data := encodeCertChain(resp.TLS.PeerCertificates)
+	// The request may be canceled as soon as its response has arrived.
+	ctx := context.WithoutCancel(req.Context())
+	pending := &t.root().certWrites
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		if err := t.CertChains.WriteFile(ctx, name, data); err != nil {
+			t.log(fmt.Sprintf("# httpdebug: saving certificate chain of %v: %v", host, err))
+			return
+		}
+		t.log(fmt.Sprintf("# tls%v: saved certificate chain of %v to %v", label, host, name))
+	}()
+}
+
+// certChainName returns the name under which the certificate chain of
+// host (with an optional port) is saved.
+func certChainName(host string) string {
+	host = strings.TrimSuffix(host, ":443")
+	host = strings.NewReplacer(":", "_", "[", "", "]", "").Replace(host)
+	return host + ".pem"
+}
+
+// encodeCertChain returns certs as PEM, each preceded by a comment.
+func encodeCertChain(certs []*x509.Certificate) []byte {
+	var buf bytes.Buffer
+	for i, cert := range certs {
+		fmt.Fprintf(&buf, "# %v: %v\n#    issued by %v, valid until %v\n", i, cert.Subject, cert.Issuer, cert.NotAfter.UTC().Format(time.RFC3339))
+		pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,139 @@
+package httpdebug
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingStorage is a Storage whose writes always fail.
+type failingStorage struct{ Dir }
+
+func (failingStorage) WriteFile(ctx context.Context, name string, data []byte) error {
+	return errors.New("disk full")
+}
+
+func TestCertChainName(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"example.com", "example.com.pem"},
+		{"example.com:443", "example.com.pem"},
+		{"127.0.0.1:8443", "127.0.0.1_8443.pem"},
+		{"[::1]:8443", "__1_8443.pem"},
+	}
+	for _, tt := range tests {
+		if got := certChainName(tt.host); got != tt.want {
+			t.Errorf("certChainName(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestWithCertChains(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	dir := t.TempDir()
+	ct := New(WithCertChains(Dir(dir)), WithTransport(server.Client().Transport))
+	client := ct.Client()
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+		// Wait for the chain, saved in the background.
+		ct.Flush()
+	}
+
+	name := certChainName(strings.TrimPrefix(server.URL, "https://"))
+	var saved []string
+	for _, s := range logged {
+		if strings.HasPrefix(s, "# tls") {
+			saved = append(saved, s)
+		}
+	}
+	if want := fmt.Sprintf("# tls: saved certificate chain of %v to %v", strings.TrimPrefix(server.URL, "https://"), name); len(saved) != 1 || saved[0] != want {
+		t.Errorf("logged %q, want only %q", saved, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("# 0: O=Acme Co\n#    issued by O=Acme Co, valid until ")) {
+		t.Errorf("chain starts %q, want a comment describing the certificate", data[:min(len(data), 80)])
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || !bytes.Equal(block.Bytes, server.Certificate().Raw) {
+		t.Error("saved chain does not hold the server's certificate")
+	}
+
+	// Failures are logged without failing the request.
+	logged = nil
+	ct = New(WithCertChains(failingStorage{}), WithTransport(server.Client().Transport))
+	resp, err := ct.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+	ct.Close()
+	if len(logged) != 2 || !strings.HasSuffix(logged[1], ": disk full") {
+		t.Errorf("logged = %#v, want the dump and the error", logged)
+	}
+
+	if ct := New(WithCertChains(nil)); ct.CertChains != nil {
+		t.Errorf("WithCertChains(nil) set CertChains = %v", ct.CertChains)
+	}
+}
+
+// blockingStorage is a Storage whose writes wait until release is closed.
+type blockingStorage struct {
+	Dir
+	release chan struct{}
+}
+
+func (s blockingStorage) WriteFile(ctx context.Context, name string, data []byte) error {
+	<-s.release
+	return s.Dir.WriteFile(ctx, name, data)
+}
+
+func TestWithCertChains_Background(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	dir := t.TempDir()
+	s := blockingStorage{Dir: Dir(dir), release: make(chan struct{})}
+	ct := New(WithCertChains(s), WithTransport(server.Client().Transport))
+	resp, err := ct.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	// The response arrived while the write is still pending.
+	close(s.release)
+	ct.Close()
+	name := certChainName(strings.TrimPrefix(server.URL, "https://"))
+	if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+		t.Errorf("chain not saved by Close: %v", err)
+	}
+}
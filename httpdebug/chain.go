@@ -0,0 +1,199 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Chain returns rt (or http.DefaultTransport if it is nil) wrapped by
+// each of the wrappers in turn, the first outermost: Chain(rt, a, b) is
+// a(b(rt)), so that a request passes through the wrappers in the order
+// given. Nil wrappers are ignored. Any function wrapping a RoundTripper
+// can be used, including Dump, OAuth2, and Retry, which fit together as
+//
+//	httpdebug.Chain(nil, httpdebug.Retry(3, time.Second), httpdebug.OAuth2(ts), httpdebug.Dump())
+//
+// so that every attempt is dumped with its credentials (redacted).
+// VerifyChain reports chains composed in a less useful order.
+func Chain(rt http.RoundTripper, wrappers ...func(http.RoundTripper) http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		if wrappers[i] != nil {
+			rt = wrappers[i](rt)
+		}
+	}
+	return rt
+}
+
+// Dump returns a wrapper for Chain that dumps the requests passing
+// through it with a CurlTransport configured by opts.
+func Dump(opts ...CurlTransportOption) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return New(append(opts[:len(opts):len(opts)], WithTransport(rt))...)
+	}
+}
+
+// OAuth2 returns a wrapper for Chain that authorizes the requests passing
+// through it with tokens from ts, as an oauth2.Transport.
+func OAuth2(ts oauth2.TokenSource) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &oauth2.Transport{Source: ts, Base: rt}
+	}
+}
+
+// Retry returns a wrapper for Chain that makes up to attempts attempts
+// at each request that fails with an error or a 429, 502, 503, or 504
+// status, waiting backoff before the second attempt and twice as long
+// before each one after that. Only requests with idempotent methods (or
+// an Idempotency-Key header) whose bodies can be sent again, using
+// GetBody, are retried. Each retry is marked with WithAttempt, so that a
+// CurlTransport wrapped by Retry labels its dump "attempt=n".
+func Retry(attempts int, backoff time.Duration) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &retryTransport{base: rt, attempts: attempts, backoff: backoff}
+	}
+}
+
+// retryTransport is the RoundTripper returned by the wrappers of Retry.
+type retryTransport struct {
+	base     http.RoundTripper
+	attempts int
+	backoff  time.Duration
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for n := 1; ; n++ {
+		attemptReq := req
+		if n > 1 {
+			attemptReq = req.WithContext(WithAttempt(ctx, n))
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+		resp, err := t.base.RoundTrip(attemptReq)
+		if n >= t.attempts || !retryable(resp, err) || !replayable(req) {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain a little of the body so that the connection can be reused.
+			io.CopyN(io.Discard, resp.Body, 4096)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(t.backoff << (n - 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether a round trip with the outcome resp, err is
+// worth retrying.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrBlocked)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// replayable reports whether req may safely be sent again.
+func replayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// ErrChainOrder is wrapped by the errors returned by VerifyChain.
+var ErrChainOrder = errors.New("httpdebug: misordered RoundTripper chain")
+
+// VerifyChain follows the chain of RoundTrippers starting at rt, as built
+// by Chain, and reports layers that are ordered so that their effects
+// are missing from the dumps:
+//
+//   - an oauth2.Transport inside a CurlTransport, which then dumps requests
+//     without their Authorization header;
+//   - a Retry inside a CurlTransport, which then dumps only the first
+//     attempt at each request;
+//   - a CurlTransport inside another, which dumps every request twice.
+//
+// It follows the Transport of a CurlTransport, the Base of an
+// oauth2.Transport, the RoundTripper wrapped by Retry, and the result of
+// the Unwrap method of any other RoundTripper that has one. The result
+// joins one error, wrapping ErrChainOrder, for each problem found, or is
+// nil if there are none.
+func VerifyChain(rt http.RoundTripper) error {
+	var errs []error
+	var curl bool
+	for depth := 0; rt != nil && depth < 100; depth++ {
+		switch rt.(type) {
+		case *CurlTransport:
+			if curl {
+				errs = append(errs, fmt.Errorf("%w: a CurlTransport wraps another, so requests are dumped twice", ErrChainOrder))
+			}
+			curl = true
+		case *oauth2.Transport:
+			if curl {
+				errs = append(errs, fmt.Errorf("%w: a CurlTransport wraps an oauth2.Transport, so dumps lack the Authorization header", ErrChainOrder))
+			}
+		case *retryTransport:
+			if curl {
+				errs = append(errs, fmt.Errorf("%w: a CurlTransport wraps a Retry, so only first attempts are dumped", ErrChainOrder))
+			}
+		}
+		rt = unwrapTransport(rt)
+	}
+	return errors.Join(errs...)
+}
+
+// unwrapTransport returns the RoundTripper that rt wraps, or nil if it
+// wraps none that is known.
+func unwrapTransport(rt http.RoundTripper) http.RoundTripper {
+	switch t := rt.(type) {
+	case *CurlTransport:
+		return t.Transport
+	case *oauth2.Transport:
+		return t.Base
+	case *retryTransport:
+		return t.base
+	case interface{ Unwrap() http.RoundTripper }:
+		return t.Unwrap()
+	}
+	return nil
+}
+
+// findCurlTransport returns the outermost CurlTransport in the chain of
+// RoundTrippers starting at rt, or nil if there is none.
+func findCurlTransport(rt http.RoundTripper) *CurlTransport {
+	for depth := 0; rt != nil && depth < 100; depth++ {
+		if ct, ok := rt.(*CurlTransport); ok {
+			return ct
+		}
+		rt = unwrapTransport(rt)
+	}
+	return nil
+}
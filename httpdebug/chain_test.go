@@ -0,0 +1,195 @@
+package httpdebug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// nameTransport is a RoundTripper that records its name before passing
+// requests on to next.
+type nameTransport struct {
+	name string
+	log  *[]string
+	next http.RoundTripper
+}
+
+func (t *nameTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	*t.log = append(*t.log, t.name)
+	return t.next.RoundTrip(req)
+}
+
+func (t *nameTransport) Unwrap() http.RoundTripper { return t.next }
+
+func named(name string, log *[]string) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &nameTransport{name: name, log: log, next: rt}
+	}
+}
+
+func TestChain(t *testing.T) {
+	if got := Chain(nil); got != http.DefaultTransport {
+		t.Errorf("Chain(nil) = %v, want http.DefaultTransport", got)
+	}
+
+	var log []string
+	rt := Chain(errTransport{err: errors.New("end")}, named("a", &log), nil, named("b", &log))
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if _, err := rt.RoundTrip(req); err == nil || err.Error() != "end" {
+		t.Fatalf("RoundTrip = %v, want end", err)
+	}
+	if got := strings.Join(log, ","); got != "a,b" {
+		t.Errorf("wrappers ran in order %v, want a,b", got)
+	}
+}
+
+func TestChain_DumpOAuth2(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer s3cr3t" {
+			t.Errorf("Authorization = %q, want Bearer s3cr3t", got)
+		}
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "s3cr3t"})
+	client.Transport = Chain(&http.Transport{}, OAuth2(ts), Dump(WithPrefix("> ")))
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(logged) != 1 || !strings.HasPrefix(logged[0], "> curl") || !strings.Contains(logged[0], "Authorization: <REDACTED>") {
+		t.Errorf("logged = %#v, want a dump with the redacted Authorization header", logged)
+	}
+	if err := VerifyChain(client.Transport); err != nil {
+		t.Errorf("VerifyChain = %v, want nil", err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	var calls atomic.Int32
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = Chain(&http.Transport{}, Retry(3, time.Millisecond), Dump())
+	req, _ := http.NewRequest("PUT", url, strings.NewReader("body"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("client.Do = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("status = %v after %v calls, want 200 after 3", resp.StatusCode, calls.Load())
+	}
+	var labels []string
+	for _, s := range logged {
+		if strings.HasPrefix(s, "# response") {
+			labels = append(labels, strings.SplitN(s, ":", 2)[0])
+		}
+	}
+	if got, want := strings.Join(labels, ","), "# response attempt=2,# response attempt=3"; got != want {
+		t.Errorf("response labels = %v, want %v", got, want)
+	}
+	for _, s := range logged {
+		if strings.HasPrefix(s, "curl") && !strings.Contains(s, "--data-raw 'body'") {
+			t.Errorf("dump %q is missing the body", s)
+		}
+	}
+
+	// POST is not idempotent, so it is not retried.
+	calls.Store(0)
+	resp, err = client.Post(url, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("client.Post = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("POST status = %v after %v calls, want 503 after 1", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetry_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	rt := Chain(errTransport{err: errors.New("down")}, Retry(5, time.Hour), func(rt http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			cancel()
+			return rt.RoundTrip(req)
+		})
+	})
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/", nil)
+	if _, err := rt.RoundTrip(req); !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("RoundTrip = %v after %v calls, want context.Canceled after 1", err, calls)
+	}
+}
+
+// roundTripFunc is a RoundTripper implemented by a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestVerifyChain(t *testing.T) {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "abc"})
+	var log []string
+	tests := []struct {
+		name string
+		rt   http.RoundTripper
+		want []string
+	}{
+		{"recommended", Chain(nil, Retry(3, time.Second), named("cache", &log), OAuth2(ts), Dump()), nil},
+		{"no CurlTransport", Chain(nil, OAuth2(ts), Retry(3, time.Second)), nil},
+		{"oauth2 inside", Chain(nil, Dump(), named("other", &log), OAuth2(ts)), []string{"Authorization"}},
+		{"retry inside", Chain(nil, Dump(), Retry(3, time.Second)), []string{"first attempts"}},
+		{"two CurlTransports", Chain(nil, Dump(), Retry(3, time.Second), Dump()), []string{"first attempts", "twice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyChain(tt.rt)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("VerifyChain = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrChainOrder) {
+				t.Fatalf("VerifyChain = %v, want ErrChainOrder", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("VerifyChain = %v, want mention of %q", err, want)
+				}
+			}
+			if got := strings.Count(err.Error(), "\n") + 1; got != len(tt.want) {
+				t.Errorf("VerifyChain reported %v problems, want %v", got, len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package httpdebug
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The collector wire protocol is intentionally simple: every Entry is
+// encoded as a single line of JSON terminated by a newline. Over stream
+// sockets (e.g. "unix" or "tcp") entries are sent back-to-back; over
+// datagram sockets (e.g. "unixgram" or "udp") each datagram carries
+// exactly one entry, so very large entries may be truncated by the OS.
+
+// maxEntrySize is the largest encoded Entry a Collector will accept.
+const maxEntrySize = 16 << 20
+
+// Forwarder is an EntrySink that streams entries to a Collector
+// running in another process.
+type Forwarder struct {
+	conn   net.Conn
+	source string
+}
+
+var _ EntrySink = &Forwarder{}
+
+// DialCollector connects to a Collector listening on the named network
+// and address (see net.Dial) and returns a Forwarder that can be passed
+// to WithEntrySink.
+func DialCollector(network, address string) (*Forwarder, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Forwarder{
+		conn:   conn,
+		source: fmt.Sprintf("%v[%v]", filepath.Base(os.Args[0]), os.Getpid()),
+	}, nil
+}
+
+// WriteEntry implements the EntrySink interface. The entry's Source
+// is set to identify this process if it is empty.
+func (f *Forwarder) WriteEntry(e *Entry) error {
+	if e.Source == "" {
+		c := *e
+		c.Source = f.source
+		e = &c
+	}
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = f.conn.Write(append(buf, '\n'))
+	return err
+}
+
+// Close closes the connection to the Collector.
+func (f *Forwarder) Close() error {
+	return f.conn.Close()
+}
+
+// Collector aggregates entries streamed from several processes so
+// they can be merged and exported in time order.
+type Collector struct {
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+// Add adds an entry to the collector.
+func (c *Collector) Add(e *Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, e)
+}
+
+// Drain removes and returns, ordered by time, all collected entries
+// whose time is before the provided time. A zero time drains everything.
+func (c *Collector) Drain(before time.Time) []*Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sort.SliceStable(c.entries, func(i, j int) bool {
+		return c.entries[i].Time.Before(c.entries[j].Time)
+	})
+
+	n := len(c.entries)
+	if !before.IsZero() {
+		n = sort.Search(len(c.entries), func(i int) bool {
+			return !c.entries[i].Time.Before(before)
+		})
+	}
+
+	result := c.entries[:n:n]
+	c.entries = append([]*Entry(nil), c.entries[n:]...)
+	return result
+}
+
+// Serve accepts stream connections on l and collects the entries sent
+// over each of them until l is closed.
+func (c *Collector) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go c.serveConn(conn)
+	}
+}
+
+func (c *Collector) serveConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, maxEntrySize)
+	for scanner.Scan() {
+		c.addEncoded(scanner.Bytes())
+	}
+}
+
+// ServePacket collects the entries sent as datagrams to pc until pc is closed.
+func (c *Collector) ServePacket(pc net.PacketConn) error {
+	buf := make([]byte, 64<<10)
+	for {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		c.addEncoded(buf[:n])
+	}
+}
+
+// addEncoded decodes and adds a single entry, ignoring malformed input.
+func (c *Collector) addEncoded(buf []byte) {
+	e := &Entry{}
+	if err := json.Unmarshal(buf, e); err != nil {
+		return
+	}
+	c.Add(e)
+}
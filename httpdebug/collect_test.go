@@ -0,0 +1,109 @@
+package httpdebug
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCollector_Drain(t *testing.T) {
+	start := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	at := func(seconds int) *Entry {
+		return &Entry{Time: start.Add(time.Duration(seconds) * time.Second)}
+	}
+	e1, e2, e3 := at(1), at(2), at(3)
+
+	c := &Collector{}
+	c.Add(e3)
+	c.Add(e1)
+	c.Add(e2)
+
+	if got, want := c.Drain(start.Add(3*time.Second)), []*Entry{e1, e2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Drain = %v, want %v", got, want)
+	}
+	if got, want := c.Drain(time.Time{}), []*Entry{e3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Drain = %v, want %v", got, want)
+	}
+	if got := c.Drain(time.Time{}); len(got) != 0 {
+		t.Errorf("Drain = %v, want empty", got)
+	}
+}
+
+func TestCollector_Networks(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		network string
+		addr    string
+	}{
+		{name: "unix", network: "unix", addr: filepath.Join(dir, "stream.sock")},
+		{name: "unixgram", network: "unixgram", addr: filepath.Join(dir, "dgram.sock")},
+		{name: "udp", network: "udp", addr: "127.0.0.1:0"},
+		{name: "tcp", network: "tcp", addr: "127.0.0.1:0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Collector{}
+			var addr string
+			switch tt.network {
+			case "unix", "tcp":
+				l, err := net.Listen(tt.network, tt.addr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer l.Close()
+				go c.Serve(l)
+				addr = l.Addr().String()
+			default:
+				pc, err := net.ListenPacket(tt.network, tt.addr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer pc.Close()
+				go c.ServePacket(pc)
+				addr = pc.LocalAddr().String()
+			}
+
+			f, err := DialCollector(tt.network, addr)
+			if err != nil {
+				t.Fatalf("DialCollector = %v", err)
+			}
+			defer f.Close()
+
+			now := time.Now().UTC()
+			want := []*Entry{
+				{Time: now, Method: "GET", URL: "/a", Curl: "curl -X GET \\\n  /a"},
+				{Time: now.Add(-time.Second), Source: "other[1]", Method: "POST", URL: "/b", Curl: "curl -X POST \\\n  /b"},
+			}
+			for _, e := range want {
+				if err := f.WriteEntry(e); err != nil {
+					t.Fatalf("WriteEntry = %v", err)
+				}
+			}
+
+			var got []*Entry
+			deadline := time.Now().Add(5 * time.Second)
+			for len(got) < len(want) && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+				got = append(got, c.Drain(time.Time{})...)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got %v entries, want %v", len(got), len(want))
+			}
+
+			// The second entry is older, and the first gets our source.
+			if got[0].Source != "other[1]" || got[1].Source != f.source {
+				t.Errorf("sources = %q, %q", got[0].Source, got[1].Source)
+			}
+			for i, e := range []*Entry{want[1], want[0]} {
+				if !got[i].Time.Equal(e.Time) || got[i].Curl != e.Curl || got[i].URL != e.URL || got[i].Method != e.Method {
+					t.Errorf("entry %v = %+v, want %+v", i, got[i], e)
+				}
+			}
+		})
+	}
+}
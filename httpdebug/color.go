@@ -0,0 +1,97 @@
+package httpdebug
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// NoColorEnvVar is the name of the environment variable that, when set
+// to a non-empty value, disables the colors of WithColor (see
+// https://no-color.org).
+const NoColorEnvVar = "NO_COLOR"
+
+// WithColor is a CurlTransportOption that highlights the method, URL,
+// redacted values, and response status codes in the curl output with
+// ANSI colors, to make interactive debugging sessions easier to scan.
+// Mutating methods (see Entry.Mutating) and the "# MUTATING" marker of
+// WithMutatingMarker stand out from safe methods in bold yellow.
+// Colors are only used while the standard logger writes to a terminal
+// and NO_COLOR is unset, so that log files and pipes stay plain. They
+// never appear in the Curl of an Entry or in FormatJSON output.
+func WithColor() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Color = true
+	}
+}
+
+// colorTerminal is used strictly for test purposes.
+var colorTerminal = func() bool {
+	if os.Getenv(NoColorEnvVar) != "" {
+		return false
+	}
+	f, ok := log.Writer().(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+const (
+	ansiReset      = "\x1b[0m"
+	ansiRed        = "\x1b[31m"
+	ansiGreen      = "\x1b[32m"
+	ansiYellow     = "\x1b[33m"
+	ansiMagenta    = "\x1b[1;35m"
+	ansiBoldYellow = "\x1b[1;33m"
+	ansiCyan       = "\x1b[36m"
+)
+
+var (
+	// curlStartRE matches the start of a curl command: the method (if
+	// any), the separator, and the URL (quoted or not).
+	curlStartRE = regexp.MustCompile(`(?m)^curl(?: -X (\S+)| -I)?( \\\n  | )('(?:[^']|'\\'')*'|\S+)`)
+	// statusRE matches the status code of a response line.
+	statusRE = regexp.MustCompile(`(?m)^(# response[^:\n]*: (?:HTTP/\S+ )?)(\d{3}\b|error\b)`)
+	// mutatingRE matches the marker of WithMutatingMarker, on its own line
+	// or ending one.
+	mutatingRE = regexp.MustCompile(`(?m)# MUTATING$`)
+	// redactedRE matches the markers that replace redacted values.
+	redactedRE = regexp.MustCompile(`<REDACTED(?::[0-9a-f]+)?>|REDACTED(?::[0-9a-f]+)?`)
+)
+
+// colorize returns s, the output about a request, with its method, URL,
+// redacted values, mutating marker, and status codes highlighted.
+func colorize(s string) string {
+	s = redactedRE.ReplaceAllString(s, ansiRed+"$0"+ansiReset)
+	s = mutatingRE.ReplaceAllString(s, ansiBoldYellow+"$0"+ansiReset)
+	s = curlStartRE.ReplaceAllStringFunc(s, func(m string) string {
+		sub := curlStartRE.FindStringSubmatch(m)
+		method, sep, url := sub[1], sub[2], sub[3]
+		start := "curl"
+		switch {
+		case method != "":
+			color := ansiMagenta
+			if (&Entry{Method: strings.Trim(method, "'")}).Mutating() {
+				color = ansiBoldYellow
+			}
+			start += " -X " + color + method + ansiReset
+		case strings.HasPrefix(m, "curl -I"):
+			start += " " + ansiMagenta + "-I" + ansiReset
+		}
+		// Resume the URL's color after any redacted values within it.
+		url = strings.ReplaceAll(url, ansiReset, ansiReset+ansiCyan)
+		return start + sep + ansiCyan + url + ansiReset
+	})
+	return statusRE.ReplaceAllStringFunc(s, func(m string) string {
+		sub := statusRE.FindStringSubmatch(m)
+		color := ansiRed
+		switch sub[2][0] {
+		case '1', '2':
+			color = ansiGreen
+		case '3':
+			color = ansiYellow
+		}
+		return sub[1] + color + sub[2] + ansiReset
+	})
+}
@@ -0,0 +1,104 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{
+			name: "GET",
+			s:    "curl \\\n  https://example.com/a \\\n  -H 'Authorization: <REDACTED>'",
+			want: "curl \\\n  \x1b[36mhttps://example.com/a\x1b[0m \\\n  -H 'Authorization: \x1b[31m<REDACTED>\x1b[0m'",
+		},
+		{
+			name: "POST with redacted query",
+			s:    "curl -X POST '/a?token=REDACTED&b=1' --data-raw 'x'",
+			want: "curl -X \x1b[1;33mPOST\x1b[0m \x1b[36m'/a?token=\x1b[31mREDACTED\x1b[0m\x1b[36m&b=1'\x1b[0m --data-raw 'x'",
+		},
+		{
+			name: "hashed secrets",
+			s:    "curl '/a?token=REDACTED:3fa2b1' \\\n  -H 'Authorization: <REDACTED:9c04e7>'",
+			want: "curl \x1b[36m'/a?token=\x1b[31mREDACTED:3fa2b1\x1b[0m\x1b[36m'\x1b[0m \\\n  -H 'Authorization: \x1b[31m<REDACTED:9c04e7>\x1b[0m'",
+		},
+		{
+			name: "safe method with a body",
+			s:    "curl -X GET /a --data-raw 'x'",
+			want: "curl -X \x1b[1;35mGET\x1b[0m \x1b[36m/a\x1b[0m --data-raw 'x'",
+		},
+		{
+			name: "mutating markers",
+			s:    "# MUTATING\ncurl -X DELETE /a\ncurl -X PURGE /b # request #0002 # MUTATING",
+			want: "\x1b[1;33m# MUTATING\x1b[0m\ncurl -X \x1b[1;33mDELETE\x1b[0m \x1b[36m/a\x1b[0m\ncurl -X \x1b[1;33mPURGE\x1b[0m \x1b[36m/b\x1b[0m # request #0002 \x1b[1;33m# MUTATING\x1b[0m",
+		},
+		{
+			name: "HEAD",
+			s:    "# request #0001\ncurl -I \\\n  /a",
+			want: "# request #0001\ncurl \x1b[1;35m-I\x1b[0m \\\n  \x1b[36m/a\x1b[0m",
+		},
+		{
+			name: "statuses",
+			s:    "# response #0001: 200 OK in 1ms\n# response: HTTP/1.1 302 Found\n# response[abc]: 404 Not Found in 1ms\n# response: error after 1ms: boom",
+			want: "# response #0001: \x1b[32m200\x1b[0m OK in 1ms\n# response: HTTP/1.1 \x1b[33m302\x1b[0m Found\n# response[abc]: \x1b[31m404\x1b[0m Not Found in 1ms\n# response: \x1b[31merror\x1b[0m after 1ms: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := colorize(tt.s); got != tt.want {
+				t.Errorf("colorize =\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithColor(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger, oldColorTerminal := logger, colorTerminal
+	defer func() { logger, colorTerminal = oldLogger, oldColorTerminal }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	sink := &entryRecorder{}
+	ct := New(WithColor(), WithPrefix("[api] "), WithEntrySink(sink))
+	client.Transport = ct
+
+	for _, terminal := range []bool{false, true} {
+		colorTerminal = func() bool { return terminal }
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	want := []string{
+		"[api] curl \\\n[api]   " + url,
+		"[api] curl \\\n[api]   \x1b[36m" + url + "\x1b[0m",
+	}
+	if len(logged) != 2 || logged[0] != want[0] || logged[1] != want[1] {
+		t.Errorf("logged = %q, want %q", logged, want)
+	}
+	for _, e := range sink.entries {
+		if strings.Contains(e.Curl, "\x1b") {
+			t.Errorf("Entry.Curl = %q, want no colors", e.Curl)
+		}
+	}
+}
+
+func TestColorTerminal_NoColor(t *testing.T) {
+	t.Setenv(NoColorEnvVar, "1")
+	if colorTerminal() {
+		t.Error("colorTerminal with NO_COLOR = true, want false")
+	}
+}
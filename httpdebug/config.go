@@ -0,0 +1,238 @@
+package httpdebug
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// Config is a snapshot of the effective configuration of a CurlTransport,
+// as returned by CurlTransport.Config, suitable for logging or exposing
+// for support purposes (e.g. as JSON). It shares no memory with the
+// transport. Values that are not plain data, such as the Transport and
+// the EntrySinks, are described by their type or function names.
+type Config struct {
+	Enabled bool
+
+	RedactEntireJWT    bool
+	ShowJWTClaims      bool
+	TokenExpiryWarning time.Duration
+	TokenSource        string `json:",omitempty"`
+	SecretHeaders      []string
+	HeaderAllowlist    []string
+	SecretCookies      []string
+	SecretParams       []string
+	ParamAllowlist     []string
+	RedactAllParams    bool
+	SecretBodyFields   []string
+	KeepUsername       bool
+	SecretPathPatterns []string
+	HashSecrets        bool
+	RedactFunc         string `json:",omitempty"`
+	RulesFile          string `json:",omitempty"`
+
+	// BodyDecoders lists the media types that have a BodyDecoder.
+	BodyDecoders []string
+
+	Transport           string `json:",omitempty"`
+	MaxBodySize         int64
+	OmitBody            bool
+	FailClosed          bool
+	StripAcceptEncoding bool
+	SafeMode            bool
+	SafeModeAllowlist   []string
+	UserAgentSuffix     string
+	UnixSocket          string
+	Resolve             []string
+	HTTPVersion         HTTPVersion
+
+	Format     Format
+	Verbosity  Verbosity
+	Color      bool
+	PrettyJSON bool
+	PrettyXML  bool
+	SingleLine bool
+	RawUnits   bool
+	Prefix     string
+
+	SampleRate       float64
+	EveryNth         int
+	AdaptiveSampling bool
+	SlowRequest      time.Duration
+	RateLimit        int
+	RateLimitPer     time.Duration
+	Dedup            bool
+
+	Name         string
+	RequestID    string `json:",omitempty"`
+	Sequence     bool
+	Serialize    bool
+	MarkMutating bool
+	EntrySinks   []string
+	Enrichers    []string
+	SDKDetectors []string
+
+	TCPInfo         bool
+	SkewThreshold   time.Duration
+	ServerTiming    bool
+	AuthHints       bool
+	Coster          string `json:",omitempty"`
+	MaxHosts        int
+	HostAggregation string `json:",omitempty"`
+	Observers       []string
+
+	ResponseValidators []string
+	AlertSinks         []string
+
+	// CaptureSize is the number of exchanges retained by the Capture,
+	// or zero if there is none.
+	CaptureSize int
+
+	CertChains string `json:",omitempty"`
+
+	// Cassette is the Path of the Cassette, if any.
+	Cassette            string `json:",omitempty"`
+	CassetteMode        CassetteMode
+	CassettePlaceholder string
+	ReplayLatency       float64
+	DriftNormalizers    []string
+
+	AsyncBufferSize int
+	Backpressure    BackpressurePolicy
+}
+
+// Config returns a snapshot of the transport's effective configuration,
+// including any settings taken from the environment by New.
+func (t *CurlTransport) Config() Config {
+	c := Config{
+		Enabled: t.Enabled(),
+
+		RedactEntireJWT:    t.RedactEntireJWT,
+		ShowJWTClaims:      t.ShowJWTClaims,
+		TokenExpiryWarning: t.TokenExpiryWarning,
+		TokenSource:        typeName(t.TokenSource),
+		SecretHeaders:      cloneStrings(t.list(&t.SecretHeaders)),
+		HeaderAllowlist:    cloneStrings(t.list(&t.HeaderAllowlist)),
+		SecretCookies:      cloneStrings(t.list(&t.SecretCookies)),
+		SecretParams:       cloneStrings(t.list(&t.SecretParams)),
+		ParamAllowlist:     cloneStrings(t.list(&t.ParamAllowlist)),
+		RedactAllParams:    t.RedactAllParams,
+		SecretBodyFields:   cloneStrings(t.list(&t.SecretBodyFields)),
+		KeepUsername:       t.KeepUsername,
+		HashSecrets:        t.HashSecrets,
+		RedactFunc:         typeName(t.RedactFunc),
+		RulesFile:          t.RulesFile,
+
+		Transport:           typeName(t.Transport),
+		MaxBodySize:         t.MaxBodySize,
+		OmitBody:            t.OmitBody,
+		FailClosed:          t.FailClosed,
+		StripAcceptEncoding: t.StripAcceptEncoding,
+		SafeMode:            t.SafeMode,
+		SafeModeAllowlist:   cloneStrings(t.list(&t.SafeModeAllowlist)),
+		UserAgentSuffix:     t.UserAgentSuffix,
+		UnixSocket:          t.UnixSocket,
+		Resolve:             cloneStrings(t.Resolve),
+		HTTPVersion:         t.HTTPVersion,
+
+		Format:     t.Format,
+		Verbosity:  t.Verbosity,
+		Color:      t.Color,
+		PrettyJSON: t.PrettyJSON,
+		PrettyXML:  t.PrettyXML,
+		SingleLine: t.SingleLine,
+		RawUnits:   t.RawUnits,
+		Prefix:     t.Prefix,
+
+		SampleRate:       t.SampleRate,
+		EveryNth:         t.EveryNth,
+		AdaptiveSampling: t.AdaptiveSampling,
+		SlowRequest:      t.SlowRequest,
+		RateLimit:        t.RateLimit,
+		RateLimitPer:     t.RateLimitPer,
+		Dedup:            t.Dedup,
+
+		Name:         t.Name,
+		RequestID:    typeName(t.RequestID),
+		Sequence:     t.Sequence,
+		Serialize:    t.Serialize,
+		MarkMutating: t.MarkMutating,
+
+		TCPInfo:         t.TCPInfo,
+		SkewThreshold:   t.SkewThreshold,
+		ServerTiming:    t.ServerTiming,
+		AuthHints:       t.AuthHints,
+		Coster:          typeName(t.Coster),
+		MaxHosts:        t.MaxHosts,
+		HostAggregation: typeName(t.HostAggregation),
+
+		CertChains: typeName(t.CertChains),
+
+		CassetteMode:        t.CassetteMode,
+		CassettePlaceholder: t.CassettePlaceholder,
+		ReplayLatency:       t.ReplayLatency,
+
+		AsyncBufferSize: t.AsyncBufferSize,
+		Backpressure:    t.Backpressure,
+	}
+	for _, re := range t.SecretPathPatterns {
+		c.SecretPathPatterns = append(c.SecretPathPatterns, re.String())
+	}
+	for _, sink := range t.EntrySinks {
+		c.EntrySinks = append(c.EntrySinks, typeName(sink))
+	}
+	for _, enrich := range t.Enrichers {
+		c.Enrichers = append(c.Enrichers, typeName(enrich))
+	}
+	for _, detect := range t.SDKDetectors {
+		c.SDKDetectors = append(c.SDKDetectors, typeName(detect))
+	}
+	for mediaType := range t.BodyDecoders {
+		c.BodyDecoders = append(c.BodyDecoders, mediaType)
+	}
+	sort.Strings(c.BodyDecoders)
+	for _, observer := range t.Observers {
+		c.Observers = append(c.Observers, typeName(observer))
+	}
+	for _, v := range t.ResponseValidators {
+		c.ResponseValidators = append(c.ResponseValidators, typeName(v))
+	}
+	for _, sink := range t.AlertSinks {
+		c.AlertSinks = append(c.AlertSinks, typeName(sink))
+	}
+	for _, n := range t.DriftNormalizers {
+		c.DriftNormalizers = append(c.DriftNormalizers, typeName(n))
+	}
+	if t.Capture != nil {
+		c.CaptureSize = t.Capture.size()
+	}
+	if t.Cassette != nil {
+		c.Cassette = t.Cassette.Path
+	}
+	return c
+}
+
+// typeName returns the name of the function v, or of its type if v is
+// not a function, or "" if v is nil.
+func typeName(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Func && rv.IsNil() {
+		return ""
+	}
+	if rv.Kind() == reflect.Func {
+		if f := runtime.FuncForPC(rv.Pointer()); f != nil {
+			return f.Name()
+		}
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// cloneStrings returns a copy of s, or nil if s is empty.
+func cloneStrings(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return append([]string(nil), s...)
+}
@@ -0,0 +1,66 @@
+package httpdebug
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func upperMethod(e *Entry) { e.Method = strings.ToUpper(e.Method) }
+
+func TestCurlTransport_Config(t *testing.T) {
+	ct := New(
+		WithSecretHeader("X-Api-Key"),
+		WithSampleRate(0.25),
+		WithRateLimit(10, time.Minute),
+		WithFormat(FormatJSON),
+		WithEntrySink(&entryRecorder{}),
+		WithEnricher(upperMethod),
+		WithCapture(50),
+		WithTransport(&http.Transport{}),
+	)
+	ct.SetEnabled(false)
+
+	c := ct.Config()
+	want := Config{
+		SecretHeaders: defaultSecretHeaders("X-Api-Key"),
+		SecretParams:  defaultSecretParams(),
+		Transport:     "*http.Transport",
+		Format:        FormatJSON,
+		SampleRate:    0.25,
+		RateLimit:     10,
+		RateLimitPer:  time.Minute,
+		EntrySinks:    []string{"*httpdebug.entryRecorder"},
+		Enrichers:     []string{"github.com/gmlewis/go-httpdebug/httpdebug.upperMethod"},
+		CaptureSize:   50,
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("Config =\n%+v\nwant:\n%+v", c, want)
+	}
+
+	// The snapshot shares no memory with the transport.
+	c.SecretHeaders[0] = "changed"
+	if ct.SecretHeaders[0] == "changed" {
+		t.Errorf("modifying Config changed SecretHeaders to %q", ct.SecretHeaders)
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	var nilFunc func() string
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{v: nil, want: ""},
+		{v: nilFunc, want: ""},
+		{v: errTransport{}, want: "httpdebug.errTransport"},
+		{v: upperMethod, want: "github.com/gmlewis/go-httpdebug/httpdebug.upperMethod"},
+	}
+	for _, tt := range tests {
+		if got := typeName(tt.v); got != tt.want {
+			t.Errorf("typeName(%#v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package httpdebug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ResponseValidator checks a response against the caller's expectations,
+// returning an error describing how it violates them, or nil. The
+// Exchange holds the dumped request, the status and redacted headers of
+// the response, and as much of its body as the caller read, up to 64 KiB;
+// BodyTruncated reports whether that is not the whole body, including
+// when the caller closed it early.
+type ResponseValidator func(x *Exchange) error
+
+// WithResponseValidator is a CurlTransportOption that checks the response
+// to every dumped request with v once its body has been read or closed,
+// turning the transport into a lightweight contract monitor. Violations
+// are logged after the dump, e.g.
+//
+//	# violation #0003: Content-Type is "text/html", want JSON
+//
+// and delivered to the AlertSinks. It may be given more than once. A nil
+// validator is ignored.
+func WithResponseValidator(v ResponseValidator) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if v != nil {
+			ct.ResponseValidators = append(ct.ResponseValidators, v)
+		}
+	}
+}
+
+// WithAlertSink is a CurlTransportOption that adds an EntrySink to
+// receive the entries of requests whose responses violate a
+// ResponseValidator, with the violation in the entry's Metadata under
+// "violation" and the response status under "status". A nil sink is
+// ignored.
+func WithAlertSink(sink EntrySink) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if sink != nil {
+			ct.AlertSinks = append(ct.AlertSinks, sink)
+		}
+	}
+}
+
+// ExpectContentType returns a ResponseValidator that flags responses
+// with a body whose media type is none of mediaTypes.
+func ExpectContentType(mediaTypes ...string) ResponseValidator {
+	return func(x *Exchange) error {
+		if !hasBody(x) {
+			return nil
+		}
+		contentType := x.Header.Get("Content-Type")
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		for _, want := range mediaTypes {
+			if strings.EqualFold(mediaType, want) {
+				return nil
+			}
+		}
+		return fmt.Errorf("Content-Type is %q, want %v", contentType, strings.Join(mediaTypes, " or "))
+	}
+}
+
+// ExpectJSON returns a ResponseValidator that flags responses with a
+// body that is not of a JSON media type, such as HTML error pages from
+// proxies, or that is not valid JSON, such as truncated responses.
+// Bodies that were not read in full are only checked for their type.
+func ExpectJSON() ResponseValidator {
+	return func(x *Exchange) error {
+		if !hasBody(x) {
+			return nil
+		}
+		contentType := x.Header.Get("Content-Type")
+		if mediaType, _, _ := mime.ParseMediaType(contentType); !isJSON(mediaType) {
+			return fmt.Errorf("Content-Type is %q, want JSON", contentType)
+		}
+		if x.BodyTruncated {
+			return nil
+		}
+		var v json.RawMessage
+		if err := json.Unmarshal(x.Body, &v); err != nil {
+			return fmt.Errorf("body is not valid JSON: %v", err)
+		}
+		return nil
+	}
+}
+
+// hasBody reports whether the response of x has a body to validate.
+func hasBody(x *Exchange) bool {
+	if x.Request != nil && x.Request.Method == http.MethodHead {
+		return false
+	}
+	if x.StatusCode == http.StatusNoContent || x.StatusCode == http.StatusNotModified {
+		return false
+	}
+	return len(x.Body) > 0 || x.BodyTruncated
+}
+
+// validateResponse arranges for the response to e, labeled label, to be
+// checked by the ResponseValidators once its body has been read or
+// closed.
+func (t *CurlTransport) validateResponse(e *Entry, label string, resp *http.Response) {
+	x := &Exchange{
+		Request:    e,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     t.redactHeaders(resp.Header),
+	}
+	if resp.Body == nil || resp.Body == http.NoBody {
+		t.checkResponse(label, x)
+		return
+	}
+	resp.Body = &validatedBody{ReadCloser: resp.Body, t: t, label: label, x: x}
+}
+
+// checkResponse runs the ResponseValidators on x, logging and alerting
+// about any violations.
+func (t *CurlTransport) checkResponse(label string, x *Exchange) {
+	var errs []error
+	for _, v := range t.ResponseValidators {
+		if err := v(x); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return
+	}
+
+	out := t.newOutput()
+	defer out.flush()
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, oneLine(err.Error()))
+		out.log(fmt.Sprintf("# violation%v: %v", label, msgs[len(msgs)-1]))
+	}
+	if len(t.AlertSinks) == 0 {
+		return
+	}
+	alert := *x.Request
+	alert.Metadata = map[string]string{}
+	for k, v := range x.Request.Metadata {
+		alert.Metadata[k] = v
+	}
+	alert.Metadata["violation"] = strings.Join(msgs, "; ")
+	alert.Metadata["status"] = x.Status
+	for _, sink := range t.AlertSinks {
+		if err := sink.WriteEntry(&alert); err != nil {
+			out.log(fmt.Sprintf("# httpdebug: alert sink error: %v", err))
+		}
+	}
+}
+
+// validatedBody records a response body as it is read, and has the
+// response checked once it has been read in full or closed.
+type validatedBody struct {
+	io.ReadCloser
+	t     *CurlTransport
+	label string
+
+	mu   sync.Mutex
+	x    *Exchange
+	done bool
+}
+
+func (b *validatedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	if !b.done {
+		buf := p[:n]
+		if room := maxCaptureBody - len(b.x.Body); len(buf) > room {
+			buf = buf[:room]
+			b.x.BodyTruncated = true
+		}
+		b.x.Body = append(b.x.Body, buf...)
+	}
+	b.mu.Unlock()
+	if errors.Is(err, io.EOF) {
+		b.finish(false)
+	}
+	return n, err
+}
+
+func (b *validatedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish(true)
+	return err
+}
+
+// finish has the response checked, if it has not been already. early
+// reports whether the body was closed before it was read in full.
+func (b *validatedBody) finish(early bool) {
+	b.mu.Lock()
+	if b.done {
+		b.mu.Unlock()
+		return
+	}
+	b.done = true
+	if early {
+		b.x.BodyTruncated = true
+	}
+	b.mu.Unlock()
+	b.t.checkResponse(b.label, b.x)
+}
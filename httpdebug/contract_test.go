@@ -0,0 +1,109 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExpectJSON(t *testing.T) {
+	jsonHeader := http.Header{"Content-Type": {"application/json; charset=utf-8"}}
+	tests := []struct {
+		name string
+		x    Exchange
+		want string
+	}{
+		{name: "valid", x: Exchange{StatusCode: 200, Header: jsonHeader, Body: []byte(`{"a":1}`)}},
+		{name: "empty", x: Exchange{StatusCode: 200, Header: http.Header{"Content-Type": {"text/html"}}}},
+		{name: "no content", x: Exchange{StatusCode: 204, Header: http.Header{}, Body: []byte("x")}},
+		{name: "head", x: Exchange{Request: &Entry{Method: "HEAD"}, StatusCode: 200, Body: []byte("x")}},
+		{
+			name: "html",
+			x:    Exchange{StatusCode: 502, Header: http.Header{"Content-Type": {"text/html"}}, Body: []byte("<html>Bad Gateway</html>")},
+			want: `Content-Type is "text/html", want JSON`,
+		},
+		{
+			name: "truncated",
+			x:    Exchange{StatusCode: 200, Header: jsonHeader, Body: []byte(`{"a":[1,2`)},
+			want: "body is not valid JSON: unexpected end of JSON input",
+		},
+		{name: "partly read", x: Exchange{StatusCode: 200, Header: jsonHeader, Body: []byte(`{"a":[1,2`), BodyTruncated: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ExpectJSON()(&tt.x)
+			if got := fmt.Sprint(err); tt.want == "" && err != nil || tt.want != "" && got != tt.want {
+				t.Errorf("ExpectJSON = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpectContentType(t *testing.T) {
+	v := ExpectContentType("application/xml", "text/xml")
+	x := &Exchange{StatusCode: 200, Header: http.Header{"Content-Type": {"Text/XML; charset=utf-8"}}, Body: []byte("<a/>")}
+	if err := v(x); err != nil {
+		t.Errorf("ExpectContentType(%q) = %v, want nil", x.Header.Get("Content-Type"), err)
+	}
+	x.Header.Set("Content-Type", "application/json")
+	if err, want := v(x), `Content-Type is "application/json", want application/xml or text/xml`; fmt.Sprint(err) != want {
+		t.Errorf("ExpectContentType(%q) = %v, want %q", x.Header.Get("Content-Type"), err, want)
+	}
+}
+
+func TestRoundTrip_ResponseValidator(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true}`)
+	})
+	mux.HandleFunc("/html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html>Service Unavailable</html>")
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	alerts := &entryRecorder{err: errors.New("pager down")}
+	ct := New(WithSequence(), WithResponseValidator(nil), WithResponseValidator(ExpectJSON()), WithAlertSink(nil), WithAlertSink(alerts), WithTransport(&http.Transport{}))
+	if len(ct.ResponseValidators) != 1 || len(ct.AlertSinks) != 1 {
+		t.Fatalf("ResponseValidators = %v, AlertSinks = %v, want one of each", ct.ResponseValidators, ct.AlertSinks)
+	}
+	client.Transport = ct
+
+	for _, path := range []string{"/ok", "/html"} {
+		resp, err := client.Get(url + path)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if len(body) == 0 {
+			t.Errorf("GET %v read no body", path)
+		}
+	}
+
+	var violations []string
+	for _, line := range logged {
+		if strings.HasPrefix(line, "# violation") || strings.HasPrefix(line, "# httpdebug:") {
+			violations = append(violations, line)
+		}
+	}
+	want := []string{`# violation #0002: Content-Type is "text/html", want JSON`, "# httpdebug: alert sink error: pager down"}
+	if strings.Join(violations, "\n") != strings.Join(want, "\n") {
+		t.Errorf("logged violations = %q, want %q", violations, want)
+	}
+	if len(alerts.entries) != 1 {
+		t.Fatalf("alerts = %v, want 1", alerts.entries)
+	}
+	if e := alerts.entries[0]; e.Seq != 2 || e.Metadata["violation"] != `Content-Type is "text/html", want JSON` || e.Metadata["status"] != "200 OK" {
+		t.Errorf("alert = %+v, want the entry of the HTML response with its violation", e)
+	}
+}
@@ -0,0 +1,66 @@
+package httpdebug
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync/atomic"
+)
+
+// Coster estimates the cost, in arbitrary units (e.g. tokens, cents, or
+// API quota), of a single request to a metered API, so that the cost of a
+// debugging session does not go unnoticed. resp is nil if the round trip
+// failed. A Coster that inspects the response body must replace it so
+// that the caller can still read it.
+type Coster interface {
+	Cost(req *http.Request, resp *http.Response) float64
+}
+
+// CosterFunc is an adapter to allow the use of an ordinary function
+// as a Coster.
+type CosterFunc func(req *http.Request, resp *http.Response) float64
+
+// Cost implements the Coster interface.
+func (f CosterFunc) Cost(req *http.Request, resp *http.Response) float64 {
+	return f(req, resp)
+}
+
+// PerRequest returns a Coster that charges the same cost for every request.
+func PerRequest(cost float64) Coster {
+	return CosterFunc(func(*http.Request, *http.Response) float64 { return cost })
+}
+
+// WithCoster is a CurlTransportOption that estimates the cost of each
+// request with c and logs it, along with the cumulative cost of all
+// requests made through the transport, as a "# cost" comment line.
+// See also TotalCost. A nil c is ignored.
+func WithCoster(c Coster) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if c != nil {
+			ct.Coster = c
+		}
+	}
+}
+
+// TotalCost returns the cumulative estimated cost of all requests made
+// through the transport, as estimated by its Coster.
+func (t *CurlTransport) TotalCost() float64 {
+	return math.Float64frombits(t.cost.Load())
+}
+
+// addCost adds cost to the cumulative cost and returns the new total.
+func addCost(total *atomic.Uint64, cost float64) float64 {
+	for {
+		old := total.Load()
+		sum := math.Float64frombits(old) + cost
+		if total.CompareAndSwap(old, math.Float64bits(sum)) {
+			return sum
+		}
+	}
+}
+
+// costReport returns the cost line for a request with the provided label
+// (see Entry.label).
+func costReport(label string, cost, total float64) string {
+	return fmt.Sprintf("# cost%v: %v (total %v)", label, cost, total)
+}
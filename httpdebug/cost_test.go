@@ -0,0 +1,67 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithCoster(t *testing.T) {
+	if ct := New(WithCoster(nil)); ct.Coster != nil {
+		t.Error("WithCoster(nil) set Coster, want nil")
+	}
+	if ct := New(WithCoster(PerRequest(1))); ct.Coster == nil {
+		t.Error("WithCoster did not set Coster")
+	}
+}
+
+func TestRoundTrip_Coster(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Tokens-Used", "40")
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	tokens := CosterFunc(func(req *http.Request, resp *http.Response) float64 {
+		var n float64
+		fmt.Sscan(resp.Header.Get("X-Tokens-Used"), &n)
+		return n
+	})
+	ct := New(WithCoster(tokens), WithSequence())
+	client.Transport = ct
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if got, want := ct.TotalCost(), 80.0; got != want {
+		t.Errorf("TotalCost = %v, want %v", got, want)
+	}
+	var costs []string
+	for _, s := range logged {
+		if strings.HasPrefix(s, "# cost") {
+			costs = append(costs, s)
+		}
+	}
+	want := []string{"# cost #0001: 40 (total 40)", "# cost #0002: 40 (total 80)"}
+	if strings.Join(costs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("cost lines = %#v, want %#v", costs, want)
+	}
+
+	var b strings.Builder
+	if err := ct.WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics = %v", err)
+	}
+	if !strings.Contains(b.String(), "\nhttpdebug_cost_total 80.0\n") {
+		t.Errorf("WriteMetrics =\n%v\nmissing httpdebug_cost_total 80.0", b.String())
+	}
+}
@@ -0,0 +1,68 @@
+package httpdebug
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// BodyDecoder renders a body (such as a protobuf message) as readable
+// text (such as prototext).
+type BodyDecoder func(body []byte) (string, error)
+
+// WithBodyDecoder is a CurlTransportOption that registers decoder for the
+// bodies of contentType (e.g. "application/x-protobuf"), which would
+// otherwise be logged as binary. The decoded request body is logged after
+// the curl command, which still sends the original bytes, and a decoded
+// response body (see WithVerbosity and FullDump) replaces the original.
+// The decoder receives the body before any redaction, so it is
+// responsible for leaving out secrets. Empty contentType or nil decoder
+// is ignored.
+func WithBodyDecoder(contentType string, decoder BodyDecoder) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || decoder == nil {
+			return
+		}
+		if ct.BodyDecoders == nil {
+			ct.BodyDecoders = map[string]BodyDecoder{}
+		}
+		ct.BodyDecoders[mediaType] = decoder
+	}
+}
+
+// decodeBody returns body decoded by the BodyDecoder for contentType,
+// reporting whether there is one.
+func (t *CurlTransport) decodeBody(contentType string, body []byte) (string, bool, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	decoder, ok := t.BodyDecoders[mediaType]
+	if !ok {
+		return "", false, nil
+	}
+	s, err := decoder(body)
+	if err != nil {
+		return "", true, fmt.Errorf("decoding %v: %w", mediaType, err)
+	}
+	return s, true, nil
+}
+
+// requestBodyAnnotation returns the comment lines showing the decoded
+// body of req, or "" if it has no BodyDecoder.
+func (t *CurlTransport) requestBodyAnnotation(label string, req *http.Request, body requestBody) string {
+	if len(t.BodyDecoders) == 0 || body.truncated || len(body.data) == 0 {
+		return ""
+	}
+	s, ok, err := t.decodeBody(req.Header.Get("Content-Type"), body.data)
+	switch {
+	case !ok:
+		return ""
+	case err != nil:
+		return fmt.Sprintf("# request body%v: %v", label, err)
+	}
+	lines := []string{fmt.Sprintf("# request body%v:", label)}
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		lines = append(lines, "#   "+line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,82 @@
+package httpdebug
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// decodeFields is a BodyDecoder for a toy binary format of
+// NUL-separated fields.
+func decodeFields(body []byte) (string, error) {
+	if bytes.HasPrefix(body, []byte("bad")) {
+		return "", errors.New("malformed message")
+	}
+	var lines []string
+	for i, field := range bytes.Split(body, []byte{0}) {
+		lines = append(lines, fmt.Sprintf("%v: %q", i+1, field))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func TestWithBodyDecoder(t *testing.T) {
+	ct := New(WithBodyDecoder("Application/X-Protobuf; proto=Msg", decodeFields), WithBodyDecoder("", decodeFields), WithBodyDecoder("application/grpc", nil))
+	if len(ct.BodyDecoders) != 1 || ct.BodyDecoders["application/x-protobuf"] == nil {
+		t.Errorf("BodyDecoders = %v, want only application/x-protobuf", ct.BodyDecoders)
+	}
+}
+
+func TestRoundTrip_BodyDecoder(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		fmt.Fprint(w, "ok\x00200")
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithBodyDecoder("application/x-protobuf", decodeFields), WithVerbosity(3))
+	tests := []struct {
+		body string
+		want string
+	}{
+		{
+			body: "alice\x0042",
+			want: "# request body:\n#   1: \"alice\"\n#   2: \"42\"",
+		},
+		{
+			body: "bad",
+			want: "# request body: decoding application/x-protobuf: malformed message",
+		},
+	}
+
+	for _, tt := range tests {
+		logged = nil
+		req, _ := http.NewRequest("POST", url, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("client.Do = %v", err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if len(logged) != 3 {
+			t.Fatalf("logged = %#v, want curl command, decoded body, and response", logged)
+		}
+		if logged[1] != tt.want {
+			t.Errorf("request body = %q, want %q", logged[1], tt.want)
+		}
+		if want := "#\n# 1: \"ok\"\n# 2: \"200\""; !strings.HasSuffix(logged[2], want) {
+			t.Errorf("response = %q, want suffix %q", logged[2], want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package httpdebug
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// WithDedup is a CurlTransportOption that collapses consecutive identical
+// requests (with the same method, URL, and body) into a single dump.
+// Repeats are passed straight through, as if the transport were disabled,
+// and are summarized in a "# last request repeated N times" line before
+// the next different request is dumped, and by Flush and Close. A
+// streamed request body (one without a GetBody) is compared once it has
+// been sent, as far as it had been when the response arrived.
+func WithDedup() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Dedup = true
+	}
+}
+
+// deduper tracks repeats of the most recently dumped request.
+// It is safe for concurrent use.
+type deduper struct {
+	mu      sync.Mutex
+	last    string
+	repeats int
+}
+
+// dedupKey returns the key identifying identical requests, given the
+// start of the body of req.
+func dedupKey(req *http.Request, sanitizedURL string, body requestBody) string {
+	return fmt.Sprintf("%v %v %v %x", req.Method, sanitizedURL, req.ContentLength, sha256.Sum256(body.data))
+}
+
+// seen records a request with key. It reports whether the request repeats
+// the previous one and, if it does not, returns the summary of the repeats
+// of the previous request, if any.
+func (d *deduper) seen(key string) (summary string, repeat bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if key == d.last {
+		d.repeats++
+		return "", true
+	}
+	summary = d.takeSummary()
+	d.last = key
+	return summary, false
+}
+
+// summary returns and resets the summary of the repeats of the previous
+// request, if any.
+func (d *deduper) summary() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.takeSummary()
+}
+
+// takeSummary is like summary. The caller must hold d.mu.
+func (d *deduper) takeSummary() string {
+	n := d.repeats
+	d.repeats = 0
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return "# last request repeated 1 time"
+	}
+	return fmt.Sprintf("# last request repeated %v times", n)
+}
@@ -0,0 +1,122 @@
+package httpdebug
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeduper(t *testing.T) {
+	d := &deduper{}
+	tests := []struct {
+		key     string
+		summary string
+		repeat  bool
+	}{
+		{key: "a"},
+		{key: "a", repeat: true},
+		{key: "b", summary: "# last request repeated 1 time"},
+		{key: "b", repeat: true},
+		{key: "b", repeat: true},
+		{key: "a", summary: "# last request repeated 2 times"},
+		{key: "b"},
+	}
+
+	for i, tt := range tests {
+		if summary, repeat := d.seen(tt.key); summary != tt.summary || repeat != tt.repeat {
+			t.Errorf("#%v: seen(%q) = (%q, %v), want (%q, %v)", i, tt.key, summary, repeat, tt.summary, tt.repeat)
+		}
+	}
+	if got := d.summary(); got != "" {
+		t.Errorf("summary = %q, want empty", got)
+	}
+}
+
+func TestRoundTrip_Dedup(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	var served int
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { served++ })
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithDedup())
+	client.Transport = ct
+	post := func(body string) {
+		t.Helper()
+		resp, err := client.Post(url, "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("client.Post = %v", err)
+		}
+		resp.Body.Close()
+	}
+	for i := 0; i < 4; i++ {
+		post("a")
+	}
+	post("b")
+	post("b")
+	ct.Close()
+
+	if served != 6 {
+		t.Errorf("server received %v requests, want 6", served)
+	}
+	var got []string
+	for _, s := range logged {
+		if strings.HasPrefix(s, "curl") {
+			s = s[strings.LastIndex(s, "--data-raw "):]
+		}
+		got = append(got, s)
+	}
+	want := []string{"--data-raw 'a'", "# last request repeated 3 times", "--data-raw 'b'", "# last request repeated 1 time"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("logged = %#v, want %#v", got, want)
+	}
+}
+
+func TestRoundTrip_DedupNotDumped(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+	oldRand := sampleRand
+	defer func() { sampleRand = oldRand }()
+	sampleRand = func() float64 { return 0.9 }
+
+	ok := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		io.Copy(io.Discard, req.Body)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	roundTrip := func(ct *CurlTransport, url string, body io.Reader) {
+		t.Helper()
+		req, _ := http.NewRequest("PUT", url, body)
+		if _, err := ct.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip = %v", err)
+		}
+	}
+
+	// Streamed bodies, without a GetBody, are deduplicated too.
+	ct := New(WithTransport(ok), WithDedup())
+	for _, url := range []string{"http://example.com/a", "http://example.com/a", "http://example.com/a", "http://example.com/b"} {
+		roundTrip(ct, url, io.NopCloser(strings.NewReader("same")))
+	}
+	if len(logged) != 3 || logged[1] != "# last request repeated 2 times" || !strings.Contains(logged[2], "/b") {
+		t.Errorf("logged = %#v, want two dumps and the repeats between them", logged)
+	}
+
+	// The summary is logged although the request taking it is held and
+	// not interesting.
+	logged = nil
+	ct = New(WithTransport(ok), WithDedup(), WithSampleRate(0.5), WithAdaptiveSampling(0))
+	for _, url := range []string{"http://example.com/a", "http://example.com/a", "http://example.com/b"} {
+		roundTrip(ct, url, strings.NewReader("same"))
+	}
+	if len(logged) != 2 || logged[1] != "# last request repeated 1 time" {
+		t.Errorf("logged = %#v, want a dump and the repeats", logged)
+	}
+}
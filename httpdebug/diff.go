@@ -0,0 +1,177 @@
+package httpdebug
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ReadCassetteResponse reads the body of resp and returns the response in
+// the form used by cassettes. The body is replaced so that it can be read
+// again.
+func ReadCassetteResponse(resp *http.Response) (*CassetteResponse, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return &CassetteResponse{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+	}, nil
+}
+
+// DiffResponse compares a recorded response with a new one and returns a
+// human-readable line for each difference in the status, headers, and
+// body, each formatted as "recorded -> new". Both responses are first
+// rewritten by the normalizers (see DefaultNormalizers), and JSON bodies
+// are compared structurally, so that formatting and key order do not
+// matter. It returns nil if the responses are equivalent.
+func DiffResponse(recorded, got *CassetteResponse, normalizers ...Normalizer) []string {
+	recorded, got = normalize(recorded, normalizers), normalize(got, normalizers)
+	var diffs []string
+	if recorded.StatusCode != got.StatusCode {
+		diffs = append(diffs, fmt.Sprintf("status: %v -> %v", statusText(recorded), statusText(got)))
+	}
+	diffs = append(diffs, diffHeaders(recorded.Header, got.Header)...)
+	diffs = append(diffs, diffBodies(recorded.Body, got.Body)...)
+	return diffs
+}
+
+// statusText returns the status line of r, or its status code if the
+// status line is unknown.
+func statusText(r *CassetteResponse) string {
+	if r.Status != "" {
+		return r.Status
+	}
+	return fmt.Sprint(r.StatusCode)
+}
+
+// diffHeaders returns the differences between two sets of headers.
+func diffHeaders(recorded, got http.Header) []string {
+	keys := map[string]bool{}
+	for k := range recorded {
+		keys[http.CanonicalHeaderKey(k)] = true
+	}
+	for k := range got {
+		keys[http.CanonicalHeaderKey(k)] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var diffs []string
+	for _, k := range sorted {
+		a, b := headerValue(recorded, k), headerValue(got, k)
+		if a != b {
+			diffs = append(diffs, fmt.Sprintf("header %v: %v -> %v", k, a, b))
+		}
+	}
+	return diffs
+}
+
+// headerValue returns the quoted, comma-joined values of the header key
+// in h, or "(missing)".
+func headerValue(h http.Header, key string) string {
+	values := h.Values(key)
+	if len(values) == 0 {
+		return "(missing)"
+	}
+	return fmt.Sprintf("%q", strings.Join(values, ", "))
+}
+
+// diffBodies returns the differences between two bodies: structural
+// differences if both are JSON, otherwise the first differing line.
+func diffBodies(recorded, got []byte) []string {
+	if bytes.Equal(recorded, got) {
+		return nil
+	}
+
+	var a, b interface{}
+	if json.Unmarshal(recorded, &a) == nil && json.Unmarshal(got, &b) == nil {
+		return diffJSON("body $", a, b)
+	}
+
+	ra, rb := strings.Split(string(recorded), "\n"), strings.Split(string(got), "\n")
+	for i := 0; i < max(len(ra), len(rb)); i++ {
+		la, lb := lineAt(ra, i), lineAt(rb, i)
+		if la != lb {
+			return []string{fmt.Sprintf("body line %v: %v -> %v", i+1, la, lb)}
+		}
+	}
+	return nil
+}
+
+// lineAt returns the quoted line i of lines, or "(missing)".
+func lineAt(lines []string, i int) string {
+	if i >= len(lines) {
+		return "(missing)"
+	}
+	return fmt.Sprintf("%q", lines[i])
+}
+
+// diffJSON returns the differences between two decoded JSON values,
+// identified by their paths below path.
+func diffJSON(path string, a, b interface{}) []string {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			keys := map[string]bool{}
+			for k := range a {
+				keys[k] = true
+			}
+			for k := range b {
+				keys[k] = true
+			}
+			sorted := make([]string, 0, len(keys))
+			for k := range keys {
+				sorted = append(sorted, k)
+			}
+			sort.Strings(sorted)
+
+			var diffs []string
+			for _, k := range sorted {
+				va, oka := a[k]
+				vb, okb := b[k]
+				p := path + "." + k
+				switch {
+				case !oka:
+					diffs = append(diffs, fmt.Sprintf("%v: (missing) -> %v", p, jsonText(vb)))
+				case !okb:
+					diffs = append(diffs, fmt.Sprintf("%v: %v -> (missing)", p, jsonText(va)))
+				default:
+					diffs = append(diffs, diffJSON(p, va, vb)...)
+				}
+			}
+			return diffs
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok && len(a) == len(b) {
+			var diffs []string
+			for i := range a {
+				diffs = append(diffs, diffJSON(fmt.Sprintf("%v[%v]", path, i), a[i], b[i])...)
+			}
+			return diffs
+		}
+	}
+
+	if ta, tb := jsonText(a), jsonText(b); ta != tb {
+		return []string{fmt.Sprintf("%v: %v -> %v", path, ta, tb)}
+	}
+	return nil
+}
+
+// jsonText returns v encoded as compact JSON.
+func jsonText(v interface{}) string {
+	buf, _ := json.Marshal(v)
+	return string(buf)
+}
@@ -0,0 +1,102 @@
+package httpdebug
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffResponse(t *testing.T) {
+	recorded := &CassetteResponse{
+		StatusCode: 200,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": {"application/json"}, "X-Old": {"1"}},
+		Body:       []byte(`{"id": 1, "name": "a", "tags": ["x", "y"], "gone": true}`),
+	}
+
+	tests := []struct {
+		name string
+		got  *CassetteResponse
+		want []string
+	}{
+		{
+			name: "equivalent JSON",
+			got: &CassetteResponse{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"application/json"}, "X-Old": {"1"}},
+				Body:       []byte(`{"gone":true,"tags":["x","y"],"name":"a","id":1}`),
+			},
+		},
+		{
+			name: "everything differs",
+			got: &CassetteResponse{
+				StatusCode: 500,
+				Status:     "500 Internal Server Error",
+				Header:     http.Header{"Content-Type": {"application/json"}, "X-New": {"2"}},
+				Body:       []byte(`{"id": 2, "name": "a", "tags": ["x", "z"], "added": null}`),
+			},
+			want: []string{
+				"status: 200 OK -> 500 Internal Server Error",
+				`header X-New: (missing) -> "2"`,
+				`header X-Old: "1" -> (missing)`,
+				"body $.added: (missing) -> null",
+				"body $.gone: true -> (missing)",
+				"body $.id: 1 -> 2",
+				`body $.tags[1]: "y" -> "z"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffResponse(recorded, tt.got); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffResponse =\n%v\nwant:\n%v", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDiffBodies(t *testing.T) {
+	tests := []struct {
+		name     string
+		recorded string
+		got      string
+		want     []string
+	}{
+		{name: "equal", recorded: "a\nb", got: "a\nb"},
+		{name: "changed line", recorded: "a\nb\nc", got: "a\nB\nc", want: []string{`body line 2: "b" -> "B"`}},
+		{name: "added line", recorded: "a", got: "a\nb", want: []string{`body line 2: (missing) -> "b"`}},
+		{name: "array length", recorded: `[1]`, got: `[1,2]`, want: []string{"body $: [1] -> [1,2]"}},
+		{name: "type change", recorded: `{"a":{}}`, got: `{"a":[]}`, want: []string{"body $.a: {} -> []"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffBodies([]byte(tt.recorded), []byte(tt.got)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffBodies = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCassetteResponse(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 200,
+		Status:     "200 OK",
+		Header:     http.Header{"A": {"b"}},
+		Body:       io.NopCloser(strings.NewReader("hello")),
+	}
+	got, err := ReadCassetteResponse(resp)
+	if err != nil {
+		t.Fatalf("ReadCassetteResponse = %v", err)
+	}
+	want := &CassetteResponse{StatusCode: 200, Status: "200 OK", Header: http.Header{"A": {"b"}}, Body: []byte("hello")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadCassetteResponse = %#v, want %#v", got, want)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hello" {
+		t.Errorf("body after ReadCassetteResponse = %q, want %q", body, "hello")
+	}
+}
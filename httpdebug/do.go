@@ -0,0 +1,162 @@
+package httpdebug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Do sends req with client, like client.Do, and records errors that
+// arise above the transport, which a CurlTransport never sees: those
+// returned by the client's CheckRedirect (such as a RedirectError), and
+// those that replace the transport's own, such as the
+// "Client.Timeout exceeded" error of a client with a Timeout. Such an
+// error is logged as a "# client error" line after the dump of the
+// request passed to Do (dumping it first if it was not dumped, e.g. as it
+// was sampled out), and is retained by the transport's Capture, if any.
+//
+// The CurlTransport is the outermost one found in the chain of
+// RoundTrippers starting at the client's Transport (see Chain). If there
+// is none, or it is disabled, Do is the same as client.Do. A nil client
+// means http.DefaultClient.
+func Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	rt := client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	ct := findCurlTransport(rt)
+	if ct == nil {
+		return client.Do(req)
+	}
+
+	trace := &doTrace{}
+	start := time.Now()
+	resp, err := client.Do(req.WithContext(context.WithValue(req.Context(), doTraceKey{}, trace)))
+	if err == nil || trace.reported(err) {
+		return resp, err
+	}
+	if opts := requestOptions(req.Context()); len(opts) > 0 {
+		ct = ct.withOptions(opts)
+	}
+	ct.clientError(req, trace.first(), resp, err, start)
+	return resp, err
+}
+
+// doTraceKey is the context key of the doTrace of a request sent by Do.
+type doTraceKey struct{}
+
+// doTrace records what a CurlTransport did with the requests (the one
+// passed to Do and any redirects) made by a single call of Do.
+type doTrace struct {
+	mu sync.Mutex
+	// entry is the dump of the first request, if it was dumped.
+	entry *Entry
+	// errs holds the errors returned by the round trips that were
+	// dumped, which the transport has recorded along with their dumps.
+	errs []error
+}
+
+// doTraceFrom returns the doTrace of ctx, or nil if it is not a request
+// sent by Do.
+func doTraceFrom(ctx context.Context) *doTrace {
+	trace, _ := ctx.Value(doTraceKey{}).(*doTrace)
+	return trace
+}
+
+// dumped records e, the dump of a request, if it is the first, and err,
+// returned by its round trip, if it is non-nil.
+func (d *doTrace) dumped(e *Entry, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.entry == nil {
+		d.entry = e
+	}
+	if err != nil {
+		d.errs = append(d.errs, err)
+	}
+}
+
+// first returns the dump of the first request, or nil if it was not
+// dumped.
+func (d *doTrace) first() *Entry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.entry
+}
+
+// reported reports whether err, returned by the client, is or wraps an
+// error returned by a round trip that was dumped, so that the transport
+// has already recorded it. Errors of round trips that were not dumped
+// (e.g. as they were sampled out) are not reported.
+func (d *doTrace) reported(err error) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, e := range d.errs {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientError records err, returned by the client for req above the
+// transport, along with e, the dump of req, dumping req first if e is
+// nil. resp is the response returned along with err, if any.
+func (t *CurlTransport) clientError(req *http.Request, e *Entry, resp *http.Response, err error, start time.Time) {
+	out := t.newOutput()
+	defer out.flush()
+	if e == nil {
+		if !t.Enabled() && !isFullDump(req.Context()) {
+			return
+		}
+		body := requestBody{omitted: true}
+		if req.GetBody != nil {
+			var peekErr error
+			if req, body, peekErr = t.peekBody(req); peekErr != nil {
+				body = requestBody{omitted: true}
+			}
+		}
+		e = &Entry{
+			Time:    start,
+			Attempt: attempt(req.Context()),
+			Method:  req.Method,
+			URL:     t.sanitizeURL(req.URL),
+			Curl:    t.formatCurl(req, body),
+		}
+		t.writeEntry(out, e)
+	}
+	msg := t.clientErrorMessage(err)
+	out.log(fmt.Sprintf("# client error%v: %v", e.label(), msg))
+
+	if t.Capture != nil {
+		x := &Exchange{Request: e, RequestHeader: t.redactHeaders(req.Header), Duration: time.Since(start), Err: msg}
+		if resp != nil {
+			x.StatusCode = resp.StatusCode
+			x.Status = resp.Status
+			x.Header = t.redactHeaders(resp.Header)
+		}
+		t.Capture.add(x)
+	}
+}
+
+// clientErrorMessage returns the message of err, returned by a client,
+// with the URL of its *url.Error sanitized, since that holds the request's
+// URL as is.
+func (t *CurlTransport) clientErrorMessage(err error) string {
+	var ue *url.Error
+	if !errors.As(err, &ue) {
+		return err.Error()
+	}
+	u, parseErr := url.Parse(ue.URL)
+	if parseErr != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("%v %q: %v", ue.Op, t.sanitizeURL(u), ue.Err)
+}
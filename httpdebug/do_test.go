@@ -0,0 +1,138 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDo_RedirectError(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/next?client_secret=s3cr3t", http.StatusFound)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithSequence(), WithCapture(10), WithTransport(&http.Transport{}))
+	client.Transport = ct
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return errors.New("redirects not allowed")
+	}
+
+	req, _ := http.NewRequest("GET", url+"/start?client_secret=s3cr3t", nil)
+	resp, err := Do(client, req)
+	if err == nil {
+		t.Fatal("Do = nil error, want the CheckRedirect error")
+	}
+	resp.Body.Close()
+
+	want := `# client error #0001: Get "/next?client_secret=REDACTED": redirects not allowed`
+	if got := logged[len(logged)-1]; got != want {
+		t.Errorf("logged = %#v, want last line %q", logged, want)
+	}
+	x, ok := ct.Last()
+	if !ok {
+		t.Fatal("Last = false, want the client error")
+	}
+	if x.Request == nil || x.Request.Seq != 1 || x.StatusCode != http.StatusFound || !strings.HasSuffix(x.Err, "redirects not allowed") {
+		t.Errorf("Last = %+v, want the first request with the redirect and the client error", x)
+	}
+	if captured := ct.Captured(); len(captured) != 2 {
+		t.Errorf("Captured = %+v, want the round trip and the client error", captured)
+	}
+}
+
+func TestDo_ClientTimeout(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithEveryNth(2), WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		// The client closes Cancel only after recording that its Timeout
+		// fired, whereas the context may be done before, in which case
+		// the client does not replace this error.
+		<-req.Cancel
+		return nil, errors.New("use of closed network connection")
+	})))
+	ct.sampled() // so that the request sent by Do is not sampled
+	client := &http.Client{Transport: ct, Timeout: 10 * time.Millisecond}
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if _, err := Do(client, req); err == nil {
+		t.Fatal("Do = nil error, want a timeout")
+	}
+	if len(logged) != 2 {
+		t.Fatalf("logged = %#v, want the request and the client error", logged)
+	}
+	if want := "curl \\\n  http://example.com/"; logged[0] != want {
+		t.Errorf("logged[0] = %q, want %q", logged[0], want)
+	}
+	if !strings.HasPrefix(logged[1], `# client error: Get "http://example.com/": `) || !strings.Contains(logged[1], "Client.Timeout exceeded") {
+		t.Errorf("logged[1] = %q, want the client's timeout", logged[1])
+	}
+}
+
+func TestDo_TransportError(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithCapture(10), WithTransport(errTransport{err: errors.New("no route")}))
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if _, err := Do(ct.Client(), req); err == nil {
+		t.Fatal("Do = nil error, want the transport's error")
+	}
+	for _, line := range logged {
+		if strings.HasPrefix(line, "# client error") {
+			t.Errorf("logged %q for an error the transport already recorded", line)
+		}
+	}
+	if captured := ct.Captured(); len(captured) != 1 {
+		t.Errorf("Captured = %+v, want only the round trip", captured)
+	}
+}
+
+func TestDo_TransportErrorSampledOut(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithEveryNth(2), WithTransport(errTransport{err: errors.New("no route")}))
+	ct.sampled() // so that the request sent by Do is not sampled
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if _, err := Do(&http.Client{Transport: ct}, req); err == nil {
+		t.Fatal("Do = nil error, want the transport's error")
+	}
+	if len(logged) != 2 || logged[1] != `# client error: Get "http://example.com/": no route` {
+		t.Errorf("logged = %#v, want the request and the error its round trip did not dump", logged)
+	}
+}
+
+func TestDo_WithoutCurlTransport(t *testing.T) {
+	client := &http.Client{Transport: errTransport{err: errors.New("no route")}}
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if _, err := Do(client, req); err == nil || !strings.Contains(err.Error(), "no route") {
+		t.Errorf("Do = %v, want the transport's error", err)
+	}
+}
+
+func TestFindCurlTransport(t *testing.T) {
+	ct := New()
+	if got := findCurlTransport(Chain(ct, Retry(2, 0))); got != ct {
+		t.Errorf("findCurlTransport = %v, want the CurlTransport behind Retry", got)
+	}
+	if got := findCurlTransport(http.DefaultTransport); got != nil {
+		t.Errorf("findCurlTransport(DefaultTransport) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,57 @@
+package httpdebug
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvVar is the name of the environment variable that New consults
+// to enable or disable dumping at startup. Its recognized values are:
+//
+//	0, false, off   disable dumping
+//	1, true, on     enable dumping
+//	curl            enable dumping with FormatCurl
+//	json            enable dumping with FormatJSON
+//
+// Any other value, or an unset variable, leaves the configuration unchanged.
+// The environment takes precedence over the options passed to New.
+const EnvVar = "HTTPDEBUG"
+
+// SetEnabled turns dumping on or off at runtime. While disabled, the
+// CurlTransport passes requests straight through to its Transport.
+// It is safe to call SetEnabled concurrently with requests.
+func (t *CurlTransport) SetEnabled(enabled bool) {
+	t.root().disabled.Store(!enabled)
+}
+
+// Enabled reports whether the transport is currently dumping requests.
+func (t *CurlTransport) Enabled() bool {
+	return !t.root().disabled.Load()
+}
+
+// applyEnv applies the settings of EnvVar and CassetteEnvVar (if any) to t.
+func (t *CurlTransport) applyEnv() {
+	if t.Cassette != nil {
+		if mode, ok := parseCassetteMode(os.Getenv(CassetteEnvVar)); ok {
+			t.CassetteMode = mode
+		}
+	}
+
+	v, ok := os.LookupEnv(EnvVar)
+	if !ok {
+		return
+	}
+
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "0", "false", "off":
+		t.SetEnabled(false)
+	case "1", "true", "on":
+		t.SetEnabled(true)
+	case "curl":
+		t.SetEnabled(true)
+		t.Format = FormatCurl
+	case "json":
+		t.SetEnabled(true)
+		t.Format = FormatJSON
+	}
+}
@@ -0,0 +1,71 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNew_EnvVar(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		opts        []CurlTransportOption
+		wantEnabled bool
+		wantFormat  Format
+	}{
+		{name: "disabled", value: "0", wantEnabled: false},
+		{name: "off", value: "OFF", wantEnabled: false},
+		{name: "enabled", value: "1", wantEnabled: true},
+		{name: "curl overrides option", value: "curl", opts: []CurlTransportOption{WithFormat(FormatJSON)}, wantEnabled: true, wantFormat: FormatCurl},
+		{name: "json", value: " json ", wantEnabled: true, wantFormat: FormatJSON},
+		{name: "unknown value is ignored", value: "maybe", opts: []CurlTransportOption{WithFormat(FormatJSON)}, wantEnabled: true, wantFormat: FormatJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvVar, tt.value)
+			ct := New(tt.opts...)
+			if got := ct.Enabled(); got != tt.wantEnabled {
+				t.Errorf("Enabled = %v, want %v", got, tt.wantEnabled)
+			}
+			if ct.Format != tt.wantFormat {
+				t.Errorf("Format = %v, want %v", ct.Format, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New()
+	client.Transport = ct
+	get := func() {
+		t.Helper()
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	ct.SetEnabled(false)
+	get()
+	if len(logged) != 0 {
+		t.Errorf("disabled transport logged %#v", logged)
+	}
+
+	ct.SetEnabled(true)
+	get()
+	if len(logged) != 1 {
+		t.Errorf("enabled transport logged %#v, want 1 entry", logged)
+	}
+}
@@ -0,0 +1,59 @@
+package httpdebug
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxDecompressedBody limits how far a compressed request body is
+// expanded for display, as a guard against decompression bombs.
+const maxDecompressedBody = 10 << 20
+
+// decompressBody returns body, which is sent with the Content-Encoding
+// in header, decompressed for display along with the encoding, or false
+// if it is not compressed with gzip or deflate or cannot be decompressed.
+func decompressBody(header http.Header, body []byte) ([]byte, string, bool) {
+	encoding := strings.ToLower(strings.TrimSpace(header.Get("Content-Encoding")))
+	var r io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, "", false
+		}
+		r = zr
+	case "deflate":
+		// "deflate" should mean the zlib format, but some clients send
+		// raw deflate data.
+		if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+			r = zr
+		} else {
+			r = flate.NewReader(bytes.NewReader(body))
+		}
+	default:
+		return nil, "", false
+	}
+	plain, err := io.ReadAll(io.LimitReader(r, maxDecompressedBody+1))
+	if err != nil || len(plain) > maxDecompressedBody {
+		return nil, "", false
+	}
+	return plain, encoding, true
+}
+
+// compressedBodyAnnotation returns the comment line noting that the body
+// of req is shown decompressed, or "" if it is not.
+func (t *CurlTransport) compressedBodyAnnotation(label string, req *http.Request, body requestBody) string {
+	if req.Header.Get("Content-Encoding") == "" || body.truncated || len(body.data) == 0 {
+		return ""
+	}
+	if _, encoding, ok := decompressBody(req.Header, body.data); ok {
+		return fmt.Sprintf("# request body%v: sent %v-compressed (%v), shown decompressed", label, encoding, t.size(int64(len(body.data))))
+	}
+	return ""
+}
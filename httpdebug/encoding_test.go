@@ -0,0 +1,102 @@
+package httpdebug
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func compress(t *testing.T, encoding, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "flate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		want     string
+		ok       bool
+	}{
+		{"gzip", "gzip", compress(t, "gzip", "hello"), "hello", true},
+		{"x-gzip", "X-Gzip", compress(t, "gzip", "hello"), "hello", true},
+		{"zlib deflate", "deflate", compress(t, "zlib", "hello"), "hello", true},
+		{"raw deflate", "deflate", compress(t, "flate", "hello"), "hello", true},
+		{"identity", "", []byte("hello"), "", false},
+		{"unsupported", "br", []byte("hello"), "", false},
+		{"corrupt", "gzip", []byte("hello"), "", false},
+		{"bomb", "gzip", compress(t, "gzip", strings.Repeat("a", maxDecompressedBody+1)), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.encoding != "" {
+				header.Set("Content-Encoding", tt.encoding)
+			}
+			got, _, ok := decompressBody(header, tt.body)
+			if ok != tt.ok || string(got) != tt.want {
+				t.Errorf("decompressBody = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_CompressedBody(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	var received []byte
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New()
+	body := compress(t, "gzip", `{"name":"gopher"}`)
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(body))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("client.Do = %v", err)
+	}
+	resp.Body.Close()
+
+	if !bytes.Equal(received, body) {
+		t.Errorf("server received %q, want the compressed body", received)
+	}
+	want := []string{
+		fmt.Sprintf("curl -X POST \\\n  %v \\\n  -H 'Content-Type: application/json' \\\n  --data-raw '{\"name\":\"gopher\"}'", url),
+		fmt.Sprintf("# request body: sent gzip-compressed (%v B), shown decompressed", len(body)),
+	}
+	if len(logged) != 2 || logged[0] != want[0] || logged[1] != want[1] {
+		t.Errorf("logged = %#v, want %#v", logged, want)
+	}
+}
@@ -0,0 +1,89 @@
+package httpdebug
+
+import (
+	"os"
+	"regexp"
+)
+
+// Enricher adds information to an Entry before it is delivered to
+// the EntrySinks.
+type Enricher func(e *Entry)
+
+// WithEnricher is a CurlTransportOption that adds an additional Enricher.
+// A nil enricher is ignored.
+func WithEnricher(enricher Enricher) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if enricher != nil {
+			ct.Enrichers = append(ct.Enrichers, enricher)
+		}
+	}
+}
+
+// These are the paths used to discover the container ID.
+var (
+	cgroupPath    = "/proc/self/cgroup"
+	mountinfoPath = "/proc/self/mountinfo"
+)
+
+var (
+	cgroupContainerRE    = regexp.MustCompile(`[0-9a-f]{64}`)
+	mountinfoContainerRE = regexp.MustCompile(`/containers/([0-9a-f]{64})/`)
+)
+
+// EnvironmentEnricher returns an Enricher that attaches metadata about the
+// environment in which the process is running so that entries aggregated
+// from several replicas remain attributable. The following Metadata keys
+// are set when known:
+//
+//	hostname      from os.Hostname
+//	pod           from $POD_NAME
+//	namespace     from $POD_NAMESPACE
+//	node          from $NODE_NAME
+//	container_id  from /proc/self/cgroup or /proc/self/mountinfo
+//
+// The POD_* and NODE_NAME variables are conventionally populated using the
+// Kubernetes downward API. The metadata is gathered once, when
+// EnvironmentEnricher is called.
+func EnvironmentEnricher() Enricher {
+	md := map[string]string{}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		md["hostname"] = hostname
+	}
+	for key, env := range map[string]string{
+		"pod":       "POD_NAME",
+		"namespace": "POD_NAMESPACE",
+		"node":      "NODE_NAME",
+	} {
+		if v := os.Getenv(env); v != "" {
+			md[key] = v
+		}
+	}
+	if id := containerID(); id != "" {
+		md["container_id"] = id
+	}
+
+	return func(e *Entry) {
+		if e.Metadata == nil {
+			e.Metadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			e.Metadata[k] = v
+		}
+	}
+}
+
+// containerID returns the ID of the container in which the process is
+// running, or "" if it cannot be determined.
+func containerID() string {
+	if buf, err := os.ReadFile(cgroupPath); err == nil {
+		if id := cgroupContainerRE.Find(buf); id != nil {
+			return string(id)
+		}
+	}
+	if buf, err := os.ReadFile(mountinfoPath); err == nil {
+		if m := mountinfoContainerRE.FindSubmatch(buf); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package httpdebug
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWithEnricher(t *testing.T) {
+	ct := New(WithEnricher(nil), WithEnricher(func(e *Entry) {}))
+	if len(ct.Enrichers) != 1 {
+		t.Errorf("len(Enrichers) = %v, want 1", len(ct.Enrichers))
+	}
+}
+
+func TestContainerID(t *testing.T) {
+	const id = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name      string
+		cgroup    string
+		mountinfo string
+		want      string
+	}{
+		{
+			name: "not in a container",
+		},
+		{
+			name:   "docker cgroup v1",
+			cgroup: "12:memory:/docker/" + id + "\n",
+			want:   id,
+		},
+		{
+			name:   "kubernetes containerd",
+			cgroup: "0::/kubepods.slice/kubepods-pod1.slice/cri-containerd-" + id + ".scope\n",
+			want:   id,
+		},
+		{
+			name:      "docker cgroup v2",
+			cgroup:    "0::/\n",
+			mountinfo: "1 2 0:1 /var/lib/docker/containers/" + id + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n",
+			want:      id,
+		},
+	}
+
+	oldCgroup, oldMountinfo := cgroupPath, mountinfoPath
+	defer func() { cgroupPath, mountinfoPath = oldCgroup, oldMountinfo }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cgroupPath = filepath.Join(dir, "cgroup")
+			mountinfoPath = filepath.Join(dir, "mountinfo")
+			if err := os.WriteFile(cgroupPath, []byte(tt.cgroup), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(mountinfoPath, []byte(tt.mountinfo), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := containerID(); got != tt.want {
+				t.Errorf("containerID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvironmentEnricher(t *testing.T) {
+	oldCgroup, oldMountinfo := cgroupPath, mountinfoPath
+	defer func() { cgroupPath, mountinfoPath = oldCgroup, oldMountinfo }()
+	cgroupPath = filepath.Join(t.TempDir(), "missing")
+	mountinfoPath = cgroupPath
+
+	t.Setenv("POD_NAME", "api-7d9f")
+	t.Setenv("POD_NAMESPACE", "prod")
+	t.Setenv("NODE_NAME", "")
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Entry{Metadata: map[string]string{"existing": "yes"}}
+	EnvironmentEnricher()(e)
+
+	want := map[string]string{
+		"existing":  "yes",
+		"hostname":  hostname,
+		"pod":       "api-7d9f",
+		"namespace": "prod",
+	}
+	if !reflect.DeepEqual(e.Metadata, want) {
+		t.Errorf("Metadata = %v, want %v", e.Metadata, want)
+	}
+}
+
+func TestRoundTrip_Enricher(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	r := &entryRecorder{}
+	client.Transport = New(
+		WithEntrySink(r),
+		WithEnricher(func(e *Entry) { e.Metadata = map[string]string{"replica": "1"} }),
+	)
+
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(r.entries) != 1 || r.entries[0].Metadata["replica"] != "1" {
+		t.Errorf("entries = %+v, want one entry with replica metadata", r.entries)
+	}
+}
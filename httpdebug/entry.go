@@ -0,0 +1,120 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Entry is a structured record of a single request dumped by a CurlTransport.
+// All values are already redacted.
+type Entry struct {
+	// Time is when the request was dumped.
+	Time time.Time `json:"time"`
+	// Source identifies the process that produced the entry.
+	Source string `json:"source,omitempty"`
+	// Prefix is the Prefix of the transport that produced the entry.
+	Prefix string `json:"prefix,omitempty"`
+	// Seq is the sequence number of the request within its transport,
+	// if the transport was created with WithSequence.
+	Seq uint64 `json:"seq,omitempty"`
+	// ID is the request ID generated by the transport's RequestID, if any.
+	ID string `json:"id,omitempty"`
+	// Attempt is the attempt number of a retried request set with
+	// WithAttempt, if any.
+	Attempt int `json:"attempt,omitempty"`
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+	// URL is the sanitized URL of the request.
+	URL string `json:"url"`
+	// Host is the host under which the request is summarized in metrics:
+	// that of the URL after any HostAggregation, or OtherHosts beyond
+	// MaxHosts.
+	Host string `json:"host,omitempty"`
+	// Curl is the request as its `curl` equivalent.
+	Curl string `json:"curl"`
+	// Metadata holds additional information attached by Enrichers.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Mutating reports whether the entry's method changes server state
+// (POST, PUT, PATCH, DELETE, the WebDAV methods PROPPATCH, MKCOL, COPY,
+// MOVE, LOCK, and UNLOCK, or PURGE, which evicts a cached resource), so
+// that what a tool changed can be picked out at a glance.
+func (e *Entry) Mutating() bool {
+	switch e.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		"PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK", "PURGE":
+		return true
+	}
+	return false
+}
+
+// label returns the sequence number, ID, and attempt number of the entry
+// as used to label the lines logged about it, e.g. " #0001 req-1 attempt=2",
+// or the empty string if it has none of them.
+func (e *Entry) label() string {
+	var s string
+	if e.Seq != 0 {
+		s += fmt.Sprintf(" #%04d", e.Seq)
+	}
+	if e.ID != "" {
+		s += " " + e.ID
+	}
+	if e.Attempt != 0 {
+		s += fmt.Sprintf(" attempt=%v", e.Attempt)
+	}
+	return s
+}
+
+// String returns the entry as a human-readable comment header
+// followed by its curl command.
+func (e *Entry) String() string {
+	header := fmt.Sprintf("# %v", e.Time.UTC().Format(time.RFC3339Nano))
+	if e.Source != "" {
+		header += " " + e.Source
+	}
+	return e.Prefix + header + "\n" + e.Curl
+}
+
+// EntrySink receives an Entry for every request dumped by a CurlTransport.
+type EntrySink interface {
+	WriteEntry(e *Entry) error
+}
+
+// WithEntrySink is a CurlTransportOption that adds an additional
+// EntrySink to receive every dumped request.
+// A nil sink is ignored.
+func WithEntrySink(sink EntrySink) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if sink != nil {
+			ct.EntrySinks = append(ct.EntrySinks, sink)
+		}
+	}
+}
+
+// writeEntry enriches e, logs it to out in the configured Format, and
+// delivers it to all EntrySinks. Sink failures are logged rather than
+// returned so that they never break the request.
+func (t *CurlTransport) writeEntry(out *output, e *Entry) {
+	if e.Prefix == "" {
+		e.Prefix = t.Prefix
+	}
+	if e.Seq == 0 && t.Sequence {
+		e.Seq = t.root().seq.Add(1)
+	}
+	if e.ID == "" && t.RequestID != nil {
+		e.ID = t.RequestID()
+	}
+	for _, enrich := range t.Enrichers {
+		enrich(e)
+	}
+	out.log(t.formatEntry(e))
+	out.do(func() {
+		for _, sink := range t.EntrySinks {
+			if err := sink.WriteEntry(e); err != nil {
+				out.log(fmt.Sprintf("# httpdebug: entry sink error: %v", err))
+			}
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// entryRecorder is an EntrySink that records every entry it receives.
+type entryRecorder struct {
+	entries []*Entry
+	err     error
+}
+
+func (r *entryRecorder) WriteEntry(e *Entry) error {
+	r.entries = append(r.entries, e)
+	return r.err
+}
+
+func TestWithEntrySink(t *testing.T) {
+	r := &entryRecorder{}
+	ct := New(WithEntrySink(nil), WithEntrySink(r))
+	if len(ct.EntrySinks) != 1 || ct.EntrySinks[0] != r {
+		t.Errorf("EntrySinks = %v, want [%v]", ct.EntrySinks, r)
+	}
+}
+
+func TestEntry_String(t *testing.T) {
+	e := &Entry{
+		Time:   time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Source: "svc[42]",
+		Curl:   "curl -X GET \\\n  /foo",
+	}
+	want := "# 2022-01-02T03:04:05Z svc[42]\ncurl -X GET \\\n  /foo"
+	if got := e.String(); got != want {
+		t.Errorf("Entry.String =\n%v\nwant:\n%v", got, want)
+	}
+
+	e.Prefix = "[github-client] "
+	want = "[github-client] " + want
+	if got := e.String(); got != want {
+		t.Errorf("Entry.String =\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestEntry_Mutating(t *testing.T) {
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{method: "GET"},
+		{method: "HEAD"},
+		{method: "OPTIONS"},
+		{method: "POST", want: true},
+		{method: "PUT", want: true},
+		{method: "PATCH", want: true},
+		{method: "DELETE", want: true},
+		{method: "PROPFIND"},
+		{method: "REPORT"},
+		{method: "PROPPATCH", want: true},
+		{method: "MKCOL", want: true},
+		{method: "MOVE", want: true},
+		{method: "PURGE", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if got := (&Entry{Method: tt.method}).Mutating(); got != tt.want {
+				t.Errorf("Mutating = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_EntrySink(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/test-url", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	r := &entryRecorder{err: fmt.Errorf("sink failed")}
+	client.Transport = New(WithEntrySink(r))
+
+	resp, err := client.Get(url + "/test-url?client_secret=abc")
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(r.entries) != 1 {
+		t.Fatalf("got %v entries, want 1", len(r.entries))
+	}
+	e := r.entries[0]
+	if want := url + "/test-url?client_secret=REDACTED"; e.Method != "GET" || e.URL != want {
+		t.Errorf("entry = %v %v, want GET %v", e.Method, e.URL, want)
+	}
+	if e.Curl != logged[0] {
+		t.Errorf("entry.Curl = %q, want %q", e.Curl, logged[0])
+	}
+	if want := "# httpdebug: entry sink error: sink failed"; len(logged) != 2 || logged[1] != want {
+		t.Errorf("logged = %#v, want sink error %q", logged, want)
+	}
+}
@@ -0,0 +1,91 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// WithTokenExpiryWarning is a CurlTransportOption that warns whenever a
+// request is sent with a token that has already expired or that expires
+// within window — a very common root cause of intermittent 401s.
+// The expiry is known for JWTs in secret headers and for the token
+// provided by the TokenSource (see WithTokenSource).
+// A window of zero or less disables the warning.
+func WithTokenExpiryWarning(window time.Duration) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.TokenExpiryWarning = window
+	}
+}
+
+// WithTokenSource is a CurlTransportOption that provides the
+// oauth2.TokenSource whose token expiry is checked by
+// WithTokenExpiryWarning. This is typically the same source used by
+// the oauth2.Transport that wraps the CurlTransport, ideally one
+// returned by oauth2.ReuseTokenSource so that the check does not
+// cause additional token refreshes.
+func WithTokenSource(ts oauth2.TokenSource) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.TokenSource = ts
+	}
+}
+
+// tokenExpiryWarnings returns a warning line for each known token
+// expiry in h (or from the TokenSource) that falls within the
+// TokenExpiryWarning window of now.
+func (t *CurlTransport) tokenExpiryWarnings(h http.Header, now time.Time) []string {
+	var keys []string
+	for k := range h {
+		if t.isSecretHeader(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	check := func(name string, expiry time.Time) {
+		if line, ok := t.expiryWarning(name, expiry, now); ok {
+			lines = append(lines, line)
+		}
+	}
+
+	for _, k := range keys {
+		for _, v := range h[k] {
+			if token, ok := parseJWT(v); ok {
+				if exp, ok := token.expiry(); ok {
+					check(k+" JWT", exp)
+				}
+			}
+		}
+	}
+
+	if t.TokenSource != nil {
+		if token, err := t.TokenSource.Token(); err == nil && !token.Expiry.IsZero() {
+			check("oauth2 token", token.Expiry)
+		}
+	}
+
+	return lines
+}
+
+// expiryWarning returns a warning if expiry is within the
+// TokenExpiryWarning window of now.
+func (t *CurlTransport) expiryWarning(name string, expiry, now time.Time) (string, bool) {
+	if expiry.Sub(now) > t.TokenExpiryWarning {
+		return "", false
+	}
+	return fmt.Sprintf("# WARNING: %v %v", name, expiryStatus(expiry, now)), true
+}
+
+// expiryStatus describes expiry relative to now.
+func expiryStatus(expiry, now time.Time) string {
+	exp := expiry.UTC().Format(time.RFC3339)
+	remaining := expiry.Sub(now)
+	if remaining > 0 {
+		return fmt.Sprintf("expires in %v (exp=%v)", remaining.Round(time.Second), exp)
+	}
+	return fmt.Sprintf("expired %v ago (exp=%v)", (-remaining).Round(time.Second), exp)
+}
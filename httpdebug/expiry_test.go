@@ -0,0 +1,84 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestWithTokenExpiryWarning(t *testing.T) {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "abc"})
+	ct := New(WithTokenExpiryWarning(time.Minute), WithTokenSource(ts))
+	if ct.TokenExpiryWarning != time.Minute || ct.TokenSource != ts {
+		t.Errorf("TokenExpiryWarning = %v, TokenSource = %v", ct.TokenExpiryWarning, ct.TokenSource)
+	}
+}
+
+// errTokenSource is an oauth2.TokenSource that always fails.
+type errTokenSource struct{}
+
+func (errTokenSource) Token() (*oauth2.Token, error) { return nil, errors.New("no token") }
+
+func TestCurlTransport_tokenExpiryWarnings(t *testing.T) {
+	now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	jwtExpiring := func(d time.Duration) string {
+		return makeJWT(`{"alg":"HS256"}`, fmt.Sprintf(`{"exp":%v}`, now.Add(d).Unix()))
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		ts     oauth2.TokenSource
+		want   []string
+	}{
+		{
+			name:   "no tokens",
+			header: http.Header{"Accept": []string{"*/*"}},
+		},
+		{
+			name:   "JWT far from expiry",
+			header: http.Header{"Authorization": []string{"Bearer " + jwtExpiring(time.Hour)}},
+		},
+		{
+			name:   "JWT without exp",
+			header: http.Header{"Authorization": []string{"Bearer " + makeJWT(`{"alg":"HS256"}`, `{"sub":"me"}`)}},
+		},
+		{
+			name:   "JWT expiring soon",
+			header: http.Header{"Authorization": []string{"Bearer " + jwtExpiring(30*time.Second)}},
+			want:   []string{"# WARNING: Authorization JWT expires in 30s (exp=2022-01-02T03:04:35Z)"},
+		},
+		{
+			name:   "JWT expired",
+			header: http.Header{"X-User-Jwt": []string{jwtExpiring(-2 * time.Minute)}},
+			want:   []string{"# WARNING: X-User-Jwt JWT expired 2m0s ago (exp=2022-01-02T03:02:05Z)"},
+		},
+		{
+			name: "oauth2 token expired",
+			ts:   oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "abc", Expiry: now.Add(-time.Second)}),
+			want: []string{"# WARNING: oauth2 token expired 1s ago (exp=2022-01-02T03:04:04Z)"},
+		},
+		{
+			name: "oauth2 token without expiry",
+			ts:   oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "abc"}),
+		},
+		{
+			name: "oauth2 token source error",
+			ts:   errTokenSource{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := New(WithTokenExpiryWarning(time.Minute), WithTokenSource(tt.ts))
+			if got := ct.tokenExpiryWarnings(tt.header, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenExpiryWarnings = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package httpdebug
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// Format selects how dumped requests are written to the log.
+type Format int
+
+const (
+	// FormatCurl writes each request as its `curl` equivalent. This is the default.
+	FormatCurl Format = iota
+	// FormatJSON writes each request as a single line of JSON-encoded Entry.
+	FormatJSON
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case FormatCurl:
+		return "curl"
+	case FormatJSON:
+		return "json"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// WithFormat is a CurlTransportOption that selects the log output format.
+func WithFormat(format Format) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Format = format
+	}
+}
+
+// WithSingleLine is a CurlTransportOption that writes each curl command on
+// one line instead of splitting it with backslash-newlines, for log
+// shippers that turn every line into a separate record. Line breaks in a
+// request body are written as ANSI-C escapes ($'...\n...'), and the
+// "# request" and "# MUTATING" markers follow the command as comments.
+func WithSingleLine() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.SingleLine = true
+	}
+}
+
+// WithPrettyJSON is a CurlTransportOption that indents JSON request
+// bodies (with a Content-Type of application/json or ending in "+json")
+// in the curl output, after redacting any SecretBodyFields, so that large
+// payloads are readable. Bodies that are not valid JSON are left alone,
+// and so is everything when WithSingleLine is also given.
+func WithPrettyJSON() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.PrettyJSON = true
+	}
+}
+
+// prettyJSON returns body indented if PrettyJSON applies to it.
+func (t *CurlTransport) prettyJSON(contentType string, body []byte) []byte {
+	if !t.PrettyJSON || t.SingleLine {
+		return body
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); !isJSON(mediaType) {
+		return body
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
+
+// WithPrettyXML is a CurlTransportOption that indents XML request bodies
+// (with a Content-Type of application/xml or text/xml, or ending in
+// "+xml"), such as the property queries of WebDAV PROPFIND and REPORT
+// requests, in the curl output. Namespace prefixes are kept as they are
+// sent. Bodies that are not well-formed XML are left alone, and so is
+// everything when WithSingleLine is also given.
+func WithPrettyXML() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.PrettyXML = true
+	}
+}
+
+// prettyXML returns body indented if PrettyXML applies to it.
+func (t *CurlTransport) prettyXML(contentType string, body []byte) []byte {
+	if !t.PrettyXML || t.SingleLine {
+		return body
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); !isXML(mediaType) {
+		return body
+	}
+	indented, err := indentXML(body)
+	if err != nil {
+		return body
+	}
+	return indented
+}
+
+// isXML reports whether mediaType is application/xml, text/xml, or a
+// structured syntax type based on XML (e.g. application/soap+xml).
+func isXML(mediaType string) bool {
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// indentXML returns the XML document body with one element per line,
+// indented by two spaces per level, and with the whitespace between
+// elements dropped. Unlike an xml.Encoder, it writes names with the
+// prefixes they were read with rather than resolving their namespaces.
+func indentXML(body []byte) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.Strict = true
+	var buf bytes.Buffer
+	depth := 0
+	// open is set while the last start tag written is still unclosed, so
+	// that an element without content can be written as "<name/>".
+	open := false
+	// text is set after character data, which its end tag follows on the
+	// same line.
+	text := false
+	newline := func() {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(strings.Repeat("  ", depth))
+	}
+	closeStart := func() {
+		if open {
+			buf.WriteByte('>')
+			open = false
+		}
+	}
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			closeStart()
+			newline()
+			buf.WriteString("<" + rawName(tok.Name))
+			for _, attr := range tok.Attr {
+				buf.WriteString(" " + rawName(attr.Name) + `="`)
+				xml.EscapeText(&buf, []byte(attr.Value))
+				buf.WriteByte('"')
+			}
+			depth++
+			open, text = true, false
+		case xml.EndElement:
+			depth--
+			switch {
+			case open:
+				buf.WriteString("/>")
+				open = false
+			case text:
+				buf.WriteString("</" + rawName(tok.Name) + ">")
+			default:
+				newline()
+				buf.WriteString("</" + rawName(tok.Name) + ">")
+			}
+			text = false
+		case xml.CharData:
+			if len(bytes.TrimSpace(tok)) == 0 {
+				continue
+			}
+			closeStart()
+			xml.EscapeText(&buf, tok)
+			text = true
+		case xml.Comment:
+			closeStart()
+			newline()
+			buf.WriteString("<!--" + string(tok) + "-->")
+		case xml.ProcInst:
+			closeStart()
+			newline()
+			buf.WriteString("<?" + tok.Target + " " + string(tok.Inst) + "?>")
+		case xml.Directive:
+			closeStart()
+			newline()
+			buf.WriteString("<!" + string(tok) + ">")
+		}
+	}
+	if depth != 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), nil
+}
+
+// rawName returns name as written in the document, with its prefix.
+func rawName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// WithPrefix is a CurlTransportOption that prepends prefix
+// (e.g. "[github-client] ") to every line logged by the transport.
+func WithPrefix(prefix string) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Prefix = prefix
+	}
+}
+
+// WithMutatingMarker is a CurlTransportOption that precedes the curl
+// output of every mutating request (see Entry.Mutating) with a
+// "# MUTATING" comment line, or ends it with a "# MUTATING" comment under
+// WithSingleLine.
+func WithMutatingMarker() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.MarkMutating = true
+	}
+}
+
+// formatEntry returns e formatted according to the transport's Format.
+func (t *CurlTransport) formatEntry(e *Entry) string {
+	if t.Format == FormatJSON {
+		if buf, err := json.Marshal(e); err == nil {
+			return string(buf)
+		}
+	}
+	if t.Verbosity == VerbosityLine {
+		return t.requestLine(e)
+	}
+	s := e.Curl
+	if t.SingleLine {
+		// The markers follow as shell comments, keeping one line.
+		if label := e.label(); label != "" {
+			s += " # request" + label
+		}
+		if t.MarkMutating && e.Mutating() {
+			s += " # MUTATING"
+		}
+		return s
+	}
+	if t.MarkMutating && e.Mutating() {
+		s = "# MUTATING\n" + s
+	}
+	if label := e.label(); label != "" {
+		s = "# request" + label + "\n" + s
+	}
+	return s
+}
@@ -0,0 +1,277 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat_String(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{format: FormatCurl, want: "curl"},
+		{format: FormatJSON, want: "json"},
+		{format: Format(42), want: "Format(42)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.format.String(); got != tt.want {
+				t.Errorf("Format.String = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithMutatingMarker(t *testing.T) {
+	if ct := New(WithMutatingMarker()); !ct.MarkMutating {
+		t.Error("WithMutatingMarker did not set MarkMutating")
+	}
+}
+
+func TestWithSingleLine(t *testing.T) {
+	ct := New(WithSingleLine(), WithTransport(&http.Transport{}))
+	if !ct.SingleLine {
+		t.Fatal("WithSingleLine did not set SingleLine")
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "one line body",
+			body: `{"a":1}`,
+			want: `curl -X POST https://example.com/foo -H 'Content-Type: text/plain' --data-raw '{"a":1}'`,
+		},
+		{
+			name: "multi-line body",
+			body: "a\r\nb'c\n",
+			want: `curl -X POST https://example.com/foo -H 'Content-Type: text/plain' --data-raw $'a\x0d\x0ab\'c\x0a'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "https://example.com/foo", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "text/plain")
+			got, err := ct.dumpRequestAsCurl(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("dumpRequestAsCurl =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPrettyJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []CurlTransportOption
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "JSON",
+			opts:        []CurlTransportOption{WithSecretBodyField("password")},
+			contentType: "application/json; charset=utf-8",
+			body:        `{"user":"bob","password":"hunter2","tags":["a"]}`,
+			want:        "--data-raw '{\n  \"password\": \"REDACTED\",\n  \"tags\": [\n    \"a\"\n  ],\n  \"user\": \"bob\"\n}'",
+		},
+		{
+			name:        "problem JSON",
+			contentType: "application/problem+json",
+			body:        `{"a":1}`,
+			want:        "--data-raw '{\n  \"a\": 1\n}'",
+		},
+		{
+			name:        "invalid JSON",
+			contentType: "application/json",
+			body:        `{"a":`,
+			want:        `--data-raw '{"a":'`,
+		},
+		{
+			name:        "not JSON",
+			contentType: "text/plain",
+			body:        `{"a":1}`,
+			want:        `--data-raw '{"a":1}'`,
+		},
+		{
+			name:        "single line",
+			opts:        []CurlTransportOption{WithSingleLine()},
+			contentType: "application/json",
+			body:        `{"a":1}`,
+			want:        `--data-raw '{"a":1}'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := New(append(tt.opts, WithPrettyJSON(), WithTransport(&http.Transport{}))...)
+			req, _ := http.NewRequest("POST", "https://example.com/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			got, err := ct.dumpRequestAsCurl(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(got, tt.want) {
+				t.Errorf("dumpRequestAsCurl =\n%v\nwant suffix:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPrettyXML(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []CurlTransportOption
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:        "WebDAV PROPFIND",
+			contentType: "application/xml; charset=utf-8",
+			body:        `<?xml version="1.0" encoding="utf-8"?>` + "\n" + `<D:propfind xmlns:D="DAV:"><D:prop><D:getetag/><D:displayname/></D:prop></D:propfind>`,
+			want: `--data-raw '<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:">
+  <D:prop>
+    <D:getetag/>
+    <D:displayname/>
+  </D:prop>
+</D:propfind>'`,
+		},
+		{
+			name:        "CalDAV REPORT with text",
+			contentType: "text/xml",
+			body:        `<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:href>/cal/a&amp;b.ics</D:href></C:calendar-multiget>`,
+			want: `--data-raw '<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:href>/cal/a&amp;b.ics</D:href>
+</C:calendar-multiget>'`,
+		},
+		{
+			name:        "invalid XML",
+			contentType: "application/xml",
+			body:        `<a><b></a`,
+			want:        `--data-raw '<a><b></a'`,
+		},
+		{
+			name:        "not XML",
+			contentType: "text/plain",
+			body:        `<a><b/></a>`,
+			want:        `--data-raw '<a><b/></a>'`,
+		},
+		{
+			name:        "single line",
+			opts:        []CurlTransportOption{WithSingleLine()},
+			contentType: "application/soap+xml",
+			body:        `<a><b/></a>`,
+			want:        `--data-raw '<a><b/></a>'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := New(append(tt.opts, WithPrettyXML(), WithTransport(&http.Transport{}))...)
+			req, _ := http.NewRequest("PROPFIND", "https://example.com/dav/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			got, err := ct.dumpRequestAsCurl(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(got, tt.want) {
+				t.Errorf("dumpRequestAsCurl =\n%v\nwant suffix:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	sink := &entryRecorder{}
+	ct := New(WithPrefix("[github-client] "), WithEntrySink(sink))
+	ct.writeEntry(ct.newOutput(), &Entry{Curl: "curl -X GET \\\n  /foo"})
+
+	if want := []string{"[github-client] curl -X GET \\\n[github-client]   /foo"}; !reflect.DeepEqual(logged, want) {
+		t.Errorf("logged = %#v, want %#v", logged, want)
+	}
+	if len(sink.entries) != 1 || sink.entries[0].Prefix != "[github-client] " {
+		t.Errorf("sink entries = %#v, want Prefix %q", sink.entries, "[github-client] ")
+	}
+}
+
+func TestCurlTransport_formatEntry(t *testing.T) {
+	e := &Entry{
+		Time:   time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		Method: "GET",
+		URL:    "/foo",
+		Curl:   "curl -X GET \\\n  /foo",
+	}
+
+	tests := []struct {
+		name   string
+		format Format
+		method string
+		mark   bool
+		want   string
+	}{
+		{
+			name:   "curl",
+			format: FormatCurl,
+			want:   "curl -X GET \\\n  /foo",
+		},
+		{
+			name:   "curl, marker on safe method",
+			format: FormatCurl,
+			mark:   true,
+			want:   "curl -X GET \\\n  /foo",
+		},
+		{
+			name:   "curl, marker on mutating method",
+			format: FormatCurl,
+			method: "DELETE",
+			mark:   true,
+			want:   "# MUTATING\ncurl -X GET \\\n  /foo",
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			want:   `{"time":"2022-01-02T03:04:05Z","method":"GET","url":"/foo","curl":"curl -X GET \\\n  /foo"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := New(WithFormat(tt.format))
+			ct.MarkMutating = tt.mark
+			e := *e
+			if tt.method != "" {
+				e.Method = tt.method
+			}
+			if got := ct.formatEntry(&e); got != tt.want {
+				t.Errorf("formatEntry =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurlTransport_formatEntry_SingleLine(t *testing.T) {
+	ct := New(WithSingleLine(), WithMutatingMarker())
+	e := &Entry{Seq: 1, Attempt: 2, Method: "POST", URL: "/foo", Curl: "curl -X POST /foo"}
+	if got, want := ct.formatEntry(e), "curl -X POST /foo # request #0001 attempt=2 # MUTATING"; got != want {
+		t.Errorf("formatEntry = %q, want %q", got, want)
+	}
+}
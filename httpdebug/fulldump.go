@@ -0,0 +1,223 @@
+package httpdebug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fullDumpKey is the context key set by FullDump.
+type fullDumpKey struct{}
+
+// FullDump returns a copy of ctx that causes any CurlTransport handling a
+// request made with it to dump that request at maximum verbosity: the
+// request, timings, and the complete response including its body. This
+// happens even if the transport is disabled or was configured without
+// timings, so that a single suspicious call site can be inspected without
+// changing how every other request is logged. Redaction still applies.
+// The response is logged once the caller has read its body to the end or
+// closed it, with at most the first 64 KiB of the body that was read.
+//
+//	req = req.WithContext(httpdebug.FullDump(req.Context()))
+func FullDump(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fullDumpKey{}, true)
+}
+
+// isFullDump reports whether ctx was returned by FullDump.
+func isFullDump(ctx context.Context) bool {
+	full, _ := ctx.Value(fullDumpKey{}).(bool)
+	return full
+}
+
+// CaptureNext causes the next n requests handled by t to be dumped at
+// maximum verbosity, as if they had been made with FullDump, regardless
+// of whether t is enabled, sampled, rate limited, or deduplicated. It is
+// meant for reproducing a problem once more while watching the log.
+// Calling CaptureNext again replaces the remaining count; an n of zero
+// or less cancels it.
+func (t *CurlTransport) CaptureNext(n int) {
+	if n < 0 {
+		n = 0
+	}
+	t.burst.Store(int64(n))
+}
+
+// takeBurst reports whether the next request falls within the count set
+// by CaptureNext, and uses it up if so.
+func (t *CurlTransport) takeBurst() bool {
+	for {
+		n := t.root().burst.Load()
+		if n <= 0 {
+			return false
+		}
+		if t.root().burst.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// CaptureNextHandler returns an http.Handler that calls CaptureNext with
+// the form value "n" of a POST request, so that a burst of full dumps can
+// be triggered from an admin endpoint:
+//
+//	http.Handle("/debug/httpdebug/capture-next", ct.CaptureNextHandler())
+//	$ curl -d n=5 localhost:6060/debug/httpdebug/capture-next
+//
+// A GET request reports the number of requests still to be captured.
+func (t *CurlTransport) CaptureNextHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost:
+			n, err := strconv.Atoi(r.PostFormValue("n"))
+			if err != nil {
+				http.Error(w, "invalid n", http.StatusBadRequest)
+				return
+			}
+			t.CaptureNext(n)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, t.burst.Load())
+	})
+}
+
+// dumpResponse logs the status, redacted headers, and redacted body of
+// resp to out as comment lines labeled with the request's label (see
+// Entry.label), along with the latency if elapsed is greater than zero.
+// So that streaming responses (e.g. server-sent events or long polls)
+// reach the caller at once, the body is not read here: it is recorded,
+// up to maxCaptureBody bytes, as the caller reads it, and the response is
+// logged once the caller has read it in full or closed it.
+func (t *CurlTransport) dumpResponse(out *output, label string, resp *http.Response, elapsed time.Duration) {
+	status := fmt.Sprintf("# response%v: %v %v", label, resp.Proto, resp.Status)
+	if elapsed > 0 {
+		status += " in " + t.duration(elapsed)
+	}
+	lines := []string{status}
+
+	header := t.redactHeaders(resp.Header)
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("# %v: %v", k, header.Get(k)))
+	}
+
+	if resp.Body == nil || resp.Body == http.NoBody {
+		out.log(strings.Join(lines, "\n"))
+		return
+	}
+	out.wait()
+	resp.Body = &dumpedBody{ReadCloser: resp.Body, t: t, out: out, contentType: resp.Header.Get("Content-Type"), lines: lines}
+}
+
+// dumpedBody records a response body as it is read, and logs the dump of
+// the response begun in lines to out once it has been read in full or
+// closed.
+type dumpedBody struct {
+	io.ReadCloser
+	t           *CurlTransport
+	out         *output
+	contentType string
+
+	mu        sync.Mutex
+	lines     []string
+	buf       []byte
+	n         int64
+	truncated bool
+	done      bool
+}
+
+func (b *dumpedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	if !b.done {
+		buf := p[:n]
+		if room := maxCaptureBody - len(b.buf); len(buf) > room {
+			buf = buf[:room]
+			b.truncated = true
+		}
+		b.buf = append(b.buf, buf...)
+		b.n += int64(n)
+	}
+	b.mu.Unlock()
+	switch {
+	case errors.Is(err, io.EOF):
+		b.finish(nil, false)
+	case err != nil:
+		b.finish(err, false)
+	}
+	return n, err
+}
+
+func (b *dumpedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish(nil, true)
+	return err
+}
+
+// finish logs the dump, if it has not been already, ending with readErr
+// if it is not nil. early reports whether the body was closed before it
+// was read in full.
+func (b *dumpedBody) finish(readErr error, early bool) {
+	b.mu.Lock()
+	if b.done {
+		b.mu.Unlock()
+		return
+	}
+	b.done = true
+	b.mu.Unlock()
+
+	t, lines := b.t, b.lines
+	if len(b.buf) > 0 {
+		lines = append(lines, "#")
+		redacted, ok := t.rules().retainedBody(b.contentType, b.buf)
+		body := string(redacted)
+		if !ok {
+			body = unredactableBody
+		} else if decoded, ok, decodeErr := t.decodeBody(b.contentType, b.buf); ok {
+			body = decoded
+			if decodeErr != nil {
+				body = decodeErr.Error()
+			}
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+			lines = append(lines, "# "+line)
+		}
+	}
+	switch {
+	case readErr != nil:
+		lines = append(lines, fmt.Sprintf("# response body error: %v", readErr))
+	case early:
+		lines = append(lines, fmt.Sprintf("# response body closed after %v", t.size(b.n)))
+	}
+	if b.truncated {
+		lines = append(lines, fmt.Sprintf("# response body truncated to %v", t.size(maxCaptureBody)))
+	}
+	b.out.release(strings.Join(lines, "\n"))
+}
+
+// errReader is an io.Reader that always returns err, or io.EOF if err is nil.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err == nil {
+		return 0, io.EOF
+	}
+	return 0, r.err
+}
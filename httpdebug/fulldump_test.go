@@ -0,0 +1,278 @@
+package httpdebug
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFullDump(t *testing.T) {
+	if isFullDump(context.Background()) {
+		t.Error("isFullDump(Background) = true, want false")
+	}
+	if !isFullDump(FullDump(context.Background())) {
+		t.Error("isFullDump(FullDump(Background)) = false, want true")
+	}
+}
+
+func TestRoundTrip_FullDump(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		fmt.Fprint(w, `{"token":"s3cr3t","id":1}`)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithSecretHeader("Set-Cookie"), WithSecretBodyField("token"))
+	ct.SetEnabled(false)
+	client.Transport = ct
+
+	// A plain request is not dumped by a disabled transport.
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+	if len(logged) != 0 {
+		t.Fatalf("logged = %#v, want nothing", logged)
+	}
+
+	req, _ := http.NewRequestWithContext(FullDump(context.Background()), "GET", url, nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("client.Do = %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("ReadAll = %v", err)
+	}
+	if got, want := string(body), `{"token":"s3cr3t","id":1}`; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+
+	if len(logged) != 3 {
+		t.Fatalf("logged = %#v, want curl, timing, and response", logged)
+	}
+	if !strings.HasPrefix(logged[0], "curl \\\n") {
+		t.Errorf("logged[0] = %q, want curl command", logged[0])
+	}
+	if !strings.HasPrefix(logged[1], "# timing: client=") {
+		t.Errorf("logged[1] = %q, want timing report", logged[1])
+	}
+	want := `# response: HTTP/1.1 200 OK
+# Content-Length: 25
+# Content-Type: application/json
+# Date: `
+	if !strings.HasPrefix(logged[2], want) {
+		t.Errorf("logged[2] =\n%v\nwant prefix:\n%v", logged[2], want)
+	}
+	wantSuffix := `# Set-Cookie: <REDACTED>
+#
+# {"id":1,"token":"REDACTED"}`
+	if !strings.HasSuffix(logged[2], wantSuffix) {
+		t.Errorf("logged[2] =\n%v\nwant suffix:\n%v", logged[2], wantSuffix)
+	}
+}
+
+func TestDumpResponse_BodyError(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	boom := errors.New("boom")
+	resp := &http.Response{
+		Proto:  "HTTP/1.1",
+		Status: "200 OK",
+		Body:   io.NopCloser(io.MultiReader(strings.NewReader("partial"), errReader{boom})),
+	}
+	ct := New()
+	ct.dumpResponse(ct.newOutput(), "", resp, 0)
+	if len(logged) != 0 {
+		t.Errorf("logged = %#v before the body was read, want nothing", logged)
+	}
+	if body, err := io.ReadAll(resp.Body); string(body) != "partial" || !errors.Is(err, boom) {
+		t.Errorf("ReadAll = (%q, %v), want (%q, %v)", body, err, "partial", boom)
+	}
+	resp.Body.Close()
+
+	want := "# response: HTTP/1.1 200 OK\n#\n# partial\n# response body error: boom"
+	if len(logged) != 1 || logged[0] != want {
+		t.Errorf("logged = %#v, want %q", logged, want)
+	}
+}
+
+func TestRoundTrip_FullDumpStreaming(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	release := make(chan struct{})
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: 1\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprint(w, "data: 2\n\n")
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), 2*maxCaptureBody))
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var mu sync.Mutex
+	var logged []string
+	logger = func(v ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		logged = append(logged, fmt.Sprint(v...))
+	}
+	last := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return logged[len(logged)-1]
+	}
+
+	client.Transport = New(WithTransport(&http.Transport{}))
+	ctx := FullDump(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", url+"/events", nil)
+	resp, err := client.Do(req)
+	close(release)
+	if err != nil {
+		t.Fatalf("client.Do = %v", err)
+	}
+	first := make([]byte, len("data: 1\n\n"))
+	if _, err := io.ReadFull(resp.Body, first); err != nil {
+		t.Fatalf("ReadFull = %v", err)
+	}
+	resp.Body.Close()
+	if got, want := last(), "#\n# data: 1\n# \n# response body closed after 9 B"; !strings.HasSuffix(got, want) {
+		t.Errorf("logged %q, want suffix %q", got, want)
+	}
+
+	req, _ = http.NewRequestWithContext(ctx, "GET", url+"/large", nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("client.Do = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if len(body) != 2*maxCaptureBody {
+		t.Errorf("read %v bytes, want %v", len(body), 2*maxCaptureBody)
+	}
+	if got, want := last(), "xxx\n# response body truncated to 66 kB"; !strings.HasSuffix(got, want) {
+		t.Errorf("logged %q, want suffix %q", got[len(got)-len(want):], want)
+	}
+}
+
+func TestRoundTrip_FullDumpLargeJSON(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"password":"RESPSECRET","padding":%q}`, strings.Repeat("x", 70000))
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	// The body is truncated, so it cannot be parsed to redact it.
+	client.Transport = New(WithTransport(&http.Transport{}), WithSecretBodyField("password"), WithVerbosity(3))
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	got := logged[len(logged)-1]
+	if want := "#\n# " + unredactableBody + "\n# response body truncated to 66 kB"; strings.Contains(got, "RESPSECRET") || !strings.HasSuffix(got, want) {
+		t.Errorf("logged %q, want the body omitted", got[max(0, len(got)-200):])
+	}
+}
+
+func TestCaptureNext(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithEveryNth(100))
+	ct.SetEnabled(false)
+	ct.CaptureNext(2)
+	client.Transport = ct
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	var curls, responses int
+	for _, l := range logged {
+		switch {
+		case strings.HasPrefix(l, "curl "):
+			curls++
+		case strings.HasPrefix(l, "# response"):
+			responses++
+		}
+	}
+	if curls != 2 || responses != 2 {
+		t.Errorf("logged = %#v, want 2 full dumps", logged)
+	}
+
+	ct.CaptureNext(-1)
+	if ct.takeBurst() {
+		t.Error("takeBurst after CaptureNext(-1) = true, want false")
+	}
+}
+
+func TestCaptureNextHandler(t *testing.T) {
+	ct := New()
+	h := ct.CaptureNextHandler()
+
+	tests := []struct {
+		method, body string
+		wantCode     int
+		wantBody     string
+	}{
+		{"POST", "n=3", http.StatusOK, "3\n"},
+		{"GET", "", http.StatusOK, "3\n"},
+		{"POST", "n=lots", http.StatusBadRequest, "invalid n\n"},
+		{"DELETE", "", http.StatusMethodNotAllowed, "method not allowed\n"},
+		{"POST", "n=0", http.StatusOK, "0\n"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+			t.Errorf("%v %q = (%v, %q), want (%v, %q)", tt.method, tt.body, w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+		}
+	}
+}
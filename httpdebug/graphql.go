@@ -0,0 +1,249 @@
+package httpdebug
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// WithGraphQL is a CurlTransportOption that describes GraphQL requests,
+// i.e. JSON bodies with a "query" string (or a batch of them) and bodies
+// of type application/graphql. Each operation is logged after the curl
+// command as its name, its query formatted one field per line, and its
+// variables, e.g.:
+//
+//	# graphql: query GetUser
+//	#   query GetUser($id: ID!) {
+//	#     user(id: $id) {
+//	#       name
+//	#     }
+//	#   }
+//	# variables: {"id":"42"}
+//
+// Variables are redacted like the rest of the body, so any variable
+// named by WithSecretBodyField is shown as "REDACTED".
+func WithGraphQL() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.GraphQL = true
+	}
+}
+
+// graphQLOperation is a GraphQL request as sent over HTTP.
+type graphQLOperation struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName"`
+	Variables     json.RawMessage `json:"variables"`
+}
+
+// graphQLAnnotation returns the comment lines describing the GraphQL
+// operations in the body of req, or "" if it has none.
+func (t *CurlTransport) graphQLAnnotation(label string, req *http.Request, body requestBody) string {
+	if body.truncated || len(body.data) == 0 {
+		return ""
+	}
+	contentType := req.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var ops []graphQLOperation
+	switch {
+	case mediaType == "application/graphql":
+		ops = []graphQLOperation{{Query: string(body.data)}}
+	case isJSON(mediaType):
+		ops = parseGraphQL(t.rules().Body(contentType, body.data))
+	}
+
+	var lines []string
+	for _, op := range ops {
+		lines = append(lines, fmt.Sprintf("# graphql%v: %v", label, op.name()))
+		for _, line := range strings.Split(formatGraphQL(op.Query), "\n") {
+			lines = append(lines, "#   "+line)
+		}
+		if v := bytes.TrimSpace(op.Variables); len(v) > 0 && !bytes.Equal(v, []byte("null")) {
+			lines = append(lines, "# variables: "+string(v))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseGraphQL returns the operations in the JSON body, which is either
+// a single operation or a batch of them, or nil if it has none.
+func parseGraphQL(body []byte) []graphQLOperation {
+	var ops []graphQLOperation
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		if err := json.Unmarshal(body, &ops); err != nil {
+			return nil
+		}
+	} else {
+		var op graphQLOperation
+		if err := json.Unmarshal(body, &op); err != nil {
+			return nil
+		}
+		ops = []graphQLOperation{op}
+	}
+	for _, op := range ops {
+		if strings.TrimSpace(op.Query) == "" {
+			return nil
+		}
+	}
+	return ops
+}
+
+// name returns the operation's type and name, e.g. "mutation AddUser",
+// taken from its query and OperationName.
+func (op *graphQLOperation) name() string {
+	tokens := graphQLTokens(op.Query)
+	kind := "query"
+	if len(tokens) > 0 {
+		switch tokens[0] {
+		case "mutation", "subscription":
+			kind = tokens[0]
+		}
+	}
+	name := op.OperationName
+	if name == "" && len(tokens) > 1 && tokens[0] == kind && isGraphQLName(tokens[1]) {
+		name = tokens[1]
+	}
+	if name == "" {
+		return kind
+	}
+	return kind + " " + name
+}
+
+// isGraphQLName reports whether tok is a name rather than punctuation.
+func isGraphQLName(tok string) bool {
+	c := tok[0]
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// formatGraphQL returns query with its comments removed and its
+// selection sets indented, one selection per line.
+func formatGraphQL(query string) string {
+	var sb strings.Builder
+	var depth int
+	// braces records, for each open brace, whether it starts a selection
+	// set (rather than an input object value).
+	var braces []bool
+	parens := 0
+	prev, prevPrev := "", ""
+	newline := func() {
+		sb.WriteByte('\n')
+		sb.WriteString(strings.Repeat("  ", depth))
+	}
+	for _, tok := range graphQLTokens(query) {
+		inSelection := depth > 0 && parens == 0 && len(braces) > 0 && braces[len(braces)-1]
+		switch {
+		case prev == "":
+		case tok == "{" && parens == 0:
+			sb.WriteByte(' ')
+		case tok == "}" && len(braces) > 0 && braces[len(braces)-1]:
+		case prev == "{" && inSelection:
+			newline()
+		case prev == "}" && depth == 0 && parens == 0:
+			sb.WriteString("\n\n")
+		case inSelection && startsSelection(tok, prev, prevPrev):
+			newline()
+		case tok == "(" || tok == ")" || tok == "]" || tok == ":" || tok == "!" || tok == "}" && parens > 0,
+			prev == "(" || prev == "[" || prev == "$" || prev == "@" || prev == "{" && parens > 0,
+			prev == "..." && tok != "on":
+		default:
+			sb.WriteByte(' ')
+		}
+
+		switch tok {
+		case "{":
+			selection := parens == 0
+			braces = append(braces, selection)
+			if selection {
+				depth++
+			}
+		case "}":
+			if len(braces) > 0 {
+				if braces[len(braces)-1] {
+					depth--
+					newline()
+				}
+				braces = braces[:len(braces)-1]
+			}
+		case "(", "[":
+			parens++
+		case ")", "]":
+			if parens > 0 {
+				parens--
+			}
+		}
+		sb.WriteString(tok)
+		prev, prevPrev = tok, prev
+	}
+	return sb.String()
+}
+
+// startsSelection reports whether tok, following prev and prevPrev in a
+// selection set, starts a new selection rather than continuing the
+// current one (as after an alias, a fragment spread, or a directive).
+func startsSelection(tok, prev, prevPrev string) bool {
+	switch {
+	case tok == "(" || tok == ")" || tok == ":" || tok == "@" || tok == "{" || tok == "}":
+		return false
+	case prev == ":" || prev == "..." || prev == "@" || prev == "on" && prevPrev == "...":
+		return false
+	}
+	return true
+}
+
+// graphQLTokens splits query into its tokens, dropping whitespace,
+// commas, and comments.
+func graphQLTokens(query string) []string {
+	var tokens []string
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			end := graphQLStringEnd(query, i)
+			tokens = append(tokens, query[i:end])
+			i = end
+		case strings.HasPrefix(query[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case strings.IndexByte("!$&()=:@[]{|}", c) >= 0:
+			tokens = append(tokens, query[i:i+1])
+			i++
+		default:
+			j := i + 1
+			for j < len(query) && strings.IndexByte(" \t\n\r,#\"!$&()=:@[]{|}", query[j]) < 0 && !strings.HasPrefix(query[j:], "...") {
+				j++
+			}
+			tokens = append(tokens, query[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+// graphQLStringEnd returns the index just after the string value that
+// starts at query[i], which is '"'.
+func graphQLStringEnd(query string, i int) int {
+	if strings.HasPrefix(query[i:], `"""`) {
+		if end := strings.Index(query[i+3:], `"""`); end >= 0 {
+			return i + 3 + end + 3
+		}
+		return len(query)
+	}
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(query)
+}
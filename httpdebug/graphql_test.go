@@ -0,0 +1,149 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFormatGraphQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "shorthand",
+			query: "{ viewer { login } }",
+			want:  "{\n  viewer {\n    login\n  }\n}",
+		},
+		{
+			name: "operation with variables, aliases, and directives",
+			query: `query GetUser($id: ID!, $withOrgs: Boolean = false) {
+  # who is it?
+  user(id: $id) { name, handle: login
+    orgs(first: 10) @include(if: $withOrgs) { nodes { name } } }
+}`,
+			want: `query GetUser($id: ID! $withOrgs: Boolean = false) {
+  user(id: $id) {
+    name
+    handle: login
+    orgs(first: 10) @include(if: $withOrgs) {
+      nodes {
+        name
+      }
+    }
+  }
+}`,
+		},
+		{
+			name:  "mutation with input object and fragments",
+			query: `mutation { createUser(input: {name: "a, {b}", tags: ["x"]}) { ...UserFields ... on Admin { level } } } fragment UserFields on User { id }`,
+			want: `mutation {
+  createUser(input: {name: "a, {b}" tags: ["x"]}) {
+    ...UserFields
+    ... on Admin {
+      level
+    }
+  }
+}
+
+fragment UserFields on User {
+  id
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatGraphQL(tt.query); got != tt.want {
+				t.Errorf("formatGraphQL =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphQLOperation_name(t *testing.T) {
+	tests := []struct {
+		op   graphQLOperation
+		want string
+	}{
+		{op: graphQLOperation{Query: "{ viewer { login } }"}, want: "query"},
+		{op: graphQLOperation{Query: "query GetUser($id: ID!) { a }"}, want: "query GetUser"},
+		{op: graphQLOperation{Query: "mutation{ a }"}, want: "mutation"},
+		{op: graphQLOperation{Query: "subscription OnEvent { a }"}, want: "subscription OnEvent"},
+		{op: graphQLOperation{Query: "query A { a } query B { b }", OperationName: "B"}, want: "query B"},
+	}
+	for _, tt := range tests {
+		if got := tt.op.name(); got != tt.want {
+			t.Errorf("name(%q) = %q, want %q", tt.op.Query, got, tt.want)
+		}
+	}
+}
+
+func TestRoundTrip_GraphQL(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithGraphQL(), WithSecretBodyField("password"))
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			contentType: "application/json",
+			body:        `{"query":"mutation Login($user: String!, $password: String!) { login(user: $user, password: $password) { token } }","variables":{"user":"bob","password":"hunter2"}}`,
+			want: `# graphql: mutation Login
+#   mutation Login($user: String! $password: String!) {
+#     login(user: $user password: $password) {
+#       token
+#     }
+#   }
+# variables: {"password":"REDACTED","user":"bob"}`,
+		},
+		{
+			contentType: "application/json",
+			body:        `[{"query":"{ a }"},{"query":"query B { b }","variables":null}]`,
+			want:        "# graphql: query\n#   {\n#     a\n#   }\n# graphql: query B\n#   query B {\n#     b\n#   }",
+		},
+		{
+			contentType: "application/graphql",
+			body:        `{ a }`,
+			want:        "# graphql: query\n#   {\n#     a\n#   }",
+		},
+		{
+			contentType: "application/json",
+			body:        `{"name":"not graphql"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		logged = nil
+		resp, err := client.Post(url+"/graphql", tt.contentType, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("client.Post = %v", err)
+		}
+		resp.Body.Close()
+
+		if tt.want == "" {
+			if len(logged) != 1 {
+				t.Errorf("logged = %#v, want only the curl command", logged)
+			}
+			continue
+		}
+		if len(logged) != 2 || logged[1] != tt.want {
+			t.Errorf("logged = %#v, want:\n%v", logged, tt.want)
+		}
+		if strings.Contains(logged[0], "hunter2") {
+			t.Errorf("curl command %q contains the password", logged[0])
+		}
+	}
+}
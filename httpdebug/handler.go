@@ -0,0 +1,110 @@
+package httpdebug
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// Handler returns an http.Handler that serves the captured exchanges,
+// newest first, so that recent outbound calls can be inspected live.
+// It is typically mounted alongside pprof:
+//
+//	http.Handle("/debug/httpdebug", ct.Capture.Handler())
+//
+// An HTML page is served by default. The exchanges are served as a JSON
+// array instead when the request has the query parameter "format=json"
+// or prefers "application/json" in its Accept header.
+//
+// A POST request with the form value "marker" inserts a marker with that
+// name into the timeline (see Capture.Mark).
+func (c *Capture) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost:
+			c.handleMark(w, r)
+			return
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		exchanges := c.Captured()
+		for i, j := 0, len(exchanges)-1; i < j; i, j = i+1, j-1 {
+			exchanges[i], exchanges[j] = exchanges[j], exchanges[i]
+		}
+
+		if wantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if exchanges == nil {
+				exchanges = []Exchange{}
+			}
+			json.NewEncoder(w).Encode(exchanges)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		captureTemplate.Execute(w, exchanges)
+	})
+}
+
+// handleMark inserts the marker posted in r. Browsers submitting the
+// form on the HTML page are redirected back to it.
+func (c *Capture) handleMark(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.PostFormValue("marker"))
+	if name == "" {
+		http.Error(w, "missing marker", http.StatusBadRequest)
+		return
+	}
+	c.Mark(name)
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		http.Redirect(w, r, r.URL.String(), http.StatusSeeOther)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// wantsJSON reports whether r asks for a JSON response.
+func wantsJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "json" {
+		return true
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
+
+var captureTemplate = template.Must(template.New("capture").Funcs(template.FuncMap{"duration": FormatDuration}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>httpdebug</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+.exchange { border-top: 1px solid #ccc; padding: 0.5em 0; }
+.error { color: #b00; }
+pre { background: #f4f4f4; padding: 0.5em; overflow-x: auto; }
+.mutating { border-left: 4px solid #d97706; padding-left: 0.5em; }
+.mutating .method { color: #d97706; }
+.badge { font-size: 0.7em; background: #d97706; color: #fff; padding: 0.1em 0.4em; border-radius: 3px; vertical-align: middle; }
+.marker { border-top: 2px dashed #2563eb; color: #2563eb; padding: 0.5em 0; }
+</style>
+</head>
+<body>
+<h1>httpdebug</h1>
+<p>{{len .}} captured exchange(s), newest first. <a href="?format=json">JSON</a></p>
+<form method="post"><input name="marker" placeholder="e.g. clicked submit"> <button>Insert marker</button></form>
+{{range .}}{{if .Marker}}<div class="marker">&#9873; {{.Marker.Time.Format "2006-01-02T15:04:05.000Z07:00"}} &middot; <strong>{{.Marker.Name}}</strong></div>
+{{else}}<div class="exchange{{if .Request.Mutating}} mutating{{end}}">
+<h3><span class="method">{{.Request.Method}}</span> {{.Request.URL}}{{if .Request.Mutating}} <span class="badge">MUTATING</span>{{end}}</h3>
+<p>{{.Request.Time.Format "2006-01-02T15:04:05.000Z07:00"}} &middot; {{if .Err}}<span class="error">{{.Err}}</span>{{else}}{{.Status}}{{end}} &middot; {{duration .Duration}}</p>
+<pre>{{.Request.Curl}}</pre>
+{{if .Header}}<details><summary>Response headers</summary><pre>{{range $k, $v := .Header}}{{range $v}}{{$k}}: {{.}}
+{{end}}{{end}}</pre></details>{{end}}
+{{if .Body}}<details><summary>Response body{{if .BodyTruncated}} (truncated){{end}}</summary><pre>{{printf "%s" .Body}}</pre></details>{{end}}
+</div>{{end}}
+{{end}}</body>
+</html>
+`))
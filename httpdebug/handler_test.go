@@ -0,0 +1,140 @@
+package httpdebug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCapture_Handler(t *testing.T) {
+	c := NewCapture(5)
+	c.add(&Exchange{
+		Request:    &Entry{Time: time.Now(), Method: "GET", URL: "/first", Curl: "curl -X GET \\\n  /first"},
+		StatusCode: 200,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"text/plain"}, "Set-Cookie": []string{"a=1", "b=2"}},
+		Body:       []byte("<hello>"),
+	})
+	c.add(&Exchange{
+		Request: &Entry{Time: time.Now(), Method: "POST", URL: "/second", Curl: "curl -X POST \\\n  /second"},
+		Err:     "connection refused",
+	})
+	h := c.Handler()
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		accept   string
+		wantCode int
+		wantType string
+	}{
+		{name: "html", method: "GET", target: "/", wantCode: 200, wantType: "text/html; charset=utf-8"},
+		{name: "browser accept", method: "GET", target: "/", accept: "text/html,application/json", wantCode: 200, wantType: "text/html; charset=utf-8"},
+		{name: "json query", method: "GET", target: "/?format=json", wantCode: 200, wantType: "application/json"},
+		{name: "json accept", method: "GET", target: "/", accept: "application/json", wantCode: 200, wantType: "application/json"},
+		{name: "bad method", method: "DELETE", target: "/", wantCode: http.StatusMethodNotAllowed, wantType: "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if tt.wantCode != 200 {
+				return
+			}
+
+			body := w.Body.String()
+			if tt.wantType == "application/json" {
+				var got []Exchange
+				if err := json.Unmarshal([]byte(body), &got); err != nil {
+					t.Fatalf("json.Unmarshal = %v", err)
+				}
+				if len(got) != 2 || got[0].Request.URL != "/second" || got[1].Request.URL != "/first" {
+					t.Errorf("exchanges = %+v, want newest first", got)
+				}
+				return
+			}
+
+			second, first := strings.Index(body, "POST</span> /second"), strings.Index(body, "GET</span> /first")
+			if second < 0 || first < 0 || second > first {
+				t.Errorf("HTML does not list exchanges newest first:\n%v", body)
+			}
+			for _, want := range []string{"connection refused", "200 OK", "&lt;hello&gt;", "Content-Type: text/plain", "Set-Cookie: a=1\nSet-Cookie: b=2\n", `<div class="exchange mutating">`, `<span class="badge">MUTATING</span>`} {
+				if !strings.Contains(body, want) {
+					t.Errorf("HTML missing %q:\n%v", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestCapture_HandlerEmptyJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewCapture(1).Handler().ServeHTTP(w, httptest.NewRequest("GET", "/?format=json", nil))
+	if got := strings.TrimSpace(w.Body.String()); got != "[]" {
+		t.Errorf("body = %q, want []", got)
+	}
+}
+
+func TestCapture_HandlerMark(t *testing.T) {
+	c := NewCapture(5)
+	c.add(&Exchange{Request: &Entry{Time: time.Now(), Method: "GET", URL: "/before", Curl: "curl \\\n  /before"}})
+	h := c.Handler()
+
+	tests := []struct {
+		name     string
+		body     string
+		accept   string
+		wantCode int
+	}{
+		{name: "missing marker", body: "marker=+", wantCode: http.StatusBadRequest},
+		{name: "api", body: "marker=clicked+submit", wantCode: http.StatusNoContent},
+		{name: "browser form", body: "marker=page+loaded", accept: "text/html", wantCode: http.StatusSeeOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/debug/httpdebug?x=1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusSeeOther && w.Header().Get("Location") != "/debug/httpdebug?x=1" {
+				t.Errorf("Location = %q, want the page", w.Header().Get("Location"))
+			}
+		})
+	}
+
+	got := c.Captured()
+	if len(got) != 3 || got[1].Marker == nil || got[1].Marker.Name != "clicked submit" || got[2].Marker.Name != "page loaded" {
+		t.Fatalf("Captured = %+v, want exchange and two markers", got)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	body := w.Body.String()
+	marker, before := strings.Index(body, "<strong>clicked submit</strong>"), strings.Index(body, "GET</span> /before")
+	if marker < 0 || before < 0 || marker > before {
+		t.Errorf("HTML does not list the marker before the older exchange:\n%v", body)
+	}
+}
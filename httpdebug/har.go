@@ -0,0 +1,190 @@
+package httpdebug
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HAR is an HTTP Archive, as exported by browsers and proxies.
+// Only the fields used by this package are represented.
+// See http://www.softwareishard.com/blog/har-12-spec/.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of an HTTP Archive.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that created an HTTP Archive.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single request/response pair in an HTTP Archive.
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+}
+
+// HARRequest is a recorded request.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse is a recorded response.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARNameValue is a header or query parameter.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is a recorded request body.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is a recorded response body. Text is base64-encoded
+// if Encoding is "base64".
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// ReadHAR reads the HTTP Archive saved at path.
+func ReadHAR(path string) (*HAR, error) {
+	return ReadHARFrom(context.Background(), Dir(""), path)
+}
+
+// ReadHARFrom reads the HTTP Archive saved in s under name.
+func ReadHARFrom(ctx context.Context, s Storage, name string) (*HAR, error) {
+	buf, err := s.ReadFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	h := &HAR{}
+	if err := json.Unmarshal(buf, h); err != nil {
+		return nil, fmt.Errorf("httpdebug: HAR %v: %w", name, err)
+	}
+	return h, nil
+}
+
+// WriteHAR writes the HTTP Archive to s under name.
+func WriteHAR(ctx context.Context, s Storage, name string, h *HAR) error {
+	buf, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.WriteFile(ctx, name, append(buf, '\n'))
+}
+
+// harHeader returns the name/value pairs as an http.Header, skipping
+// HTTP/2 pseudo-headers (e.g. ":authority").
+func harHeader(pairs []HARNameValue) http.Header {
+	h := http.Header{}
+	for _, p := range pairs {
+		if strings.HasPrefix(p.Name, ":") {
+			continue
+		}
+		h.Add(p.Name, p.Value)
+	}
+	return h
+}
+
+// NewRequest returns a new http.Request that re-issues the recorded request.
+func (r *HARRequest) NewRequest(ctx context.Context) (*http.Request, error) {
+	var body []byte
+	if r.PostData != nil {
+		body = []byte(r.PostData.Text)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = harHeader(r.Headers)
+	req.Header.Del("Content-Length")
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+	if r.PostData != nil && r.PostData.MimeType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", r.PostData.MimeType)
+	}
+	return req, nil
+}
+
+// Body returns the decoded response body.
+func (c *HARContent) Body() ([]byte, error) {
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+	return []byte(c.Text), nil
+}
+
+// CassetteResponse returns the recorded response in the form used by
+// cassettes, so that it can be compared with DiffResponse.
+func (r *HARResponse) CassetteResponse() (*CassetteResponse, error) {
+	body, err := r.Content.Body()
+	if err != nil {
+		return nil, err
+	}
+	return &CassetteResponse{
+		StatusCode: r.Status,
+		Status:     strings.TrimSpace(fmt.Sprintf("%v %v", r.Status, r.StatusText)),
+		Header:     harHeader(r.Headers),
+		Body:       body,
+	}, nil
+}
+
+// Interactions returns the entries of the archive in the form used by
+// cassettes.
+func (h *HAR) Interactions() ([]*Interaction, error) {
+	var interactions []*Interaction
+	for _, e := range h.Log.Entries {
+		resp, err := e.Response.CassetteResponse()
+		if err != nil {
+			return nil, fmt.Errorf("%v %v: %w", e.Request.Method, e.Request.URL, err)
+		}
+		req := CassetteRequest{Method: e.Request.Method, URL: e.Request.URL, Header: harHeader(e.Request.Headers)}
+		if e.Request.PostData != nil {
+			req.Body = []byte(e.Request.PostData.Text)
+			if req.Header.Get("Content-Type") == "" && e.Request.PostData.MimeType != "" {
+				req.Header.Set("Content-Type", e.Request.PostData.MimeType)
+			}
+		}
+		interactions = append(interactions, &Interaction{Request: req, Response: *resp})
+	}
+	return interactions, nil
+}
@@ -0,0 +1,103 @@
+package httpdebug
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testHAR = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "test", "version": "1"},
+    "entries": [
+      {
+        "startedDateTime": "2022-01-02T03:04:05.000Z",
+        "time": 12.5,
+        "request": {
+          "method": "POST",
+          "url": "https://api.example.com/v1/items?q=1",
+          "httpVersion": "HTTP/2",
+          "headers": [
+            {"name": ":authority", "value": "api.example.com"},
+            {"name": "Accept", "value": "application/json"},
+            {"name": "Content-Length", "value": "13"}
+          ],
+          "queryString": [{"name": "q", "value": "1"}],
+          "postData": {"mimeType": "application/json", "text": "{\"name\":\"a\"}"},
+          "headersSize": -1,
+          "bodySize": 13
+        },
+        "response": {
+          "status": 201,
+          "statusText": "Created",
+          "httpVersion": "HTTP/2",
+          "headers": [{"name": "content-type", "value": "application/json"}],
+          "content": {"size": 5, "mimeType": "application/json", "text": "eyJpZCI6MX0=", "encoding": "base64"},
+          "redirectURL": "",
+          "headersSize": -1,
+          "bodySize": 8
+        }
+      }
+    ]
+  }
+}`
+
+func TestReadHAR(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.har")
+	if err := os.WriteFile(path, []byte(testHAR), 0600); err != nil {
+		t.Fatal(err)
+	}
+	h, err := ReadHAR(path)
+	if err != nil {
+		t.Fatalf("ReadHAR = %v", err)
+	}
+	if len(h.Log.Entries) != 1 {
+		t.Fatalf("got %v entries, want 1", len(h.Log.Entries))
+	}
+	e := h.Log.Entries[0]
+
+	req, err := e.Request.NewRequest(context.Background())
+	if err != nil {
+		t.Fatalf("NewRequest = %v", err)
+	}
+	if req.Method != "POST" || req.URL.String() != "https://api.example.com/v1/items?q=1" {
+		t.Errorf("request = %v %v, want POST https://api.example.com/v1/items?q=1", req.Method, req.URL)
+	}
+	wantHeader := map[string][]string{
+		"Accept":       {"application/json"},
+		"Content-Type": {"application/json"},
+	}
+	if !reflect.DeepEqual(map[string][]string(req.Header), wantHeader) {
+		t.Errorf("request header = %v, want %v", req.Header, wantHeader)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if got, want := string(body), `{"name":"a"}`; got != want {
+		t.Errorf("request body = %q, want %q", got, want)
+	}
+
+	resp, err := e.Response.CassetteResponse()
+	if err != nil {
+		t.Fatalf("CassetteResponse = %v", err)
+	}
+	if resp.StatusCode != 201 || resp.Status != "201 Created" || string(resp.Body) != `{"id":1}` {
+		t.Errorf("response = %v %q, want 201 Created {\"id\":1}", resp.Status, resp.Body)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("response Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestReadHAR_Errors(t *testing.T) {
+	if _, err := ReadHAR(filepath.Join(t.TempDir(), "missing.har")); err == nil {
+		t.Error("ReadHAR(missing) = nil error, want error")
+	}
+	path := filepath.Join(t.TempDir(), "bad.har")
+	os.WriteFile(path, []byte("not json"), 0600)
+	if _, err := ReadHAR(path); err == nil {
+		t.Error("ReadHAR(bad) = nil error, want error")
+	}
+}
@@ -0,0 +1,87 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithAuthHints is a CurlTransportOption that logs a short diagnostic
+// section for 401 Unauthorized and 403 Forbidden responses, assembled from
+// the request and response, to save working through the usual checklist.
+func WithAuthHints() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.AuthHints = true
+	}
+}
+
+// scopeHeaders are response headers that describe the OAuth scopes
+// granted to, and required of, a token (as sent by e.g. GitHub).
+var scopeHeaders = []string{"X-OAuth-Scopes", "X-Accepted-OAuth-Scopes"}
+
+// authHints returns the diagnostic section for resp, or "" if resp is
+// not a 401 or 403. No secret values are included.
+func (t *CurlTransport) authHints(req *http.Request, resp *http.Response, now time.Time) string {
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+		return ""
+	}
+
+	lines := []string{fmt.Sprintf("# %v hints:", resp.Status)}
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, "#   "+fmt.Sprintf(format, args...))
+	}
+
+	if auth := req.Header.Get("Authorization"); auth == "" {
+		add("Authorization header: missing")
+	} else if scheme, _, ok := strings.Cut(auth, " "); ok {
+		add("Authorization header: present (scheme %q)", scheme)
+	} else {
+		add("Authorization header: present (no scheme)")
+	}
+
+	var keys []string
+	for k := range req.Header {
+		if t.isSecretHeader(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		token, ok := parseJWT(req.Header.Get(k))
+		if !ok {
+			continue
+		}
+		add("%v JWT: %v", k, token)
+		if exp, ok := token.expiry(); ok {
+			add("%v JWT %v", k, expiryStatus(exp, now))
+		} else {
+			add("%v JWT has no exp claim", k)
+		}
+	}
+
+	if t.TokenSource != nil {
+		if token, err := t.TokenSource.Token(); err != nil {
+			add("oauth2 token source error: %v", err)
+		} else if !token.Expiry.IsZero() {
+			add("oauth2 token %v", expiryStatus(token.Expiry, now))
+		}
+	}
+
+	challenges := resp.Header.Values("WWW-Authenticate")
+	if len(challenges) == 0 && resp.StatusCode == http.StatusUnauthorized {
+		add("WWW-Authenticate: missing")
+	}
+	for _, c := range challenges {
+		add("WWW-Authenticate: %v", c)
+	}
+
+	for _, h := range scopeHeaders {
+		if v, ok := resp.Header[http.CanonicalHeaderKey(h)]; ok {
+			add("%v: %v", h, strings.Join(v, ", "))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
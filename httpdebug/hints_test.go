@@ -0,0 +1,129 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestWithAuthHints(t *testing.T) {
+	if ct := New(WithAuthHints()); !ct.AuthHints {
+		t.Error("WithAuthHints did not set AuthHints")
+	}
+}
+
+func TestCurlTransport_authHints(t *testing.T) {
+	now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	expired := makeJWT(`{"alg":"HS256"}`, fmt.Sprintf(`{"aud":"api","exp":%v}`, now.Add(-time.Minute).Unix()))
+
+	tests := []struct {
+		name       string
+		reqHeader  http.Header
+		status     int
+		respHeader http.Header
+		ts         oauth2.TokenSource
+		want       string
+	}{
+		{
+			name:   "not an auth failure",
+			status: http.StatusOK,
+		},
+		{
+			name:   "401 with opaque Authorization",
+			status: http.StatusUnauthorized,
+			want: `# 401 Unauthorized hints:
+#   Authorization header: present (no scheme)
+#   WWW-Authenticate: missing`,
+			reqHeader: http.Header{"Authorization": []string{"opaque"}},
+		},
+		{
+			name:      "401 with expired JWT",
+			reqHeader: http.Header{"Authorization": []string{"Bearer " + expired}},
+			status:    http.StatusUnauthorized,
+			respHeader: http.Header{
+				"Www-Authenticate": []string{`Bearer error="invalid_token"`},
+			},
+			want: `# 401 Unauthorized hints:
+#   Authorization header: present (scheme "Bearer")
+#   Authorization JWT: alg=HS256 aud=api exp=2022-01-02T03:03:05Z
+#   Authorization JWT expired 1m0s ago (exp=2022-01-02T03:03:05Z)
+#   WWW-Authenticate: Bearer error="invalid_token"`,
+		},
+		{
+			name:      "403 with scopes and token source",
+			reqHeader: http.Header{"Authorization": []string{"token abc"}},
+			status:    http.StatusForbidden,
+			respHeader: http.Header{
+				"X-Oauth-Scopes":          []string{"repo"},
+				"X-Accepted-Oauth-Scopes": []string{"admin:org"},
+			},
+			ts: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "abc", Expiry: now.Add(time.Hour)}),
+			want: `# 403 Forbidden hints:
+#   Authorization header: present (scheme "token")
+#   oauth2 token expires in 1h0m0s (exp=2022-01-02T04:04:05Z)
+#   X-OAuth-Scopes: repo
+#   X-Accepted-OAuth-Scopes: admin:org`,
+		},
+		{
+			name:   "403 without credentials",
+			status: http.StatusForbidden,
+			ts:     errTokenSource{},
+			want: `# 403 Forbidden hints:
+#   Authorization header: missing
+#   oauth2 token source error: no token`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/foo", nil)
+			for k, v := range tt.reqHeader {
+				req.Header[k] = v
+			}
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Status:     fmt.Sprintf("%v %v", tt.status, http.StatusText(tt.status)),
+				Header:     tt.respHeader,
+			}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+
+			ct := New(WithAuthHints(), WithTokenSource(tt.ts))
+			if got := ct.authHints(req, resp, now); got != tt.want {
+				t.Errorf("authHints =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_AuthHints(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithAuthHints())
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	want := `# 401 Unauthorized hints:
+#   Authorization header: missing
+#   WWW-Authenticate: Basic realm="test"`
+	if len(logged) != 2 || logged[1] != want {
+		t.Errorf("logged = %#v, want hints %q", logged, want)
+	}
+}
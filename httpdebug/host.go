@@ -0,0 +1,77 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hostOverride returns the Host header of req if it differs from the
+// host of its URL (as set by service meshes and gateways that route on
+// it), or "" if req is sent to the host it names.
+func hostOverride(req *http.Request) string {
+	if req.Host == "" || req.Host == req.URL.Host {
+		return ""
+	}
+	return req.Host
+}
+
+// sniAnnotation returns a warning if the https request req is sent with
+// a TLS server name (taken from its URL, or from the Transport's
+// TLSClientConfig.ServerName) that differs from its Host header, or "" if
+// they match. Servers that pick a certificate by server name and a
+// virtual host by Host header answer such requests with certificate
+// errors or 404s that are hard to explain from the Go code alone. If the
+// Host header was overridden, the warning suggests a curl "--connect-to"
+// command line that sends its name for both.
+func (t *CurlTransport) sniAnnotation(label string, req *http.Request) string {
+	if req.URL.Scheme != "https" {
+		return ""
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	serverName := req.URL.Hostname()
+	if tr, ok := t.httpTransport(); ok && tr.TLSClientConfig != nil && tr.TLSClientConfig.ServerName != "" {
+		serverName = tr.TLSClientConfig.ServerName
+	}
+	hostName, hostPort := splitHostPort(host)
+	if strings.EqualFold(hostName, serverName) {
+		return ""
+	}
+
+	warning := fmt.Sprintf("# sni%v: TLS server name %v does not match Host header %v; expect certificate errors or 404s if the server routes on either",
+		label, serverName, host)
+	if hostOverride(req) == "" {
+		return warning
+	}
+	urlName, urlPort := splitHostPort(req.URL.Host)
+	u := *req.URL
+	u.Host = host
+	return fmt.Sprintf("%v\n#   to send %v for both: curl --connect-to %v %v", warning, hostName,
+		shellQuote(connectTo(hostName, hostPort, urlName, urlPort)), shellWord(t.sanitizeURL(&u)))
+}
+
+// splitHostPort returns the name and port of the https host, which may
+// lack a port, in which case it is "443".
+func splitHostPort(host string) (name, port string) {
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return strings.Trim(host, "[]"), "443"
+	}
+	return name, port
+}
+
+// connectTo returns the argument of curl's "--connect-to" flag that sends
+// requests for fromName:fromPort to toName:toPort.
+func connectTo(fromName, fromPort, toName, toPort string) string {
+	bracket := func(name string) string {
+		if strings.Contains(name, ":") {
+			return "[" + name + "]"
+		}
+		return name
+	}
+	return bracket(fromName) + ":" + fromPort + ":" + bracket(toName) + ":" + toPort
+}
@@ -0,0 +1,72 @@
+package httpdebug
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestDumpRequestAsCurl_HostOverride(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://10.0.0.7:8080/api", nil)
+	req.Host = "api.internal"
+
+	got, err := New().dumpRequestAsCurl(req)
+	if err != nil {
+		t.Fatalf("dumpRequestAsCurl = %v", err)
+	}
+	if want := "curl \\\n  http://10.0.0.7:8080/api \\\n  -H 'Host: api.internal'"; got != want {
+		t.Errorf("dumpRequestAsCurl =\n%v\nwant:\n%v", got, want)
+	}
+
+	req.Host = req.URL.Host
+	if got, _ := New().dumpRequestAsCurl(req); got != "curl \\\n  http://10.0.0.7:8080/api" {
+		t.Errorf("dumpRequestAsCurl = %q, want no Host header", got)
+	}
+}
+
+func TestSNIAnnotation(t *testing.T) {
+	tests := []struct {
+		name       string
+		url, host  string
+		serverName string
+		want       string
+	}{
+		{name: "http", url: "http://10.0.0.7/", host: "api.internal"},
+		{name: "no override", url: "https://example.com/"},
+		{name: "same name", url: "https://example.com/", host: "EXAMPLE.com:443"},
+		{
+			name: "override",
+			url:  "https://10.0.0.7:8443/v1?key=s3cr3t",
+			host: "api.internal",
+			want: "# sni #0001: TLS server name 10.0.0.7 does not match Host header api.internal; expect certificate errors or 404s if the server routes on either\n" +
+				"#   to send api.internal for both: curl --connect-to 'api.internal:443:10.0.0.7:8443' 'https://api.internal/v1?key=REDACTED'",
+		},
+		{
+			name: "ipv6",
+			url:  "https://[::1]/",
+			host: "api.internal:9443",
+			want: "# sni #0001: TLS server name ::1 does not match Host header api.internal:9443; expect certificate errors or 404s if the server routes on either\n" +
+				"#   to send api.internal for both: curl --connect-to 'api.internal:9443:[::1]:443' https://api.internal:9443/",
+		},
+		{
+			name:       "server name",
+			url:        "https://example.com/",
+			serverName: "other.example.com",
+			want:       "# sni #0001: TLS server name other.example.com does not match Host header example.com; expect certificate errors or 404s if the server routes on either",
+		},
+		{name: "server name matches host", url: "https://10.0.0.7/", host: "api.internal", serverName: "api.internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			ct := New(WithSecretParam("key"), WithTransport(&http.Transport{TLSClientConfig: &tls.Config{ServerName: tt.serverName}}))
+			if got := ct.sniAnnotation(" #0001", req); got != tt.want {
+				t.Errorf("sniAnnotation =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
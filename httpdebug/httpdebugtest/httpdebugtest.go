@@ -0,0 +1,97 @@
+// Package httpdebugtest provides helpers for testing code that uses
+// httpdebug, so that downstream projects can regression-test their
+// redaction configuration:
+//
+//	var buf bytes.Buffer
+//	log.SetOutput(&buf)
+//	defer log.SetOutput(os.Stderr)
+//	ct := dbg.New(dbg.WithSecretHeader("X-Api-Key"))
+//	// ... make requests through ct ...
+//	httpdebugtest.AssertNoSecrets(t, buf.String(), apiKey, password)
+package httpdebugtest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// detector finds one kind of well-known secret.
+type detector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// detectors find secrets by their well-known formats. A JWT is only
+// reported if its signature is present, since httpdebug redacts just the
+// signature by default.
+var detectors = []detector{
+	{"a JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]{10,}`)},
+	{"an AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"a GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{"a Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"a Stripe secret key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{16,}`)},
+	{"a Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{"a private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+}
+
+// AssertNoSecrets reports an error on t for every occurrence in output
+// (typically the log produced by a CurlTransport) of one of the given
+// secret values, and of anything that looks like a well-known kind of
+// secret: an unredacted JWT, an AWS access key ID, a GitHub, Slack, or
+// Stripe token, a Google API key, or a private key. Empty secrets are
+// ignored. The errors give the line of each occurrence but show only
+// the start of the secret, so that the test log does not leak it.
+func AssertNoSecrets(t testing.TB, output string, secrets ...string) {
+	t.Helper()
+	for _, f := range findSecrets(output, secrets) {
+		t.Errorf("httpdebugtest: line %v contains %v", f.line, f.what)
+	}
+}
+
+// finding is a secret found by findSecrets.
+type finding struct {
+	pos  int
+	line int
+	what string
+}
+
+// findSecrets returns the occurrences in output of secrets and of
+// anything matched by the detectors, in order.
+func findSecrets(output string, secrets []string) []finding {
+	var found []finding
+	add := func(pos int, what string) {
+		found = append(found, finding{pos, strings.Count(output[:pos], "\n") + 1, what})
+	}
+	for i, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		for pos := 0; ; pos += len(secret) {
+			n := strings.Index(output[pos:], secret)
+			if n < 0 {
+				break
+			}
+			pos += n
+			add(pos, fmt.Sprintf("secret #%v (%v)", i+1, mask(secret)))
+		}
+	}
+	for _, d := range detectors {
+		for _, loc := range d.re.FindAllStringIndex(output, -1) {
+			add(loc[0], fmt.Sprintf("%v (%v)", d.name, mask(output[loc[0]:loc[1]])))
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].pos < found[j].pos })
+	return found
+}
+
+// mask returns the start of secret followed by an ellipsis, or only
+// asterisks if secret is too short to reveal any of it.
+func mask(secret string) string {
+	if len(secret) < 12 {
+		return "***"
+	}
+	return secret[:4] + "..."
+}
@@ -0,0 +1,81 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HTTPVersion is the HTTP protocol version that the curl output asks for.
+type HTTPVersion int
+
+const (
+	// HTTPVersionAuto emits "--http1.0" for requests whose Proto is
+	// HTTP/1.0 (e.g. those of HTTP/1.0 clients dumped by Middleware),
+	// "--http1.1" if the Transport is an *http.Transport with HTTP/2
+	// disabled (by a non-nil, empty TLSNextProto), and otherwise leaves
+	// the version to curl. Responses that were received over HTTP/2 are
+	// then annotated with the flag that reproduces them.
+	HTTPVersionAuto HTTPVersion = iota
+	// HTTPVersion1_1 emits "--http1.1".
+	HTTPVersion1_1
+	// HTTPVersion2 emits "--http2".
+	HTTPVersion2
+	// HTTPVersion2PriorKnowledge emits "--http2-prior-knowledge", for
+	// servers that speak HTTP/2 over cleartext without an upgrade (h2c).
+	HTTPVersion2PriorKnowledge
+	// HTTPVersion1_0 emits "--http1.0", for a Transport that speaks
+	// HTTP/1.0 to servers that do not support persistent connections or
+	// chunked bodies.
+	HTTPVersion1_0
+)
+
+// WithHTTPVersion is a CurlTransportOption that declares the HTTP version
+// that the Transport is forced to use, so that the curl output includes
+// the corresponding flag (e.g. because a bug only reproduces over HTTP/2).
+func WithHTTPVersion(v HTTPVersion) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.HTTPVersion = v
+	}
+}
+
+// httpVersionFlag returns the curl flag selecting the HTTP version, if any.
+func (t *CurlTransport) httpVersionFlag() string {
+	switch t.HTTPVersion {
+	case HTTPVersion1_1:
+		return "--http1.1"
+	case HTTPVersion2:
+		return "--http2"
+	case HTTPVersion2PriorKnowledge:
+		return "--http2-prior-knowledge"
+	case HTTPVersion1_0:
+		return "--http1.0"
+	}
+	if tr, ok := t.httpTransport(); ok && tr.TLSNextProto != nil && len(tr.TLSNextProto) == 0 {
+		return "--http1.1"
+	}
+	return ""
+}
+
+// requestVersionFlag returns the curl flag selecting the HTTP version of
+// req, if any.
+func (t *CurlTransport) requestVersionFlag(req *http.Request) string {
+	if t.HTTPVersion == HTTPVersionAuto && req.ProtoMajor == 1 && req.ProtoMinor == 0 {
+		return "--http1.0"
+	}
+	return t.httpVersionFlag()
+}
+
+// protocolNotice returns a line annotating a response received over
+// HTTP/2 with the curl flag that reproduces it, or the empty string if
+// the curl output already selects the version or the response is not
+// HTTP/2.
+func (t *CurlTransport) protocolNotice(label string, req *http.Request, resp *http.Response) string {
+	if resp.ProtoMajor != 2 || t.httpVersionFlag() != "" {
+		return ""
+	}
+	flag := "--http2"
+	if req.URL.Scheme == "http" {
+		flag = "--http2-prior-knowledge"
+	}
+	return fmt.Sprintf("# protocol%v: %v, reproduce with %v", label, resp.Proto, flag)
+}
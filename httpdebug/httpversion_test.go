@@ -0,0 +1,71 @@
+package httpdebug
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCurlTransport_httpVersionFlag(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   HTTPVersion
+		transport http.RoundTripper
+		want      string
+	}{
+		{name: "auto", transport: &http.Transport{}},
+		{name: "auto, HTTP/2 disabled", transport: &http.Transport{TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{}}, want: "--http1.1"},
+		{name: "auto, other transport", transport: errTransport{}},
+		{name: "HTTP/1.1", version: HTTPVersion1_1, want: "--http1.1"},
+		{name: "HTTP/2", version: HTTPVersion2, want: "--http2"},
+		{name: "HTTP/2 prior knowledge", version: HTTPVersion2PriorKnowledge, want: "--http2-prior-knowledge"},
+		{name: "HTTP/1.0", version: HTTPVersion1_0, want: "--http1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := New(WithHTTPVersion(tt.version), WithTransport(tt.transport))
+			if got := ct.httpVersionFlag(); got != tt.want {
+				t.Errorf("httpVersionFlag = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_HTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	for _, version := range []HTTPVersion{HTTPVersionAuto, HTTPVersion2} {
+		logged = nil
+		client := &http.Client{Transport: New(WithHTTPVersion(version), WithTransport(server.Client().Transport))}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != 2 {
+			t.Fatalf("resp.Proto = %v, want HTTP/2", resp.Proto)
+		}
+
+		if version == HTTPVersion2 {
+			if len(logged) != 1 || !strings.Contains(logged[0], "\n  --http2") {
+				t.Errorf("logged = %#v, want a curl command with --http2 only", logged)
+			}
+			continue
+		}
+		if len(logged) != 2 || logged[1] != "# protocol: HTTP/2.0, reproduce with --http2" {
+			t.Errorf("logged = %#v, want curl command and protocol notice", logged)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package httpdebug
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithJWTClaims is a CurlTransportOption that reports the decoded
+// header and well-known claims of JWTs found in secret headers.
+// See CurlTransport.ShowJWTClaims.
+func WithJWTClaims() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.ShowJWTClaims = true
+	}
+}
+
+// These are the JOSE header fields and claims that are safe to report.
+var (
+	jwtHeaderFields = []string{"alg", "kid", "typ"}
+	jwtClaimFields  = []string{"iss", "sub", "aud", "exp", "nbf", "iat"}
+)
+
+// jwtToken is a decoded, unverified JWT.
+type jwtToken struct {
+	header map[string]interface{}
+	claims map[string]interface{}
+}
+
+// parseJWT decodes the JWT in value, which may be prefixed by an
+// authorization scheme such as "Bearer ". The signature is not verified.
+func parseJWT(value string) (*jwtToken, bool) {
+	if _, token, ok := strings.Cut(strings.TrimSpace(value), " "); ok {
+		value = strings.TrimSpace(token)
+	}
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	decode := func(s string) (map[string]interface{}, bool) {
+		buf, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		if err != nil {
+			return nil, false
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(buf, &m); err != nil {
+			return nil, false
+		}
+		return m, true
+	}
+
+	header, ok := decode(parts[0])
+	if !ok {
+		return nil, false
+	}
+	claims, ok := decode(parts[1])
+	if !ok {
+		return nil, false
+	}
+	return &jwtToken{header: header, claims: claims}, true
+}
+
+// expiry returns the time of the token's exp claim, if present.
+func (j *jwtToken) expiry() (time.Time, bool) {
+	exp, ok := j.claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0).UTC(), true
+}
+
+// String returns the safe fields of the token as space-separated
+// key=value pairs.
+func (j *jwtToken) String() string {
+	var fields []string
+	for _, k := range jwtHeaderFields {
+		if v, ok := j.header[k]; ok {
+			fields = append(fields, fmt.Sprintf("%v=%v", k, v))
+		}
+	}
+	for _, k := range jwtClaimFields {
+		v, ok := j.claims[k]
+		if !ok {
+			continue
+		}
+		switch v := v.(type) {
+		case float64:
+			if k == "exp" || k == "nbf" || k == "iat" {
+				fields = append(fields, fmt.Sprintf("%v=%v", k, time.Unix(int64(v), 0).UTC().Format(time.RFC3339)))
+				continue
+			}
+		case []interface{}:
+			var values []string
+			for _, a := range v {
+				values = append(values, fmt.Sprint(a))
+			}
+			fields = append(fields, fmt.Sprintf("%v=%v", k, strings.Join(values, ",")))
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%v=%v", k, v))
+	}
+	return strings.Join(fields, " ")
+}
+
+// jwtAnnotations returns a comment line describing each JWT found in the
+// secret headers of h, in header key order.
+func (t *CurlTransport) jwtAnnotations(h http.Header) []string {
+	var keys []string
+	for k := range h {
+		if t.isSecretHeader(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, k := range keys {
+		for _, v := range h[k] {
+			if token, ok := parseJWT(v); ok {
+				lines = append(lines, fmt.Sprintf("# jwt %v: %v", k, token))
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,92 @@
+package httpdebug
+
+import (
+	"encoding/base64"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// makeJWT returns an unsigned JWT with the provided header and claims JSON.
+func makeJWT(header, claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(header)) + "." + enc([]byte(claims)) + ".c2lnbmF0dXJl"
+}
+
+func TestWithJWTClaims(t *testing.T) {
+	if ct := New(WithJWTClaims()); !ct.ShowJWTClaims {
+		t.Error("WithJWTClaims did not set ShowJWTClaims")
+	}
+}
+
+func TestParseJWT(t *testing.T) {
+	token := makeJWT(`{"alg":"RS256"}`, `{"exp":1641092645}`)
+
+	tests := []struct {
+		name    string
+		value   string
+		wantOK  bool
+		wantExp time.Time
+	}{
+		{name: "empty"},
+		{name: "not a JWT", value: "token abc123"},
+		{name: "bad base64", value: "Bearer a!.b!.c"},
+		{name: "bad JSON", value: "Bearer YQ.Yg.Yw"},
+		{name: "bare token", value: token, wantOK: true, wantExp: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "bearer token", value: "Bearer " + token, wantOK: true, wantExp: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseJWT(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("parseJWT ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if exp, _ := got.expiry(); !exp.Equal(tt.wantExp) {
+				t.Errorf("expiry = %v, want %v", exp, tt.wantExp)
+			}
+		})
+	}
+}
+
+func TestJWTToken_String(t *testing.T) {
+	token, ok := parseJWT(makeJWT(
+		`{"alg":"RS256","typ":"JWT","kid":"k1","jku":"ignored"}`,
+		`{"iss":"https://issuer","sub":"user-1","aud":["api","web"],"exp":1641092645,"iat":1641089045,"email":"private@example.com"}`,
+	))
+	if !ok {
+		t.Fatal("parseJWT failed")
+	}
+
+	want := "alg=RS256 kid=k1 typ=JWT iss=https://issuer sub=user-1 aud=api,web exp=2022-01-02T03:04:05Z iat=2022-01-02T02:04:05Z"
+	if got := token.String(); got != want {
+		t.Errorf("String =\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestCurlTransport_jwtAnnotations(t *testing.T) {
+	token := makeJWT(`{"alg":"HS256"}`, `{"sub":"me"}`)
+	h := http.Header{
+		"Authorization": []string{"Bearer " + token},
+		"X-User-Jwt":    []string{token},
+		"X-Other":       []string{token},
+	}
+
+	ct := New(WithJWTClaims())
+	want := []string{
+		"# jwt Authorization: alg=HS256 sub=me",
+		"# jwt X-User-Jwt: alg=HS256 sub=me",
+	}
+	if got := ct.jwtAnnotations(h); !reflect.DeepEqual(got, want) {
+		t.Errorf("jwtAnnotations = %#v, want %#v", got, want)
+	}
+
+	// The token itself is never partially shown.
+	if got := ct.redactHeader("Authorization", h["Authorization"]); got != "<REDACTED>" {
+		t.Errorf("redactHeader = %q, want <REDACTED>", got)
+	}
+}
@@ -0,0 +1,30 @@
+package httpdebug
+
+import (
+	"fmt"
+	"time"
+)
+
+// Marker is a named point in the capture timeline, inserted with Mark
+// to anchor later analysis to a user action (e.g. "clicked submit").
+type Marker struct {
+	Time time.Time `json:"time"`
+	Name string    `json:"name"`
+}
+
+// Mark inserts a marker with the given name into the timeline of
+// retained exchanges. It appears in Captured, Archive, and Handler as an
+// Exchange whose Marker is set and whose other fields are empty.
+func (c *Capture) Mark(name string) {
+	c.add(&Exchange{Marker: &Marker{Time: time.Now(), Name: name}})
+}
+
+// Mark logs a marker with the given name and inserts it into the capture
+// timeline, if capturing is enabled, so that the requests that follow a
+// user action are easy to find.
+func (t *CurlTransport) Mark(name string) {
+	t.log(fmt.Sprintf("# marker: %v", name))
+	if t.Capture != nil {
+		t.Capture.Mark(name)
+	}
+}
@@ -0,0 +1,36 @@
+package httpdebug
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCurlTransport_Mark(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	New(WithPrefix("[svc] ")).Mark("no capture")
+	ct := New(WithCapture(3))
+	ct.Mark("clicked submit")
+
+	if want := []string{"[svc] # marker: no capture", "# marker: clicked submit"}; !reflect.DeepEqual(logged, want) {
+		t.Errorf("logged = %#v, want %#v", logged, want)
+	}
+	x, ok := ct.Last()
+	if !ok || x.Marker == nil || x.Marker.Name != "clicked submit" || x.Marker.Time.IsZero() || x.Request != nil {
+		t.Fatalf("Last = %+v, want marker", x)
+	}
+
+	buf, err := json.Marshal(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(buf), `{"marker":{"time":`) || strings.Contains(string(buf), "request") {
+		t.Errorf("json = %s, want marker only", buf)
+	}
+}
@@ -0,0 +1,58 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Middleware returns an http.Handler that logs every inbound request in
+// the same sanitized curl format as CurlTransport before passing it on
+// to next, so that server developers can reproduce exactly what a client
+// sent. The full URL is reconstructed from the Host header, or the
+// local address for HTTP/1.0 requests without one, and whether the
+// connection used TLS. The options configure redaction and output
+// just as they do for New.
+func Middleware(next http.Handler, opts ...CurlTransportOption) http.Handler {
+	ct := New(opts...)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct.Enabled() {
+			ct.dumpInbound(r)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// dumpInbound logs the inbound request r. The body of r is preserved.
+func (t *CurlTransport) dumpInbound(r *http.Request) {
+	u := *r.URL
+	u.Scheme = "http"
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	u.Host = r.Host
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok && u.Host == "" {
+		u.Host = addr.String()
+	}
+
+	out := r.Clone(r.Context())
+	out.URL = &u
+	out.Body = r.Body
+
+	s, err := t.dumpRequestAsCurl(out)
+	r.Body = out.Body
+	if err != nil {
+		t.log(fmt.Sprintf("# httpdebug: dumping inbound request: %v", err))
+		return
+	}
+
+	o := t.newOutput()
+	t.writeEntry(o, &Entry{
+		Time:   time.Now(),
+		Method: r.Method,
+		URL:    t.sanitizeURL(&u),
+		Curl:   s,
+	})
+	o.flush()
+}
@@ -0,0 +1,119 @@
+package httpdebug
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMiddleware(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	var gotBody string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		gotBody = string(buf)
+	}), WithSecretParam("token"))
+
+	tests := []struct {
+		name    string
+		target  string
+		body    string
+		wantURL string
+	}{
+		{
+			name:    "plain http",
+			target:  "http://api.example.com/hooks?token=abc&x=1",
+			body:    `{"a":1}`,
+			wantURL: "'http://api.example.com/hooks?token=REDACTED&x=1'",
+		},
+		{
+			name:    "tls", // httptest sets req.TLS for https targets
+			target:  "https://secure.example.com:8443/path",
+			wantURL: "https://secure.example.com:8443/path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged, gotBody = nil, ""
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest("POST", tt.target, body)
+			req.Header.Set("Authorization", "secret")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			want := "curl -X POST \\\n  " + tt.wantURL + " \\\n  -H 'Authorization: <REDACTED>'"
+			if tt.body != "" {
+				want += " \\\n  --data-raw '" + tt.body + "'"
+			}
+			if len(logged) != 1 || logged[0] != want {
+				t.Errorf("logged = %#v, want %q", logged, want)
+			}
+			if gotBody != tt.body {
+				t.Errorf("handler body = %q, want %q", gotBody, tt.body)
+			}
+		})
+	}
+}
+
+func TestMiddleware_BadBody(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	var called bool
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Body = io.NopCloser(iotest.ErrReader(errors.New("custom error")))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !called {
+		t.Error("handler was not called")
+	}
+	if want := "# httpdebug: dumping inbound request: custom error"; len(logged) != 1 || logged[0] != want {
+		t.Errorf("logged = %#v, want %q", logged, want)
+	}
+}
+
+func TestMiddleware_HTTP10WithoutHost(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ts := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	defer ts.Close()
+
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "GET /status HTTP/1.0\r\n\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	want := "curl \\\n  " + ts.URL + "/status \\\n  --http1.0"
+	if len(logged) != 1 || logged[0] != want {
+		t.Errorf("logged = %#v, want %q", logged, want)
+	}
+}
@@ -0,0 +1,65 @@
+package httpdebug
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+)
+
+// Normalizer rewrites a response before it is compared by DiffResponse,
+// so that comparisons focus on meaningful differences.
+type Normalizer func(r *CassetteResponse)
+
+// IgnoreHeaders returns a Normalizer that removes the named headers
+// (case insensitive).
+func IgnoreHeaders(names ...string) Normalizer {
+	return func(r *CassetteResponse) {
+		for _, name := range names {
+			r.Header.Del(name)
+		}
+	}
+}
+
+// MaskBody returns a Normalizer that replaces every match of re in the
+// body with replacement, which may refer to submatches as in
+// regexp.Regexp.ReplaceAll.
+func MaskBody(re *regexp.Regexp, replacement string) Normalizer {
+	return func(r *CassetteResponse) {
+		r.Body = re.ReplaceAll(r.Body, []byte(replacement))
+	}
+}
+
+var (
+	// timestampRE matches RFC 3339 timestamps, e.g. "2022-01-02T03:04:05.678Z".
+	timestampRE = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:[Zz]|[+-]\d{2}:\d{2})?`)
+	// uuidRE matches UUIDs, e.g. "123e4567-e89b-12d3-a456-426614174000".
+	uuidRE = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+)
+
+// DefaultNormalizers ignore the headers that differ between otherwise
+// identical responses (the date and request IDs, and the Content-Length,
+// since the bodies are compared directly) and mask timestamps and UUIDs
+// in bodies.
+var DefaultNormalizers = []Normalizer{
+	IgnoreHeaders("Age", "Cf-Ray", "Content-Length", "Date", "Request-Id", "X-Amz-Request-Id", "X-Amzn-Requestid",
+		"X-Correlation-Id", "X-Github-Request-Id", "X-Request-Id"),
+	MaskBody(timestampRE, "<TIMESTAMP>"),
+	MaskBody(uuidRE, "<UUID>"),
+}
+
+// normalize returns a copy of r rewritten by the normalizers.
+func normalize(r *CassetteResponse, normalizers []Normalizer) *CassetteResponse {
+	if len(normalizers) == 0 {
+		return r
+	}
+	c := *r
+	c.Header = r.Header.Clone()
+	if c.Header == nil {
+		c.Header = http.Header{}
+	}
+	c.Body = slices.Clone(r.Body)
+	for _, n := range normalizers {
+		n(&c)
+	}
+	return &c
+}
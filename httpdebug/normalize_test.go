@@ -0,0 +1,54 @@
+package httpdebug
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestNormalizers(t *testing.T) {
+	r := &CassetteResponse{
+		StatusCode: 200,
+		Header:     http.Header{"Date": {"Sun, 02 Jan 2022 03:04:05 GMT"}, "X-Request-Id": {"abc"}, "Content-Type": {"application/json"}},
+		Body:       []byte(`{"id":"123e4567-e89b-12d3-a456-426614174000","created":"2022-01-02T03:04:05.678Z","n":42}`),
+	}
+
+	got := normalize(r, DefaultNormalizers)
+	want := &CassetteResponse{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"id":"<UUID>","created":"<TIMESTAMP>","n":42}`),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalize =\n%#v\nwant:\n%#v", got, want)
+	}
+	if r.Header.Get("Date") == "" || string(r.Body) == string(got.Body) {
+		t.Error("normalize modified the original response")
+	}
+
+	if got := normalize(r, []Normalizer{IgnoreHeaders("content-type"), MaskBody(regexp.MustCompile(`"n":(\d+)`), `"n":<$1>`)}); got.Header.Get("Content-Type") != "" || string(got.Body) != `{"id":"123e4567-e89b-12d3-a456-426614174000","created":"2022-01-02T03:04:05.678Z","n":<42>}` {
+		t.Errorf("normalize = %v %s, want Content-Type ignored and n masked", got.Header, got.Body)
+	}
+}
+
+func TestDiffResponse_Normalizers(t *testing.T) {
+	recorded := &CassetteResponse{
+		StatusCode: 200,
+		Header:     http.Header{"Date": {"Sun, 02 Jan 2022 03:04:05 GMT"}, "X-Request-Id": {"abc"}},
+		Body:       []byte(`{"id":"123e4567-e89b-12d3-a456-426614174000","at":"2022-01-02T03:04:05Z","n":1}`),
+	}
+	got := &CassetteResponse{
+		StatusCode: 200,
+		Header:     http.Header{"Date": {"Mon, 03 Jan 2022 03:04:05 GMT"}, "X-Request-Id": {"def"}},
+		Body:       []byte(`{"id":"00000000-e89b-12d3-a456-426614174000","at":"2022-01-03T03:04:05Z","n":2}`),
+	}
+
+	if diffs := DiffResponse(recorded, got); len(diffs) != 5 {
+		t.Errorf("DiffResponse without normalizers = %#v, want 5 differences", diffs)
+	}
+	want := []string{"body $.n: 1 -> 2"}
+	if diffs := DiffResponse(recorded, got, DefaultNormalizers...); !reflect.DeepEqual(diffs, want) {
+		t.Errorf("DiffResponse with DefaultNormalizers = %#v, want %#v", diffs, want)
+	}
+}
@@ -0,0 +1,29 @@
+package httpdebug
+
+import (
+	"net/http"
+	"time"
+)
+
+// Observer is notified of the outcome of every request dumped by a
+// CurlTransport, as integrations such as the Prometheus metrics of the
+// contrib/httpdebugprom module are. Observers must be safe for
+// concurrent use.
+type Observer interface {
+	// ObserveRoundTrip is called once the response headers of the request
+	// described by e have arrived (resp is then non-nil) or the round
+	// trip has failed with err, elapsed after the request was sent.
+	// It must not modify req, and may only read the body of resp if it
+	// replaces it with an equivalent one.
+	ObserveRoundTrip(e *Entry, req *http.Request, resp *http.Response, err error, elapsed time.Duration)
+}
+
+// WithObserver is a CurlTransportOption that adds an additional Observer.
+// A nil observer is ignored.
+func WithObserver(observer Observer) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if observer != nil {
+			ct.Observers = append(ct.Observers, observer)
+		}
+	}
+}
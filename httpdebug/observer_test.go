@@ -0,0 +1,76 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// observerRecorder is an Observer that records what it observes.
+type observerRecorder struct {
+	mu      sync.Mutex
+	entries []*Entry
+	codes   []int
+	errs    []error
+}
+
+func (o *observerRecorder) ObserveRoundTrip(e *Entry, req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries = append(o.entries, e)
+	code := 0
+	if resp != nil {
+		code = resp.StatusCode
+	}
+	o.codes = append(o.codes, code)
+	o.errs = append(o.errs, err)
+}
+
+func TestWithObserver(t *testing.T) {
+	if got := New(WithObserver(nil)); got.Observers != nil {
+		t.Errorf("WithObserver(nil) set Observers = %v, want nil", got.Observers)
+	}
+	o := &observerRecorder{}
+	ct := New(WithObserver(o))
+	if len(ct.Observers) != 1 || ct.Observers[0] != o {
+		t.Errorf("Observers = %v, want [%v]", ct.Observers, o)
+	}
+	if got, want := ct.Config().Observers, []string{"*httpdebug.observerRecorder"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("Config().Observers = %q, want %q", got, want)
+	}
+}
+
+func TestRoundTrip_Observer(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", http.NotFound)
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	o := &observerRecorder{}
+	client.Transport = New(WithObserver(o))
+	resp, err := client.Get(url + "/missing")
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	boom := errors.New("boom")
+	ct := New(WithObserver(o), WithTransport(errTransport{err: boom}))
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if _, err := ct.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip = nil error, want error")
+	}
+
+	if len(o.entries) != 2 || o.entries[0].URL != url+"/missing" || o.entries[1].URL != "http://example.com/" {
+		t.Fatalf("observed entries = %v, want the two requests", o.entries)
+	}
+	if got, want := fmt.Sprint(o.codes, o.errs), "[404 0] [<nil> boom]"; got != want {
+		t.Errorf("observed codes and errors = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,388 @@
+package httpdebug
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPI is a draft OpenAPI 3 document, as generated by GenerateOpenAPI.
+// Only the parts of the specification that can be inferred from traffic
+// are represented. Marshal it as JSON (e.g. with WriteJSON) to save it.
+type OpenAPI struct {
+	OpenAPI string                                  `json:"openapi"`
+	Info    OpenAPIInfo                             `json:"info"`
+	Servers []OpenAPIServer                         `json:"servers,omitempty"`
+	Paths   map[string]map[string]*OpenAPIOperation `json:"paths"`
+}
+
+// OpenAPIInfo is the metadata of an OpenAPI document.
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIServer is a base URL of the API.
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+// OpenAPIOperation is a method of a path, keyed by the lowercase method
+// name in OpenAPI.Paths.
+type OpenAPIOperation struct {
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter is a path or query parameter of an operation.
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema,omitempty"`
+	Example  string         `json:"example,omitempty"`
+}
+
+// OpenAPIRequestBody is the request body of an operation, keyed by
+// media type.
+type OpenAPIRequestBody struct {
+	Content map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an operation, keyed by status code
+// in OpenAPIOperation.Responses.
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is a body of a given media type, with an example
+// taken from the traffic.
+type OpenAPIMediaType struct {
+	Schema  *OpenAPISchema `json:"schema,omitempty"`
+	Example interface{}    `json:"example,omitempty"`
+}
+
+// OpenAPISchema is a data type inferred from example values.
+type OpenAPISchema struct {
+	Type       string                    `json:"type,omitempty"`
+	Format     string                    `json:"format,omitempty"`
+	Nullable   bool                      `json:"nullable,omitempty"`
+	Properties map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Items      *OpenAPISchema            `json:"items,omitempty"`
+}
+
+// WriteJSON writes the document to w as indented JSON.
+func (o *OpenAPI) WriteJSON(w io.Writer) error {
+	buf, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(buf, '\n'))
+	return err
+}
+
+// GenerateOpenAPI returns a draft OpenAPI 3 document describing the API
+// exercised by the interactions, as a starting point for documenting an
+// undocumented API: its servers, its paths with the parameters and
+// methods seen, and the request and response bodies of each operation,
+// with schemas inferred from the JSON bodies and the first body of each
+// media type as an example.
+//
+// Path segments that look like identifiers (numbers, UUIDs, and long hex
+// strings) become path parameters named after the segment before them,
+// e.g. "/users/42/repos" becomes "/users/{userId}/repos". Query
+// parameters are required if every request of the operation has them.
+// Since the interactions are redacted, secrets appear as "REDACTED" in
+// the examples.
+func GenerateOpenAPI(title string, interactions []*Interaction) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: title, Version: "draft"},
+		Paths:   map[string]map[string]*OpenAPIOperation{},
+	}
+
+	type key struct{ path, method string }
+	var keys []key
+	samples := map[key][]*Interaction{}
+	pathParams := map[key][]string{}
+	servers := map[string]bool{}
+	for _, x := range interactions {
+		u, err := url.Parse(x.Request.URL)
+		if err != nil {
+			continue
+		}
+		if u.Host != "" {
+			servers[u.Scheme+"://"+u.Host] = true
+		}
+		path, params := templatePath(u.Path)
+		k := key{path, strings.ToLower(x.Request.Method)}
+		if samples[k] == nil {
+			keys = append(keys, k)
+			pathParams[k] = params
+		}
+		samples[k] = append(samples[k], x)
+	}
+
+	for _, k := range keys {
+		if doc.Paths[k.path] == nil {
+			doc.Paths[k.path] = map[string]*OpenAPIOperation{}
+		}
+		doc.Paths[k.path][k.method] = openAPIOperation(pathParams[k], samples[k])
+	}
+	for server := range servers {
+		doc.Servers = append(doc.Servers, OpenAPIServer{URL: server})
+	}
+	sort.Slice(doc.Servers, func(i, j int) bool { return doc.Servers[i].URL < doc.Servers[j].URL })
+	return doc
+}
+
+// OpenAPI returns a draft OpenAPI 3 document describing the API exercised
+// by the retained exchanges (see GenerateOpenAPI).
+func (c *Capture) OpenAPI(title string) *OpenAPI {
+	var interactions []*Interaction
+	for _, x := range c.Captured() {
+		if i := x.Interaction(); i != nil {
+			interactions = append(interactions, i)
+		}
+	}
+	return GenerateOpenAPI(title, interactions)
+}
+
+// OpenAPI returns a draft OpenAPI 3 document describing the API exercised
+// by the exchanges retained by WithCapture (see GenerateOpenAPI). The
+// document has no paths if capturing is not enabled.
+func (t *CurlTransport) OpenAPI(title string) *OpenAPI {
+	if t.Capture == nil {
+		return GenerateOpenAPI(title, nil)
+	}
+	return t.Capture.OpenAPI(title)
+}
+
+// hexIDRE matches the long hex strings used as identifiers, e.g. commit
+// hashes.
+var hexIDRE = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+
+// templatePath returns path with the segments that look like identifiers
+// replaced by parameters, e.g. "/users/{userId}", and the names of the
+// parameters in order.
+func templatePath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	used := map[string]int{}
+	for i, seg := range segments {
+		if !isPathID(seg) {
+			continue
+		}
+		name := "id"
+		if i > 0 && segments[i-1] != "" && !strings.HasPrefix(segments[i-1], "{") {
+			name = singular(segments[i-1]) + "Id"
+		}
+		if used[name]++; used[name] > 1 {
+			name += strconv.Itoa(used[name])
+		}
+		params = append(params, name)
+		segments[i] = "{" + name + "}"
+	}
+	if path == "" {
+		return "/", nil
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// isPathID reports whether the path segment looks like an identifier.
+func isPathID(seg string) bool {
+	if seg == "" {
+		return false
+	}
+	if _, err := strconv.ParseUint(seg, 10, 64); err == nil {
+		return true
+	}
+	return uuidRE.FindString(seg) == seg || hexIDRE.MatchString(seg)
+}
+
+// singular returns the path segment without a plural "s" and with its
+// punctuation removed, for use in a parameter name, e.g. "userId" from
+// "users".
+func singular(seg string) string {
+	seg = strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' {
+			return -1
+		}
+		return r
+	}, seg)
+	if len(seg) > 1 && strings.HasSuffix(seg, "s") && !strings.HasSuffix(seg, "ss") {
+		seg = seg[:len(seg)-1]
+	}
+	return seg
+}
+
+// openAPIOperation returns the operation seen in the samples, whose paths
+// have the named parameters.
+func openAPIOperation(pathParams []string, samples []*Interaction) *OpenAPIOperation {
+	op := &OpenAPIOperation{Responses: map[string]*OpenAPIResponse{}}
+
+	for _, name := range pathParams {
+		op.Parameters = append(op.Parameters, &OpenAPIParameter{Name: name, In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"}})
+	}
+	queries := map[string]*OpenAPIParameter{}
+	seen := map[string]int{}
+	var names []string
+	for _, x := range samples {
+		u, _ := url.Parse(x.Request.URL)
+		for name, values := range u.Query() {
+			p := queries[name]
+			if p == nil {
+				p = &OpenAPIParameter{Name: name, In: "query", Schema: &OpenAPISchema{Type: "string"}}
+				queries[name] = p
+				names = append(names, name)
+			}
+			if p.Example == "" && len(values) > 0 && values[0] != "" {
+				p.Example = values[0]
+			}
+			seen[name]++
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := queries[name]
+		p.Required = seen[name] == len(samples)
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	for _, x := range samples {
+		if len(x.Request.Body) > 0 {
+			if op.RequestBody == nil {
+				op.RequestBody = &OpenAPIRequestBody{Content: map[string]*OpenAPIMediaType{}}
+			}
+			addExample(op.RequestBody.Content, x.Request.Header.Get("Content-Type"), x.Request.Body)
+		}
+
+		code := strconv.Itoa(x.Response.StatusCode)
+		resp := op.Responses[code]
+		if resp == nil {
+			resp = &OpenAPIResponse{Description: http.StatusText(x.Response.StatusCode)}
+			if resp.Description == "" {
+				resp.Description = x.Response.Status
+			}
+			op.Responses[code] = resp
+		}
+		if len(x.Response.Body) > 0 {
+			if resp.Content == nil {
+				resp.Content = map[string]*OpenAPIMediaType{}
+			}
+			addExample(resp.Content, x.Response.Header.Get("Content-Type"), x.Response.Body)
+		}
+	}
+	return op
+}
+
+// addExample adds body, of the given content type, to content, merging
+// its schema with those of the earlier bodies of its media type.
+func addExample(content map[string]*OpenAPIMediaType, contentType string, body []byte) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	m := content[mediaType]
+	if m == nil {
+		m = &OpenAPIMediaType{}
+		content[mediaType] = m
+	}
+
+	var example interface{} = string(body)
+	var schema *OpenAPISchema
+	if isJSON(mediaType) {
+		d := json.NewDecoder(bytes.NewReader(body))
+		d.UseNumber()
+		var v interface{}
+		if err := d.Decode(&v); err == nil {
+			example, schema = v, inferSchema(v)
+		}
+	}
+	if schema == nil {
+		schema = &OpenAPISchema{Type: "string"}
+	}
+	if m.Example == nil {
+		m.Example = example
+	}
+	m.Schema = mergeSchema(m.Schema, schema)
+}
+
+// inferSchema returns the schema of the JSON value v, as decoded with
+// json.Decoder.UseNumber.
+func inferSchema(v interface{}) *OpenAPISchema {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		s := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+		for name, value := range v {
+			s.Properties[name] = inferSchema(value)
+		}
+		return s
+	case []interface{}:
+		s := &OpenAPISchema{Type: "array"}
+		for _, value := range v {
+			s.Items = mergeSchema(s.Items, inferSchema(value))
+		}
+		if s.Items == nil {
+			s.Items = &OpenAPISchema{}
+		}
+		return s
+	case string:
+		s := &OpenAPISchema{Type: "string"}
+		switch {
+		case timestampRE.FindString(v) == v:
+			s.Format = "date-time"
+		case uuidRE.FindString(v) == v:
+			s.Format = "uuid"
+		}
+		return s
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &OpenAPISchema{Type: "integer"}
+		}
+		return &OpenAPISchema{Type: "number"}
+	case bool:
+		return &OpenAPISchema{Type: "boolean"}
+	}
+	return &OpenAPISchema{Nullable: true}
+}
+
+// mergeSchema returns a schema that describes the values of both a and b,
+// either of which may be nil. Where they disagree on a type, the first
+// one seen wins, except that integers widen to numbers.
+func mergeSchema(a, b *OpenAPISchema) *OpenAPISchema {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	nullable := a.Nullable || b.Nullable
+	switch {
+	case a.Type == "":
+		a = b
+	case b.Type == "":
+	case a.Type == "integer" && b.Type == "number":
+		a.Type = "number"
+	case a.Type != b.Type:
+	case a.Type == "object":
+		for name, s := range b.Properties {
+			a.Properties[name] = mergeSchema(a.Properties[name], s)
+		}
+	case a.Type == "array":
+		a.Items = mergeSchema(a.Items, b.Items)
+	case a.Format != b.Format:
+		a.Format = ""
+	}
+	a.Nullable = nullable
+	return a
+}
@@ -0,0 +1,270 @@
+package httpdebug
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplatePath(t *testing.T) {
+	tests := []struct {
+		path       string
+		want       string
+		wantParams []string
+	}{
+		{"", "/", nil},
+		{"/", "/", nil},
+		{"/users", "/users", nil},
+		{"/users/42/repos", "/users/{userId}/repos", []string{"userId"}},
+		{"/orgs/7/teams/9", "/orgs/{orgId}/teams/{teamId}", []string{"orgId", "teamId"}},
+		{"/42", "/{id}", []string{"id"}},
+		{"/a/1/2", "/a/{aId}/{id}", []string{"aId", "id"}},
+		{"/items/1/items/2", "/items/{itemId}/items/{itemId2}", []string{"itemId", "itemId2"}},
+		{"/commits/0123456789abcdef0123", "/commits/{commitId}", []string{"commitId"}},
+		{"/sessions/123e4567-e89b-12d3-a456-426614174000", "/sessions/{sessionId}", []string{"sessionId"}},
+		{"/access/5", "/access/{accessId}", []string{"accessId"}},
+		{"/api-keys/5", "/api-keys/{apikeyId}", []string{"apikeyId"}},
+		{"/v2/cafe", "/v2/cafe", nil},
+	}
+	for _, tt := range tests {
+		got, params := templatePath(tt.path)
+		if got != tt.want || !reflect.DeepEqual(params, tt.wantParams) {
+			t.Errorf("templatePath(%q) = (%q, %q), want (%q, %q)", tt.path, got, params, tt.want, tt.wantParams)
+		}
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	var v interface{}
+	d := json.NewDecoder(strings.NewReader(`{"id":1,"score":1.5,"name":"x","at":"2022-01-02T03:04:05Z","tags":["a"],"ok":true,"none":null,"list":[1,2.5],"empty":[]}`))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	str := &OpenAPISchema{Type: "string"}
+	want := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{
+		"id":    {Type: "integer"},
+		"score": {Type: "number"},
+		"name":  str,
+		"at":    {Type: "string", Format: "date-time"},
+		"tags":  {Type: "array", Items: str},
+		"ok":    {Type: "boolean"},
+		"none":  {Nullable: true},
+		"list":  {Type: "array", Items: &OpenAPISchema{Type: "number"}},
+		"empty": {Type: "array", Items: &OpenAPISchema{}},
+	}}
+	if got := inferSchema(v); !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("inferSchema =\n%s\nwant:\n%s", gotJSON, wantJSON)
+	}
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	jsonHeader := http.Header{"Content-Type": {"application/json; charset=utf-8"}}
+	interactions := []*Interaction{
+		{
+			Request:  CassetteRequest{Method: "GET", URL: "https://api.example.com/users/1?fields=name&key=REDACTED"},
+			Response: CassetteResponse{StatusCode: 200, Status: "200 OK", Header: jsonHeader, Body: []byte(`{"id":1,"name":"Ann"}`)},
+		},
+		{
+			Request:  CassetteRequest{Method: "GET", URL: "https://api.example.com/users/2?key=REDACTED"},
+			Response: CassetteResponse{StatusCode: 200, Status: "200 OK", Header: jsonHeader, Body: []byte(`{"id":2,"email":null}`)},
+		},
+		{
+			Request:  CassetteRequest{Method: "GET", URL: "https://api.example.com/users/3?key=REDACTED"},
+			Response: CassetteResponse{StatusCode: 404, Status: "404 Not Found", Header: http.Header{"Content-Type": {"text/plain"}}, Body: []byte("no such user")},
+		},
+		{
+			Request:  CassetteRequest{Method: "POST", URL: "https://api.example.com/users", Header: jsonHeader, Body: []byte(`{"name":"Bo"}`)},
+			Response: CassetteResponse{StatusCode: 201, Status: "201 Created"},
+		},
+		{
+			Request:  CassetteRequest{Method: "DELETE", URL: "http://localhost:8080/users/4"},
+			Response: CassetteResponse{StatusCode: 599, Status: "599 Network Timeout"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateOpenAPI("Example API", interactions).WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON = %v", err)
+	}
+	want := `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "Example API",
+    "version": "draft"
+  },
+  "servers": [
+    {
+      "url": "http://localhost:8080"
+    },
+    {
+      "url": "https://api.example.com"
+    }
+  ],
+  "paths": {
+    "/users": {
+      "post": {
+        "requestBody": {
+          "content": {
+            "application/json": {
+              "schema": {
+                "type": "object",
+                "properties": {
+                  "name": {
+                    "type": "string"
+                  }
+                }
+              },
+              "example": {
+                "name": "Bo"
+              }
+            }
+          }
+        },
+        "responses": {
+          "201": {
+            "description": "Created"
+          }
+        }
+      }
+    },
+    "/users/{userId}": {
+      "delete": {
+        "parameters": [
+          {
+            "name": "userId",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          }
+        ],
+        "responses": {
+          "599": {
+            "description": "599 Network Timeout"
+          }
+        }
+      },
+      "get": {
+        "parameters": [
+          {
+            "name": "userId",
+            "in": "path",
+            "required": true,
+            "schema": {
+              "type": "string"
+            }
+          },
+          {
+            "name": "fields",
+            "in": "query",
+            "schema": {
+              "type": "string"
+            },
+            "example": "name"
+          },
+          {
+            "name": "key",
+            "in": "query",
+            "required": true,
+            "schema": {
+              "type": "string"
+            },
+            "example": "REDACTED"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "OK",
+            "content": {
+              "application/json": {
+                "schema": {
+                  "type": "object",
+                  "properties": {
+                    "email": {
+                      "nullable": true
+                    },
+                    "id": {
+                      "type": "integer"
+                    },
+                    "name": {
+                      "type": "string"
+                    }
+                  }
+                },
+                "example": {
+                  "id": 1,
+                  "name": "Ann"
+                }
+              }
+            }
+          },
+          "404": {
+            "description": "Not Found",
+            "content": {
+              "text/plain": {
+                "schema": {
+                  "type": "string"
+                },
+                "example": "no such user"
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("GenerateOpenAPI =\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestCurlTransport_OpenAPI(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":7}`)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	if doc := New().OpenAPI("none"); len(doc.Paths) != 0 {
+		t.Errorf("OpenAPI without a Capture has paths %v, want none", doc.Paths)
+	}
+
+	ct := New(WithCapture(10), WithSecretBodyField("password"), WithTransport(&http.Transport{}))
+	client.Transport = ct
+	ct.Mark("start")
+	resp, err := client.Post(url+"/items", "application/json", strings.NewReader(`{"name":"x","password":"hunter2"}`))
+	if err != nil {
+		t.Fatalf("client.Post = %v", err)
+	}
+	var item struct{ ID int }
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		t.Fatalf("Decode = %v", err)
+	}
+	resp.Body.Close()
+
+	op := ct.OpenAPI("Items").Paths["/items"]["post"]
+	if op == nil {
+		t.Fatalf("OpenAPI has no POST /items operation")
+	}
+	if got, want := op.RequestBody.Content["application/json"].Example, map[string]interface{}{"name": "x", "password": "REDACTED"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("request example = %v, want %v", got, want)
+	}
+	if got, want := op.Responses["200"].Content["application/json"].Example, map[string]interface{}{"id": json.Number("7")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("response example = %v, want %v", got, want)
+	}
+}
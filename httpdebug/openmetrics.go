@@ -0,0 +1,244 @@
+package httpdebug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the histogram buckets, in seconds, for latencies.
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// sizeBuckets are the histogram buckets, in bytes, for body sizes.
+var sizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+
+// seriesKey identifies the histograms of a single host and method.
+type seriesKey struct {
+	host, method string
+}
+
+// requestKey identifies the request counter of a single host, method,
+// and status code.
+type requestKey struct {
+	host, method, code string
+}
+
+// histogram is a fixed-bucket histogram.
+type histogram struct {
+	buckets []float64
+	counts  []uint64 // non-cumulative, one per bucket
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// requestStats accumulates the counters and histograms written by
+// WriteMetrics. It is safe for concurrent use.
+type requestStats struct {
+	mu           sync.Mutex
+	requests     map[requestKey]uint64
+	duration     map[seriesKey]*histogram
+	requestSize  map[seriesKey]*histogram
+	responseSize map[seriesKey]*histogram
+}
+
+// observe records the outcome of a single round trip, summarized under
+// host.
+func (s *requestStats) observe(host string, req *http.Request, resp *http.Response, elapsed time.Duration) {
+	key := seriesKey{host: host, method: req.Method}
+	s.mu.Lock()
+	if s.requests == nil {
+		s.requests = map[requestKey]uint64{}
+		s.duration = map[seriesKey]*histogram{}
+		s.requestSize = map[seriesKey]*histogram{}
+		s.responseSize = map[seriesKey]*histogram{}
+	}
+	s.requests[requestKey{host: key.host, method: key.method, code: statusLabel(resp)}]++
+	s.histogram(s.duration, key, durationBuckets).observe(elapsed.Seconds())
+	if req.ContentLength >= 0 {
+		s.histogram(s.requestSize, key, sizeBuckets).observe(float64(req.ContentLength))
+	}
+	s.mu.Unlock()
+
+	if resp != nil {
+		observeResponseSize(resp, func(v float64) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.histogram(s.responseSize, key, sizeBuckets).observe(v)
+		})
+	}
+}
+
+// histogram returns the histogram for key in m, creating it if
+// necessary. The caller must hold s.mu.
+func (s *requestStats) histogram(m map[seriesKey]*histogram, key seriesKey, buckets []float64) *histogram {
+	h, ok := m[key]
+	if !ok {
+		h = newHistogram(buckets)
+		m[key] = h
+	}
+	return h
+}
+
+// WriteMetrics writes a snapshot of the transport's request counters and
+// latency and body size histograms to w in the OpenMetrics text format,
+// without depending on a Prometheus client library. The metrics are the
+// same as those exported by the WithMetrics option of the
+// contrib/httpdebugprom module, plus the cumulative estimated cost if the
+// transport has a Coster.
+func (t *CurlTransport) WriteMetrics(w io.Writer) error {
+	s := &t.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# TYPE httpdebug_requests counter")
+	fmt.Fprintln(bw, "# HELP httpdebug_requests Total number of HTTP requests by host, method, and status code.")
+	keys := make([]requestKey, 0, len(s.requests))
+	for k := range s.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.host != b.host {
+			return a.host < b.host
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+	for _, k := range keys {
+		fmt.Fprintf(bw, "httpdebug_requests_total{host=%v,method=%v,code=%v} %v\n",
+			labelValue(k.host), labelValue(k.method), labelValue(k.code), s.requests[k])
+	}
+
+	if t.Coster != nil {
+		fmt.Fprintln(bw, "# TYPE httpdebug_cost counter")
+		fmt.Fprintln(bw, "# HELP httpdebug_cost Cumulative estimated cost of all requests.")
+		fmt.Fprintf(bw, "httpdebug_cost_total %v\n", formatFloat(t.TotalCost()))
+	}
+
+	writeHistograms(bw, "httpdebug_request_duration_seconds", "seconds", "Round-trip latency of HTTP requests.", s.duration)
+	writeHistograms(bw, "httpdebug_request_size_bytes", "bytes", "Size of HTTP request bodies.", s.requestSize)
+	writeHistograms(bw, "httpdebug_response_size_bytes", "bytes", "Size of HTTP response bodies.", s.responseSize)
+	fmt.Fprintln(bw, "# EOF")
+	return bw.Flush()
+}
+
+// writeHistograms writes the metric family of the named histograms.
+func writeHistograms(w io.Writer, name, unit, help string, m map[seriesKey]*histogram) {
+	fmt.Fprintf(w, "# TYPE %v histogram\n", name)
+	fmt.Fprintf(w, "# UNIT %v %v\n", name, unit)
+	fmt.Fprintf(w, "# HELP %v %v\n", name, help)
+	keys := make([]seriesKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].host != keys[j].host {
+			return keys[i].host < keys[j].host
+		}
+		return keys[i].method < keys[j].method
+	})
+	for _, k := range keys {
+		h := m[k]
+		labels := fmt.Sprintf("host=%v,method=%v", labelValue(k.host), labelValue(k.method))
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%v_bucket{%v,le=%q} %v\n", name, labels, formatFloat(le), cumulative)
+		}
+		fmt.Fprintf(w, "%v_bucket{%v,le=\"+Inf\"} %v\n", name, labels, h.count)
+		fmt.Fprintf(w, "%v_sum{%v} %v\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(w, "%v_count{%v} %v\n", name, labels, h.count)
+	}
+}
+
+// labelValue returns s as a quoted OpenMetrics label value.
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// formatFloat formats v as an OpenMetrics number, always including a
+// decimal point for integral values (e.g. "1.0").
+func formatFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// MetricsHandler returns an http.Handler that serves the output of
+// WriteMetrics, suitable for scraping.
+func (t *CurlTransport) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		t.WriteMetrics(w)
+	})
+}
+
+// statusLabel returns the status code of resp as a metric label value,
+// or "error" if there is no response.
+func statusLabel(resp *http.Response) string {
+	if resp == nil {
+		return "error"
+	}
+	return strconv.Itoa(resp.StatusCode)
+}
+
+// observeResponseSize passes the size of the body of resp to observe,
+// either immediately if it is known from the Content-Length or once the
+// body has been read or closed.
+func observeResponseSize(resp *http.Response, observe func(float64)) {
+	if resp.ContentLength >= 0 || resp.Body == nil {
+		observe(float64(max(resp.ContentLength, 0)))
+		return
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, observe: observe}
+}
+
+// countingBody counts the bytes read from a response body of unknown
+// length and reports the total when the body is exhausted or closed.
+type countingBody struct {
+	io.ReadCloser
+	observe func(float64)
+	n       int64
+	once    sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.report()
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.report()
+	return b.ReadCloser.Close()
+}
+
+func (b *countingBody) report() {
+	b.once.Do(func() { b.observe(float64(b.n)) })
+}
@@ -0,0 +1,122 @@
+package httpdebug
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetrics_Empty(t *testing.T) {
+	var b strings.Builder
+	if err := New().WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics = %v", err)
+	}
+	want := `# TYPE httpdebug_requests counter
+# HELP httpdebug_requests Total number of HTTP requests by host, method, and status code.
+# TYPE httpdebug_request_duration_seconds histogram
+# UNIT httpdebug_request_duration_seconds seconds
+# HELP httpdebug_request_duration_seconds Round-trip latency of HTTP requests.
+# TYPE httpdebug_request_size_bytes histogram
+# UNIT httpdebug_request_size_bytes bytes
+# HELP httpdebug_request_size_bytes Size of HTTP request bodies.
+# TYPE httpdebug_response_size_bytes histogram
+# UNIT httpdebug_response_size_bytes bytes
+# HELP httpdebug_response_size_bytes Size of HTTP response bodies.
+# EOF
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteMetrics =\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	client, mux, serverURL, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("x", 100))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	ct := New()
+	client.Transport = ct
+	for _, path := range []string{"/", "/", "/missing"} {
+		resp, err := client.Post(serverURL+path, "text/plain", strings.NewReader("body"))
+		if err != nil {
+			t.Fatalf("client.Post = %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	var b strings.Builder
+	if err := ct.WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics = %v", err)
+	}
+	got := b.String()
+
+	u, _ := url.Parse(serverURL)
+	labels := fmt.Sprintf(`host=%q,method="POST"`, u.Host)
+	for _, want := range []string{
+		fmt.Sprintf("httpdebug_requests_total{%v,code=\"200\"} 2\n", labels),
+		fmt.Sprintf("httpdebug_requests_total{%v,code=\"404\"} 1\n", labels),
+		fmt.Sprintf("httpdebug_request_duration_seconds_count{%v} 3\n", labels),
+		fmt.Sprintf("httpdebug_request_size_bytes_bucket{%v,le=\"64.0\"} 3\n", labels),
+		fmt.Sprintf("httpdebug_request_size_bytes_sum{%v} 12.0\n", labels),
+		fmt.Sprintf("httpdebug_response_size_bytes_bucket{%v,le=\"64.0\"} 1\n", labels),
+		fmt.Sprintf("httpdebug_response_size_bytes_bucket{%v,le=\"256.0\"} 3\n", labels),
+		fmt.Sprintf("httpdebug_response_size_bytes_bucket{%v,le=\"+Inf\"} 3\n", labels),
+		fmt.Sprintf("httpdebug_response_size_bytes_sum{%v} 219.0\n", labels),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteMetrics =\n%v\nmissing: %v", got, want)
+		}
+	}
+	if !strings.HasSuffix(got, "# EOF\n") {
+		t.Errorf("WriteMetrics does not end with # EOF:\n%v", got)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	New().MetricsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if got, want := w.Header().Get("Content-Type"), "application/openmetrics-text; version=1.0.0; charset=utf-8"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	if !strings.HasSuffix(w.Body.String(), "# EOF\n") {
+		t.Errorf("body = %q, want OpenMetrics exposition", w.Body.String())
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	if got, want := labelValue("a\"b\\c\nd"), `"a\"b\\c\nd"`; got != want {
+		t.Errorf("labelValue = %v, want %v", got, want)
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{v: 0, want: "0.0"},
+		{v: 1, want: "1.0"},
+		{v: 0.005, want: "0.005"},
+		{v: 1048576, want: "1048576.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := formatFloat(tt.v); got != tt.want {
+				t.Errorf("formatFloat(%v) = %v, want %v", tt.v, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package httpdebug
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+)
+
+// NewForwardProxy returns an http.Handler that acts as an HTTP forward
+// proxy, sending every proxied request through a CurlTransport configured
+// with opts so that it is logged as curl. Pointing HTTP_PROXY at it allows
+// third-party binaries to be debugged without modifying their code.
+//
+// HTTPS requests tunneled with CONNECT are passed through unmodified;
+// only the tunnel itself is logged, since its contents are encrypted.
+func NewForwardProxy(opts ...CurlTransportOption) http.Handler {
+	ct := New(opts...)
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.Header.Del("Proxy-Authorization")
+		},
+		Transport: ct,
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			ct.tunnel(w, r)
+			return
+		}
+		if !r.URL.IsAbs() {
+			http.Error(w, "httpdebug: this is a forward proxy; requests must use absolute URLs", http.StatusBadRequest)
+			return
+		}
+		rp.ServeHTTP(w, r)
+	})
+}
+
+// tunnelDialTimeout limits how long a CONNECT tunnel waits to reach its target.
+const tunnelDialTimeout = 30 * time.Second
+
+// tunnel handles a CONNECT request by splicing the client connection
+// to the requested host.
+func (t *CurlTransport) tunnel(w http.ResponseWriter, r *http.Request) {
+	if t.Enabled() {
+		t.log(fmt.Sprintf("# CONNECT %v (tunneled; contents not visible)", r.Host))
+	}
+
+	upstream, err := net.DialTimeout("tcp", r.Host, tunnelDialTimeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "httpdebug: hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hj.Hijack()
+	if err != nil {
+		upstream.Close()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	pipe := func(dst net.Conn, src io.Reader) {
+		io.Copy(dst, src)
+		if tc, ok := dst.(*net.TCPConn); ok {
+			tc.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go pipe(upstream, buf)
+	go pipe(client, upstream)
+	<-done
+	<-done
+	client.Close()
+	upstream.Close()
+}
+
+// NewReverseProxy returns an httputil.ReverseProxy that forwards requests
+// to target (see httputil.ProxyRequest.SetURL) and logs both the inbound
+// request and the rewritten upstream request as curl, which is useful for
+// debugging API gateways and local service shims. The X-Forwarded-*
+// headers are set on the upstream request. The options configure
+// redaction and output just as they do for New. The returned proxy may
+// be customized further, for example by setting its ErrorHandler.
+func NewReverseProxy(target *url.URL, opts ...CurlTransportOption) *httputil.ReverseProxy {
+	ct := New(opts...)
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if ct.Enabled() {
+				ct.log("# reverse proxy: inbound request")
+				// pr.In must not be modified, so the inbound request is
+				// dumped from a shallow copy that shares the body of
+				// pr.Out, which then sends what dumpInbound peeked.
+				in := *pr.In
+				in.Body = pr.Out.Body
+				ct.dumpInbound(&in)
+				pr.Out.Body = in.Body
+			}
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out = pr.Out.WithContext(withNotice(pr.Out.Context(), "# reverse proxy: upstream request"))
+		},
+		Transport: ct,
+	}
+}
+
+// noticeKey is the context key set by withNotice.
+type noticeKey struct{}
+
+// withNotice returns a copy of ctx that causes a CurlTransport to log
+// notice just before the dump of a request made with it, and not at all
+// if the request is not dumped.
+func withNotice(ctx context.Context, notice string) context.Context {
+	return context.WithValue(ctx, noticeKey{}, notice)
+}
+
+// noticeFrom returns the notice set by withNotice, or "".
+func noticeFrom(ctx context.Context) string {
+	notice, _ := ctx.Value(noticeKey{}).(string)
+	return notice
+}
@@ -0,0 +1,192 @@
+package httpdebug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewForwardProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			t.Error("Proxy-Authorization was forwarded")
+		}
+		fmt.Fprintf(w, "%v %v", r.Method, r.URL.Path)
+	}))
+	defer backend.Close()
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logged := make(chan string, 10)
+	logger = func(v ...interface{}) { logged <- fmt.Sprint(v...) }
+
+	proxy := httptest.NewServer(NewForwardProxy())
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	req, _ := http.NewRequest("GET", backend.URL+"/hello", nil)
+	req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("client.Do = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "GET /hello" {
+		t.Errorf("body = %q, want %q", body, "GET /hello")
+	}
+	if got := <-logged; !strings.HasPrefix(got, "curl \\\n  "+backend.URL+"/hello") {
+		t.Errorf("logged = %q, want curl command", got)
+	}
+}
+
+func TestNewForwardProxy_RelativeURL(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewForwardProxy().ServeHTTP(w, httptest.NewRequest("GET", "/not-absolute", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestNewForwardProxy_Connect(t *testing.T) {
+	// An echo server stands in for a TLS endpoint.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logged := make(chan string, 10)
+	logger = func(v ...interface{}) { logged <- fmt.Sprint(v...) }
+
+	proxy := httptest.NewServer(NewForwardProxy())
+	defer proxy.Close()
+
+	conn, err := net.Dial("tcp", proxy.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	target := l.Addr().String()
+	fmt.Fprintf(conn, "CONNECT %v HTTP/1.1\r\nHost: %v\r\n\r\n", target, target)
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("ReadResponse = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("CONNECT status = %v", resp.Status)
+	}
+
+	fmt.Fprint(conn, "ping\n")
+	line, err := r.ReadString('\n')
+	if err != nil || line != "ping\n" {
+		t.Errorf("echo = %q, %v", line, err)
+	}
+
+	if got, want := <-logged, fmt.Sprintf("# CONNECT %v (tunneled; contents not visible)", target); got != want {
+		t.Errorf("logged = %q, want %q", got, want)
+	}
+}
+
+func TestNewReverseProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%v %v %s", r.Method, r.URL.Path, body)
+	}))
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL + "/api")
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	proxy := httptest.NewServer(NewReverseProxy(target))
+	defer proxy.Close()
+
+	resp, err := http.Post(proxy.URL+"/items", "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("http.Post = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if want := "POST /api/items payload"; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if len(logged) != 4 {
+		t.Fatalf("logged = %#v, want 4 entries", logged)
+	}
+	if logged[0] != "# reverse proxy: inbound request" || logged[2] != "# reverse proxy: upstream request" {
+		t.Errorf("logged markers = %q, %q", logged[0], logged[2])
+	}
+	if want := "curl -X POST \\\n  " + proxy.URL + "/items"; !strings.HasPrefix(logged[1], want) || !strings.HasSuffix(logged[1], "--data-raw 'payload'") {
+		t.Errorf("inbound = %q, want prefix %q", logged[1], want)
+	}
+	if want := "curl -X POST \\\n  " + backend.URL + "/api/items"; !strings.HasPrefix(logged[3], want) || !strings.Contains(logged[3], "X-Forwarded-Host") || !strings.HasSuffix(logged[3], "--data-raw 'payload'") {
+		t.Errorf("upstream = %q, want prefix %q", logged[3], want)
+	}
+}
+
+func TestNewReverseProxy_SampledOut(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL)
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	// The inbound request must not be modified by the proxy.
+	rp := NewReverseProxy(target, WithEveryNth(2))
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := r.Body
+		rp.ServeHTTP(w, r)
+		if r.Body != body {
+			t.Errorf("inbound request body was replaced")
+		}
+	}))
+	defer proxy.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Post(proxy.URL, "text/plain", strings.NewReader("payload"))
+		if err != nil {
+			t.Fatalf("http.Post = %v", err)
+		}
+		if body, _ := io.ReadAll(resp.Body); string(body) != "payload" {
+			t.Errorf("body = %q, want %q", body, "payload")
+		}
+		resp.Body.Close()
+	}
+
+	// The second upstream request is sampled out, and so is its marker.
+	if len(logged) != 6 || logged[4] != "# reverse proxy: inbound request" {
+		t.Fatalf("logged = %#v, want 6 entries", logged)
+	}
+	if logged[2] != "# reverse proxy: upstream request" {
+		t.Errorf("logged[2] = %q, want the upstream marker", logged[2])
+	}
+}
@@ -0,0 +1,131 @@
+package httpdebug
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxRateLimitKeys is the number of distinct requests tracked by a rate
+// limiter before expired ones are discarded.
+const maxRateLimitKeys = 1024
+
+// WithRateLimit is a CurlTransportOption that dumps at most n similar
+// requests (those with the same method and URL) in every period of per,
+// so that bursts like retries in a tight loop do not flood the log.
+// Suppressed requests are passed straight through, as if the transport
+// were disabled, and are summarized in a "# suppressed N similar
+// requests in the last ..." line before the next one that is dumped, or,
+// if there is none, when the window is discarded to make room for other
+// requests and by Close. An n or per of zero or less disables rate
+// limiting.
+func WithRateLimit(n int, per time.Duration) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.RateLimit = n
+		ct.RateLimitPer = per
+	}
+}
+
+// rateWindow tracks the requests with a single key.
+type rateWindow struct {
+	start      time.Time // start of the current window
+	count      int       // requests dumped in the current window
+	suppressed int       // requests suppressed since the last dump
+	firstDrop  time.Time // time of the first suppressed request
+}
+
+// rateLimiter counts similar requests in fixed windows.
+// It is safe for concurrent use.
+type rateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+// allow reports whether the request with key may be dumped at now, given
+// a limit of n per period. If it may, and earlier requests with the same
+// key were suppressed, allow also returns the summary line to log first.
+// The summaries of the windows of other keys discarded to make room for
+// key are returned as expired, to be logged regardless.
+func (l *rateLimiter) allow(key string, n int, per time.Duration, now time.Time) (summary string, expired []string, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.windows == nil {
+		l.windows = map[string]*rateWindow{}
+	}
+	w, found := l.windows[key]
+	if !found {
+		if len(l.windows) >= maxRateLimitKeys {
+			expired = l.prune(per, now)
+		}
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+	if now.Sub(w.start) >= per {
+		w.start = now
+		w.count = 0
+	}
+	if w.count >= n {
+		if w.suppressed == 0 {
+			w.firstDrop = now
+		}
+		w.suppressed++
+		return "", expired, false
+	}
+
+	w.count++
+	if w.suppressed > 0 {
+		summary = fmt.Sprintf("# suppressed %v similar requests in the last %v", w.suppressed, now.Sub(w.firstDrop).Round(time.Second))
+		w.suppressed = 0
+	}
+	return summary, expired, true
+}
+
+// prune discards the windows that have expired and returns the summaries
+// of those that suppressed requests (see summaries). The caller must hold
+// l.mu.
+func (l *rateLimiter) prune(per time.Duration, now time.Time) []string {
+	var keys []string
+	for key, w := range l.windows {
+		if now.Sub(w.start) < per {
+			continue
+		}
+		if w.suppressed > 0 {
+			keys = append(keys, key)
+		} else {
+			delete(l.windows, key)
+		}
+	}
+	return l.takeSummaries(keys, now)
+}
+
+// summaries discards all windows and returns, in order of key, a line for
+// each that suppressed requests since its last dump, naming the requests,
+// since no dump of them follows.
+func (l *rateLimiter) summaries(now time.Time) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var keys []string
+	for key, w := range l.windows {
+		if w.suppressed > 0 {
+			keys = append(keys, key)
+		}
+	}
+	summaries := l.takeSummaries(keys, now)
+	l.windows = nil
+	return summaries
+}
+
+// takeSummaries discards the windows with keys and returns their
+// summaries in order of key. The caller must hold l.mu.
+func (l *rateLimiter) takeSummaries(keys []string, now time.Time) []string {
+	sort.Strings(keys)
+	var summaries []string
+	for _, key := range keys {
+		w := l.windows[key]
+		summaries = append(summaries, fmt.Sprintf("# suppressed %v similar requests in the last %v: %v", w.suppressed, now.Sub(w.firstDrop).Round(time.Second), key))
+		delete(l.windows, key)
+	}
+	return summaries
+}
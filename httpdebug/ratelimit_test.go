@@ -0,0 +1,159 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	ct := New(WithRateLimit(5, time.Minute))
+	if ct.RateLimit != 5 || ct.RateLimitPer != time.Minute {
+		t.Errorf("RateLimit = %v per %v, want 5 per 1m0s", ct.RateLimit, ct.RateLimitPer)
+	}
+}
+
+func TestRateLimiter_allow(t *testing.T) {
+	start := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	l := &rateLimiter{}
+
+	tests := []struct {
+		key     string
+		at      time.Duration
+		wantOK  bool
+		summary string
+	}{
+		{key: "GET /a", at: 0, wantOK: true},
+		{key: "GET /a", at: time.Second, wantOK: true},
+		{key: "GET /a", at: 2 * time.Second},
+		{key: "GET /b", at: 3 * time.Second, wantOK: true},
+		{key: "GET /a", at: 4 * time.Second},
+		{key: "GET /a", at: 5 * time.Second},
+		{key: "GET /a", at: 61 * time.Second, wantOK: true, summary: "# suppressed 3 similar requests in the last 59s"},
+		{key: "GET /a", at: 62 * time.Second, wantOK: true},
+		{key: "GET /a", at: 63 * time.Second},
+	}
+
+	for i, tt := range tests {
+		summary, _, ok := l.allow(tt.key, 2, time.Minute, start.Add(tt.at))
+		if ok != tt.wantOK || summary != tt.summary {
+			t.Errorf("#%v: allow(%q, +%v) = (%q, %v), want (%q, %v)", i, tt.key, tt.at, summary, ok, tt.summary, tt.wantOK)
+		}
+	}
+}
+
+func TestRateLimiter_prune(t *testing.T) {
+	start := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	l := &rateLimiter{}
+	for i := 0; i < maxRateLimitKeys; i++ {
+		l.allow(fmt.Sprintf("GET /%v", i), 1, time.Second, start)
+	}
+	l.allow("GET /0", 1, time.Second, start.Add(time.Millisecond)) // suppressed
+
+	_, expired, ok := l.allow("GET /new", 1, time.Second, start.Add(time.Minute))
+	if got := len(l.windows); got != 1 || !ok {
+		t.Errorf("got %v windows after pruning, want 1", got)
+	}
+	if want := []string{"# suppressed 1 similar requests in the last 1m0s: GET /0"}; !reflect.DeepEqual(expired, want) {
+		t.Errorf("expired = %q, want %q", expired, want)
+	}
+}
+
+func TestRateLimiter_summaries(t *testing.T) {
+	start := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	l := &rateLimiter{}
+	for _, key := range []string{"GET /b", "GET /b", "GET /b", "GET /a", "GET /a", "GET /c"} {
+		l.allow(key, 1, time.Minute, start)
+	}
+
+	want := []string{
+		"# suppressed 1 similar requests in the last 2s: GET /a",
+		"# suppressed 2 similar requests in the last 2s: GET /b",
+	}
+	if got := l.summaries(start.Add(2 * time.Second)); !reflect.DeepEqual(got, want) {
+		t.Errorf("summaries = %q, want %q", got, want)
+	}
+	if got := l.summaries(start.Add(3 * time.Second)); got != nil {
+		t.Errorf("summaries after summaries = %q, want none", got)
+	}
+}
+
+func TestRoundTrip_RateLimit(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithRateLimit(2, time.Hour))
+	for i := 0; i < 10; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+	resp, err := client.Get(url + "/other")
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(logged) != 3 {
+		t.Fatalf("got %v dumps, want 3: %#v", len(logged), logged)
+	}
+	if !strings.Contains(logged[2], "/other") {
+		t.Errorf("logged[2] = %q, want dump of the other URL", logged[2])
+	}
+
+	// The requests suppressed with no dump to follow are reported on Close.
+	client.Transport.(*CurlTransport).Close()
+	if len(logged) != 4 || !strings.HasPrefix(logged[3], "# suppressed 8 similar requests in the last ") || !strings.HasSuffix(logged[3], ": GET "+url) {
+		t.Errorf("logged after Close = %#v, want a summary of the suppressed requests", logged[3:])
+	}
+}
+
+func TestRoundTrip_RateLimitSummaryNotDumped(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+	oldRand := sampleRand
+	defer func() { sampleRand = oldRand }()
+	sampleRand = func() float64 { return 0.9 }
+
+	ok := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	tests := []struct {
+		name string
+		opts []CurlTransportOption
+	}{
+		// The request that takes the summary repeats the last one dumped.
+		{"dedup", []CurlTransportOption{WithDedup()}},
+		// The request that takes the summary is held and not interesting.
+		{"adaptive sampling", []CurlTransportOption{WithSampleRate(0.5), WithAdaptiveSampling(0)}},
+	}
+	for _, tt := range tests {
+		logged = nil
+		ct := New(append(tt.opts, WithTransport(ok), WithRateLimit(1, 50*time.Millisecond))...)
+		for i := 0; i < 3; i++ {
+			if i == 2 {
+				time.Sleep(60 * time.Millisecond)
+			}
+			req, _ := http.NewRequest("GET", "http://example.com/a", nil)
+			if _, err := ct.RoundTrip(req); err != nil {
+				t.Fatalf("%v: RoundTrip = %v", tt.name, err)
+			}
+		}
+		if len(logged) != 2 || !strings.HasPrefix(logged[1], "# suppressed 1 similar requests in the last ") {
+			t.Errorf("%v: logged = %#v, want a dump and the summary", tt.name, logged)
+		}
+	}
+}
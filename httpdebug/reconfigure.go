@@ -0,0 +1,113 @@
+package httpdebug
+
+// The Set and Add methods change the name lists of a transport that is
+// already in use. Their fields are guarded by listMu and are never
+// modified in place: each change stores a new slice, so that a Redactor
+// holding the previous one keeps a consistent snapshot.
+
+// AddSecretHeaders adds header keys to the SecretHeaders, like
+// WithSecretHeaders, so that a long-lived service can change its
+// redaction rules without a restart. Like the other Set and Add methods,
+// it is safe to call concurrently with requests and with each other;
+// assigning the fields directly is not. Empty names are ignored.
+func (t *CurlTransport) AddSecretHeaders(names ...string) {
+	t.addList(&t.SecretHeaders, names)
+}
+
+// SetSecretHeaders replaces the SecretHeaders, including the
+// DefaultSecretHeaders, with names, like WithReplaceSecretHeaders.
+// Headers whose keys contain the letters 'jwt' are still redacted.
+func (t *CurlTransport) SetSecretHeaders(names ...string) {
+	t.setList(&t.SecretHeaders, names)
+}
+
+// AddHeaderAllowlist adds header keys to the HeaderAllowlist, like
+// WithHeaderAllowlist.
+func (t *CurlTransport) AddHeaderAllowlist(names ...string) {
+	t.addList(&t.HeaderAllowlist, names)
+}
+
+// SetHeaderAllowlist replaces the HeaderAllowlist with names. Passing
+// none prints all headers but the SecretHeaders in the clear again.
+func (t *CurlTransport) SetHeaderAllowlist(names ...string) {
+	t.setList(&t.HeaderAllowlist, names)
+}
+
+// AddSecretCookies adds cookie names to the SecretCookies, like
+// WithSecretCookie.
+func (t *CurlTransport) AddSecretCookies(names ...string) {
+	t.addList(&t.SecretCookies, names)
+}
+
+// SetSecretCookies replaces the SecretCookies with names.
+func (t *CurlTransport) SetSecretCookies(names ...string) {
+	t.setList(&t.SecretCookies, names)
+}
+
+// AddSecretParams adds query parameter names to the SecretParams, like
+// WithSecretParams.
+func (t *CurlTransport) AddSecretParams(names ...string) {
+	t.addList(&t.SecretParams, names)
+}
+
+// SetSecretParams replaces the SecretParams, including the
+// DefaultSecretParams, with names, like WithReplaceSecretParams.
+func (t *CurlTransport) SetSecretParams(names ...string) {
+	t.setList(&t.SecretParams, names)
+}
+
+// AddParamAllowlist adds query parameter names to the ParamAllowlist,
+// like WithParamAllowlist.
+func (t *CurlTransport) AddParamAllowlist(names ...string) {
+	t.addList(&t.ParamAllowlist, names)
+}
+
+// SetParamAllowlist replaces the ParamAllowlist with names.
+func (t *CurlTransport) SetParamAllowlist(names ...string) {
+	t.setList(&t.ParamAllowlist, names)
+}
+
+// AddSecretBodyFields adds JSON object keys to the SecretBodyFields, like
+// WithSecretBodyField.
+func (t *CurlTransport) AddSecretBodyFields(names ...string) {
+	t.addList(&t.SecretBodyFields, names)
+}
+
+// SetSecretBodyFields replaces the SecretBodyFields with names.
+func (t *CurlTransport) SetSecretBodyFields(names ...string) {
+	t.setList(&t.SecretBodyFields, names)
+}
+
+// AddSafeModeAllowlist adds URL patterns to the SafeModeAllowlist, like
+// WithSafeMode, without turning SafeMode on.
+func (t *CurlTransport) AddSafeModeAllowlist(patterns ...string) {
+	t.addList(&t.SafeModeAllowlist, patterns)
+}
+
+// SetSafeModeAllowlist replaces the SafeModeAllowlist with patterns.
+// Passing none blocks every request while SafeMode is on.
+func (t *CurlTransport) SetSafeModeAllowlist(patterns ...string) {
+	t.setList(&t.SafeModeAllowlist, patterns)
+}
+
+// list returns the current value of the name list field of t.
+func (t *CurlTransport) list(field *[]string) []string {
+	t.listMu.RLock()
+	defer t.listMu.RUnlock()
+	return *field
+}
+
+// addList stores a new slice of the names in field of t followed by the
+// non-empty names.
+func (t *CurlTransport) addList(field *[]string, names []string) {
+	t.listMu.Lock()
+	defer t.listMu.Unlock()
+	*field = concatNonEmpty(*field, names)
+}
+
+// setList stores a new slice of the non-empty names in field of t.
+func (t *CurlTransport) setList(field *[]string, names []string) {
+	t.listMu.Lock()
+	defer t.listMu.Unlock()
+	*field = concatNonEmpty([]string{}, names)
+}
@@ -0,0 +1,114 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCurlTransport_SetAdd(t *testing.T) {
+	tests := []struct {
+		name  string
+		field func(ct *CurlTransport) []string
+		add   func(ct *CurlTransport, names ...string)
+		set   func(ct *CurlTransport, names ...string)
+	}{
+		{"SecretHeaders", func(ct *CurlTransport) []string { return ct.SecretHeaders }, (*CurlTransport).AddSecretHeaders, (*CurlTransport).SetSecretHeaders},
+		{"HeaderAllowlist", func(ct *CurlTransport) []string { return ct.HeaderAllowlist }, (*CurlTransport).AddHeaderAllowlist, (*CurlTransport).SetHeaderAllowlist},
+		{"SecretCookies", func(ct *CurlTransport) []string { return ct.SecretCookies }, (*CurlTransport).AddSecretCookies, (*CurlTransport).SetSecretCookies},
+		{"SecretParams", func(ct *CurlTransport) []string { return ct.SecretParams }, (*CurlTransport).AddSecretParams, (*CurlTransport).SetSecretParams},
+		{"ParamAllowlist", func(ct *CurlTransport) []string { return ct.ParamAllowlist }, (*CurlTransport).AddParamAllowlist, (*CurlTransport).SetParamAllowlist},
+		{"SecretBodyFields", func(ct *CurlTransport) []string { return ct.SecretBodyFields }, (*CurlTransport).AddSecretBodyFields, (*CurlTransport).SetSecretBodyFields},
+		{"SafeModeAllowlist", func(ct *CurlTransport) []string { return ct.SafeModeAllowlist }, (*CurlTransport).AddSafeModeAllowlist, (*CurlTransport).SetSafeModeAllowlist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := New()
+			before := tt.field(ct)
+			tt.add(ct, "one", "", "two")
+			want := append(append([]string{}, before...), "one", "two")
+			if got := tt.field(ct); !reflect.DeepEqual(got, want) {
+				t.Errorf("after Add, %v = %#v, want %#v", tt.name, got, want)
+			}
+			if got := tt.field(ct); len(before) > 0 && &got[0] == &before[0] {
+				t.Errorf("Add modified the previous %v in place", tt.name)
+			}
+
+			tt.set(ct, "three")
+			if got, want := tt.field(ct), []string{"three"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("after Set, %v = %#v, want %#v", tt.name, got, want)
+			}
+			tt.set(ct)
+			if got := tt.field(ct); len(got) != 0 {
+				t.Errorf("after Set with no names, %v = %#v, want none", tt.name, got)
+			}
+		})
+	}
+}
+
+func TestCurlTransport_SetAdd_Redaction(t *testing.T) {
+	ct := New(WithTransport(&http.Transport{}))
+	req, _ := http.NewRequest("GET", "https://example.com/?page=1&session=s1", nil)
+	req.Header.Set("X-Tenant-Secret", "t1")
+
+	ct.AddSecretHeaders("X-Tenant-Secret")
+	ct.AddSecretParams("session")
+	got, err := ct.dumpRequestAsCurl(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "t1") || strings.Contains(got, "s1") {
+		t.Errorf("dumpRequestAsCurl =\n%v\nwant the new secrets redacted", got)
+	}
+
+	ct.SetSecretParams()
+	if got := ct.sanitizeURL(req.URL); got != "https://example.com/?page=1&session=s1" {
+		t.Errorf("sanitizeURL after SetSecretParams() = %v, want nothing redacted", got)
+	}
+}
+
+func TestCurlTransport_SetAdd_Concurrent(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	ct := New(WithTransport(&http.Transport{}), WithSafeMode("example.com"))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				name := fmt.Sprintf("x-secret-%v-%v", i, j)
+				ct.AddSecretHeaders(name)
+				ct.AddSecretParams(name)
+				ct.AddSafeModeAllowlist(name + ".example.com")
+				if j%10 == 0 {
+					ct.SetHeaderAllowlist()
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				req, _ := http.NewRequest("GET", "https://example.com/?x=1", nil)
+				req.Header.Set("Authorization", "token")
+				ctx := WithRequestOptions(req.Context(), WithPrefix("[x] "))
+				if _, err := ct.withOptions(requestOptions(ctx)).dumpRequestAsCurl(req); err != nil {
+					t.Error(err)
+				}
+				ct.allowed(req)
+				ct.Config()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := len(ct.SecretHeaders), len(DefaultSecretHeaders)+4*50; got != want {
+		t.Errorf("got %v SecretHeaders, want %v", got, want)
+	}
+}
@@ -0,0 +1,566 @@
+package httpdebug
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Redactor scrubs secrets from headers, query parameters, URLs, and
+// bodies. It is the redaction engine used by CurlTransport and can be
+// used by other logging code to apply identical rules; see
+// CurlTransport.Redactor. The fields have the same meaning as the
+// corresponding CurlTransport fields.
+type Redactor struct {
+	RedactEntireJWT  bool
+	ShowJWTClaims    bool
+	SecretHeaders    []string
+	HeaderAllowlist  []string
+	SecretCookies    []string
+	SecretParams     []string
+	ParamAllowlist   []string
+	RedactAllParams  bool
+	SecretBodyFields []string
+	KeepUsername     bool
+
+	SecretPathPatterns []*regexp.Regexp
+
+	// Placeholder, if non-empty, replaces each secret in its entirety
+	// instead of the default "REDACTED" and "<REDACTED>" markers.
+	Placeholder string
+
+	// HashSecrets, when true and there is no Placeholder, causes each
+	// marker to be followed by a short hash of the secret it replaces,
+	// e.g. "REDACTED:3fa2b1", so that requests using the same secret
+	// can be told apart from those using another.
+	HashSecrets bool
+
+	// HashKey is the key of the HMAC giving the hashes. Redactors with
+	// the same key give the same hash for a secret; if it is empty, a
+	// key chosen at random when the process starts is used, so hashes
+	// only correlate secrets within the process.
+	HashKey []byte
+
+	// RedactFunc, if non-nil, is consulted before the other rules.
+	RedactFunc RedactFunc
+}
+
+// RedactKind identifies what a RedactFunc is asked to transform.
+type RedactKind int
+
+const (
+	// RedactHeader is a request or response header. The values of a
+	// header given more than once are joined with ", ".
+	RedactHeader RedactKind = iota
+	// RedactParam is a query parameter of a URL.
+	RedactParam
+	// RedactBodyField is a field of a form-encoded body, or a field of a
+	// JSON body with a string, number, boolean, or null value, which is
+	// passed in its JSON encoding unless it is a string.
+	RedactBodyField
+)
+
+func (k RedactKind) String() string {
+	switch k {
+	case RedactHeader:
+		return "header"
+	case RedactParam:
+		return "param"
+	case RedactBodyField:
+		return "body field"
+	}
+	return fmt.Sprintf("RedactKind(%d)", int(k))
+}
+
+// RedactFunc transforms the value of the header, query parameter, or
+// body field called name before it is logged, e.g. by masking, hashing,
+// or truncating it. It returns the value to log and true, or false to
+// leave the value to the built-in redaction rules. Returning the empty
+// string and true drops the header, parameter, or field entirely.
+type RedactFunc func(kind RedactKind, name, value string) (string, bool)
+
+// WithRedactFunc is a CurlTransportOption that gives f complete control
+// over how each header, query parameter, and body field is transformed
+// before it is logged, captured, or recorded. Values for which f returns
+// false are redacted by the other rules. A nil f is ignored.
+func WithRedactFunc(f RedactFunc) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if f != nil {
+			ct.RedactFunc = f
+		}
+	}
+}
+
+// custom returns the value that the RedactFunc transforms value into,
+// and whether it handled it.
+func (r *Redactor) custom(kind RedactKind, name, value string) (string, bool) {
+	if r.RedactFunc == nil {
+		return "", false
+	}
+	return r.RedactFunc(kind, name, value)
+}
+
+// WithHashedSecrets is a CurlTransportOption that follows each marker
+// replacing a secret with a short hash of the secret, e.g.
+// "REDACTED:3fa2b1" or "<REDACTED:9c04e7>", so that it is possible to
+// tell whether two requests used the same token, e.g. when debugging
+// token rotation, without exposing it. The hash is an HMAC with key, so
+// that it cannot be used to confirm a guessed secret without the key;
+// processes sharing a key give the same hash for a secret. A nil or
+// empty key is replaced by one chosen at random when the process
+// starts. Cassettes are always recorded without hashes, so that they
+// can be replayed with other credentials.
+func WithHashedSecrets(key []byte) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.HashSecrets = true
+		ct.HashKey = key
+	}
+}
+
+// processHashKey is the HashKey used when none is given.
+var processHashKey = sync.OnceValue(func() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+})
+
+// hashLen is the number of hex digits of the hash following a marker.
+const hashLen = 6
+
+// mask returns the Placeholder, or marker if there is none, followed by
+// the hash of the secret value if HashSecrets is set.
+func (r *Redactor) mask(marker, value string) string {
+	if r.Placeholder != "" {
+		return r.Placeholder
+	}
+	if !r.HashSecrets {
+		return marker
+	}
+	key := r.HashKey
+	if len(key) == 0 {
+		key = processHashKey()
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(value))
+	sum := hex.EncodeToString(h.Sum(nil))[:hashLen]
+	if inner, ok := strings.CutSuffix(marker, ">"); ok {
+		return inner + ":" + sum + ">"
+	}
+	return marker + ":" + sum
+}
+
+// unescapeHashes returns the encoded query or form s with the colons
+// following the "REDACTED" markers, which need no escaping, unescaped.
+func (r *Redactor) unescapeHashes(s string) string {
+	if !r.HashSecrets || r.Placeholder != "" {
+		return s
+	}
+	return strings.ReplaceAll(s, "REDACTED%3A", "REDACTED:")
+}
+
+// Param returns the value of the query parameter key, redacted if
+// the parameter is secret, or the empty string if the RedactFunc drops
+// it.
+func (r *Redactor) Param(key, value string) string {
+	if v, ok := r.custom(RedactParam, key, value); ok {
+		return v
+	}
+	return r.param(key, value)
+}
+
+// param returns the value of the query parameter key, redacted if the
+// parameter is secret according to the built-in rules.
+func (r *Redactor) param(key, value string) string {
+	if value != "" && r.redactParam(key) {
+		return r.mask("REDACTED", value)
+	}
+	return value
+}
+
+// Body returns body with any secrets redacted, according to its
+// contentType. Form-encoded bodies are redacted like query parameters,
+// and JSON bodies have the values of any SecretBodyFields redacted.
+// The body is returned unchanged if nothing needs to be redacted.
+func (r *Redactor) Body(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return r.formBody(body)
+	case isJSON(mediaType):
+		return r.jsonBody(body)
+	}
+	return body
+}
+
+// retainedBody returns Body(contentType, body), or nil and false if body
+// is JSON that the SecretBodyFields or the RedactFunc might apply to but
+// that cannot be parsed, e.g. because it was truncated.
+func (r *Redactor) retainedBody(contentType string, body []byte) ([]byte, bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if len(body) > 0 && isJSON(mediaType) && (len(r.SecretBodyFields) > 0 || r.RedactFunc != nil) && !json.Valid(body) {
+		return nil, false
+	}
+	return r.Body(contentType, body), true
+}
+
+// isJSON reports whether mediaType is application/json or a structured
+// syntax type based on it (e.g. application/problem+json).
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func (r *Redactor) formBody(body []byte) []byte {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return body
+	}
+	var redacted bool
+	for key, vs := range values {
+		kept := vs[:0]
+		for _, v := range vs {
+			rv, ok := r.custom(RedactBodyField, key, v)
+			if !ok {
+				rv = r.param(key, v)
+			}
+			dropped := ok && rv == ""
+			redacted = redacted || rv != v || dropped
+			if !dropped {
+				kept = append(kept, rv)
+			}
+		}
+		values[key] = kept
+		if len(kept) == 0 {
+			delete(values, key)
+		}
+	}
+	if !redacted {
+		return body
+	}
+	return []byte(r.unescapeHashes(values.Encode()))
+}
+
+func (r *Redactor) jsonBody(body []byte) []byte {
+	if len(r.SecretBodyFields) == 0 && r.RedactFunc == nil {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+	if !r.redactJSON(v) {
+		return body
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return buf
+}
+
+// redactJSON redacts the SecretBodyFields found anywhere in v and
+// reports whether anything was redacted.
+func (r *Redactor) redactJSON(v interface{}) bool {
+	var redacted bool
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if rv, ok := r.customJSON(k, value); ok {
+				switch {
+				case rv == nil:
+					delete(v, k)
+				case rv == value:
+					continue
+				default:
+					v[k] = rv
+				}
+				redacted = true
+				continue
+			}
+			if r.isSecretBodyField(k) {
+				v[k] = r.mask("REDACTED", secretJSON(value))
+				redacted = true
+				continue
+			}
+			redacted = r.redactJSON(value) || redacted
+		}
+	case []interface{}:
+		for _, value := range v {
+			redacted = r.redactJSON(value) || redacted
+		}
+	}
+	return redacted
+}
+
+// customJSON returns the value that the RedactFunc transforms the JSON
+// field key with the given scalar value into, which is nil if it drops
+// the field, and whether it handled it.
+func (r *Redactor) customJSON(key string, value interface{}) (interface{}, bool) {
+	if r.RedactFunc == nil {
+		return nil, false
+	}
+	var s string
+	switch value := value.(type) {
+	case string:
+		s = value
+	case json.Number:
+		s = value.String()
+	case bool:
+		s = strconv.FormatBool(value)
+	case nil:
+		s = "null"
+	default:
+		return nil, false
+	}
+	rv, ok := r.RedactFunc(RedactBodyField, key, s)
+	switch {
+	case !ok:
+		return nil, false
+	case rv == "":
+		return nil, true
+	case rv == s:
+		return value, true
+	}
+	return rv, true
+}
+
+// secretJSON returns the JSON value v of a secret body field as hashed:
+// strings as they are, and other values in their JSON encoding.
+func secretJSON(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	buf, _ := json.Marshal(v)
+	return string(buf)
+}
+
+func (r *Redactor) isSecretBodyField(key string) bool {
+	for _, f := range r.SecretBodyFields {
+		if strings.EqualFold(key, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// URL returns uri as a string with the secret query parameters, the
+// secret parts of its path, and any userinfo credentials redacted.
+func (r *Redactor) URL(uri *url.URL) string {
+	if uri == nil {
+		return ""
+	}
+	newURL := *uri
+	if newURL.User != nil {
+		username := r.mask("REDACTED", newURL.User.Username())
+		if r.KeepUsername {
+			username = newURL.User.Username()
+		}
+		if password, ok := newURL.User.Password(); ok {
+			newURL.User = url.UserPassword(username, r.mask("REDACTED", password))
+		} else {
+			newURL.User = url.User(username)
+		}
+	}
+	if escaped := newURL.EscapedPath(); len(r.SecretPathPatterns) > 0 {
+		if p := r.redactPath(escaped); p != escaped {
+			path, err := url.PathUnescape(p)
+			if err != nil {
+				path = p // e.g. a Placeholder with a '%'
+			}
+			newURL.Path, newURL.RawPath = path, p
+		}
+	}
+	params := newURL.Query()
+	var redacted bool
+	for key, values := range params {
+		kept := values[:0]
+		for _, v := range values {
+			rv, ok := r.custom(RedactParam, key, v)
+			if !ok {
+				rv = r.param(key, v)
+			}
+			dropped := ok && rv == ""
+			redacted = redacted || rv != v || dropped
+			if !dropped {
+				kept = append(kept, rv)
+			}
+		}
+		params[key] = kept
+		if len(kept) == 0 {
+			delete(params, key)
+		}
+	}
+	if redacted {
+		newURL.RawQuery = r.unescapeHashes(params.Encode())
+	}
+	return newURL.String()
+}
+
+// redactPath returns the escaped URL path p with the matches of the
+// SecretPathPatterns, or of their capturing groups if they have any,
+// redacted.
+func (r *Redactor) redactPath(p string) string {
+	for _, re := range r.SecretPathPatterns {
+		var b strings.Builder
+		last := 0
+		for _, m := range re.FindAllStringSubmatchIndex(p, -1) {
+			if len(m) == 2 {
+				m = append(m, m...) // no groups: redact the whole match
+			}
+			for i := 2; i+1 < len(m); i += 2 {
+				if m[i] < last || m[i] == m[i+1] {
+					continue // unmatched, empty, or nested in a redacted group
+				}
+				b.WriteString(p[last:m[i]])
+				b.WriteString(r.mask("REDACTED", p[m[i]:m[i+1]]))
+				last = m[i+1]
+			}
+		}
+		if last > 0 {
+			b.WriteString(p[last:])
+			p = b.String()
+		}
+	}
+	return p
+}
+
+// redactParam reports whether the value of the query parameter key
+// should be redacted.
+func (r *Redactor) redactParam(key string) bool {
+	for _, p := range r.SecretParams {
+		if strings.EqualFold(key, p) {
+			return true
+		}
+	}
+	if !r.RedactAllParams && len(r.ParamAllowlist) == 0 {
+		return false
+	}
+	for _, p := range r.ParamAllowlist {
+		if strings.EqualFold(key, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Header returns the values of the header key joined for display,
+// with any secrets redacted, or the empty string if the RedactFunc
+// drops the header.
+func (r *Redactor) Header(key string, values []string) string {
+	value, _ := r.header(key, values)
+	return value
+}
+
+// header returns the values of the header key joined for display, with
+// any secrets redacted, and whether the header is kept rather than
+// dropped by the RedactFunc.
+func (r *Redactor) header(key string, values []string) (string, bool) {
+	value := strings.Join(values, ", ")
+	if v, ok := r.custom(RedactHeader, key, value); ok {
+		return v, v != ""
+	}
+	return r.builtinHeader(key, values, value), true
+}
+
+// builtinHeader returns value, the joined values of the header key,
+// with any secrets redacted according to the built-in rules.
+func (r *Redactor) builtinHeader(key string, values []string, value string) string {
+	if strings.EqualFold(key, "Cookie") && len(r.SecretCookies) > 0 && r.headerAllowed(key) {
+		return strings.Join(r.redactCookies(values), ", ")
+	}
+	if r.isSecretHeader(key) {
+		if !r.RedactEntireJWT && !r.ShowJWTClaims && r.Placeholder == "" {
+			parts := strings.Split(value, ".")
+			if len(parts) == 3 {
+				return fmt.Sprintf("%v.%v.%v", parts[0], parts[1], r.mask("<REDACTED>", value))
+			}
+		}
+		return r.mask("<REDACTED>", value)
+	}
+
+	if !r.headerAllowed(key) {
+		return r.mask("<REDACTED>", value)
+	}
+	return value
+}
+
+// isSecretHeader reports whether the header key is one of the
+// SecretHeaders or contains the letters 'jwt'.
+func (r *Redactor) isSecretHeader(key string) bool {
+	if strings.Contains(strings.ToLower(key), "jwt") {
+		return true
+	}
+	for _, secret := range r.SecretHeaders {
+		if strings.EqualFold(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// Headers returns a copy of h with any secrets redacted.
+func (r *Redactor) Headers(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	result := make(http.Header, len(h))
+	for k, v := range h {
+		if value, ok := r.header(k, v); ok {
+			result[k] = []string{value}
+		}
+	}
+	return result
+}
+
+// headerAllowed reports whether the header key may be printed in the
+// clear according to the HeaderAllowlist.
+func (r *Redactor) headerAllowed(key string) bool {
+	if len(r.HeaderAllowlist) == 0 {
+		return true
+	}
+	for _, name := range r.HeaderAllowlist {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactCookies redacts the values of any SecretCookies found in
+// the provided 'Cookie' header values.
+func (r *Redactor) redactCookies(values []string) []string {
+	if len(r.SecretCookies) == 0 {
+		return values
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		cookies := strings.Split(value, ";")
+		for i, cookie := range cookies {
+			name, value, ok := strings.Cut(cookie, "=")
+			if !ok {
+				continue
+			}
+			for _, secret := range r.SecretCookies {
+				if strings.EqualFold(strings.TrimSpace(name), secret) {
+					cookies[i] = name + "=" + r.mask("<REDACTED>", value)
+					break
+				}
+			}
+		}
+		result = append(result, strings.Join(cookies, ";"))
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxRedirects is the number of redirects followed by a Client, the same
+// as the default of an http.Client.
+const maxRedirects = 10
+
+// RedirectError is returned (wrapped in a *url.Error) by requests made
+// with a Client that are stopped for redirecting too often, with a
+// diagnostic of the redirect chain logged alongside.
+type RedirectError struct {
+	// Chain holds the requests made so far, starting with the original
+	// one, as their methods and sanitized URLs, each followed by the
+	// status of its response, e.g. "GET https://example.com/a (302 Found)".
+	// The last is the redirected request that was not made.
+	Chain []string
+	// Loop reports whether the chain went round in a loop, rather than
+	// exceeding the limit of 10 redirects.
+	Loop bool
+}
+
+func (e *RedirectError) Error() string {
+	reason := fmt.Sprintf("stopped after %v redirects", maxRedirects)
+	if e.Loop {
+		reason = "redirect loop"
+	}
+	return "httpdebug: " + reason + ": " + strings.Join(e.Chain, " -> ")
+}
+
+// checkRedirect is the CheckRedirect of the Client. Like that of a plain
+// http.Client, it stops after 10 redirects, but it also stops once a URL
+// is about to be requested for the third time, so that a loop is caught
+// early while flows that return to a page once (e.g. after logging in)
+// still work. Either way it logs the chain and returns a RedirectError.
+func (t *CurlTransport) checkRedirect(req *http.Request, via []*http.Request) error {
+	visits := 0
+	for _, prev := range via {
+		if prev.Method == req.Method && prev.URL.String() == req.URL.String() {
+			visits++
+		}
+	}
+	loop := visits >= 2
+	if !loop && len(via) < maxRedirects {
+		return nil
+	}
+
+	requests := append(via[:len(via):len(via)], req)
+	err := &RedirectError{Loop: loop}
+	for i, r := range requests {
+		step := r.Method + " " + t.sanitizeURL(r.URL)
+		if i+1 < len(requests) && requests[i+1].Response != nil {
+			step += " (" + requests[i+1].Response.Status + ")"
+		}
+		err.Chain = append(err.Chain, step)
+	}
+
+	lines := []string{"# redirect loop: " + err.Chain[len(err.Chain)-1] + " would be requested a third time:"}
+	if !loop {
+		lines[0] = fmt.Sprintf("# too many redirects: stopped after %v:", maxRedirects)
+	}
+	for i, step := range err.Chain {
+		if i > 0 {
+			step = "-> " + step
+		}
+		lines = append(lines, "#   "+step)
+	}
+	t.log(strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,91 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestClient_Redirects(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b?client_secret=s3cr3t", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/a", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/n/", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/n/"))
+		http.Redirect(w, r, fmt.Sprintf("/n/%v", n+1), http.StatusFound)
+	})
+	loggedIn := false
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		if !loggedIn {
+			http.Redirect(w, r, "/login", http.StatusFound)
+		}
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		loggedIn = true
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client = New(WithVerbosity(0), WithTransport(&http.Transport{})).Client()
+
+	t.Run("loop", func(t *testing.T) {
+		logged = nil
+		_, err := client.Get(url + "/a")
+		var redirectErr *RedirectError
+		if !errors.As(err, &redirectErr) || !redirectErr.Loop {
+			t.Fatalf("client.Get = %v, want a redirect loop", err)
+		}
+		want := []string{
+			"GET " + url + "/a (302 Found)",
+			"GET " + url + "/b?client_secret=REDACTED (301 Moved Permanently)",
+			"GET " + url + "/a (302 Found)",
+			"GET " + url + "/b?client_secret=REDACTED (301 Moved Permanently)",
+			"GET " + url + "/a",
+		}
+		if got := strings.Join(redirectErr.Chain, "\n"); got != strings.Join(want, "\n") {
+			t.Errorf("Chain =\n%v\nwant:\n%v", got, strings.Join(want, "\n"))
+		}
+		diagnostic := logged[len(logged)-1]
+		if !strings.HasPrefix(diagnostic, "# redirect loop: GET "+url+"/a would be requested a third time:\n#   GET "+url+"/a (302 Found)\n#   -> GET ") {
+			t.Errorf("diagnostic = %q, want the chain", diagnostic)
+		}
+		if strings.Contains(err.Error(), "s3cr3t") || strings.Contains(diagnostic, "s3cr3t") {
+			t.Errorf("error %q or diagnostic %q contains the secret", err, diagnostic)
+		}
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		logged = nil
+		_, err := client.Get(url + "/n/0")
+		var redirectErr *RedirectError
+		if !errors.As(err, &redirectErr) || redirectErr.Loop || len(redirectErr.Chain) != 11 {
+			t.Fatalf("client.Get = %v, want too many redirects", err)
+		}
+		if !strings.Contains(err.Error(), "httpdebug: stopped after 10 redirects: GET "+url+"/n/0 (302 Found) -> ") {
+			t.Errorf("client.Get = %v, want the chain", err)
+		}
+		if !strings.HasPrefix(logged[len(logged)-1], "# too many redirects: stopped after 10:\n") {
+			t.Errorf("diagnostic = %q", logged[len(logged)-1])
+		}
+	})
+
+	t.Run("revisit", func(t *testing.T) {
+		resp, err := client.Get(url + "/home")
+		if err != nil {
+			t.Fatalf("client.Get = %v, want the login flow to succeed", err)
+		}
+		resp.Body.Close()
+	})
+}
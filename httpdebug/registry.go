@@ -0,0 +1,56 @@
+package httpdebug
+
+import "sync"
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*CurlTransport{}
+)
+
+// WithName is a CurlTransportOption that names the transport (e.g. "stripe")
+// and adds it to the package-level registry, so that a process with
+// several transports can address each of them individually.
+// See Transports and Lookup.
+func WithName(name string) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Name = name
+	}
+}
+
+// Transports returns the named transports in the process, keyed by name.
+// If several transports were created with the same name, the most recent
+// one is returned.
+func Transports() map[string]*CurlTransport {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	result := make(map[string]*CurlTransport, len(registry))
+	for name, t := range registry {
+		result[name] = t
+	}
+	return result
+}
+
+// Lookup returns the named transport, if any.
+func Lookup(name string) (*CurlTransport, bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	t, ok := registry[name]
+	return t, ok
+}
+
+// registerTransport adds t to the registry under its Name.
+func registerTransport(t *CurlTransport) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[t.Name] = t
+}
+
+// unregisterTransport removes t from the registry, unless another
+// transport has since been registered under the same name.
+func unregisterTransport(t *CurlTransport) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registry[t.Name] == t {
+		delete(registry, t.Name)
+	}
+}
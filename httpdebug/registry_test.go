@@ -0,0 +1,46 @@
+package httpdebug
+
+import "testing"
+
+func TestWithName(t *testing.T) {
+	stripe := New(WithName("stripe"))
+	defer stripe.Close()
+	github := New(WithName("github"))
+	defer github.Close()
+	anonymous := New()
+	defer anonymous.Close()
+
+	if stripe.Name != "stripe" {
+		t.Errorf("Name = %q, want %q", stripe.Name, "stripe")
+	}
+
+	got := Transports()
+	if len(got) != 2 || got["stripe"] != stripe || got["github"] != github {
+		t.Errorf("Transports = %v, want stripe and github", got)
+	}
+	if ct, ok := Lookup("stripe"); !ok || ct != stripe {
+		t.Errorf("Lookup(stripe) = (%p, %v), want (%p, true)", ct, ok, stripe)
+	}
+	if _, ok := Lookup("missing"); ok {
+		t.Error("Lookup(missing) = true, want false")
+	}
+
+	stripe.Close()
+	if _, ok := Lookup("stripe"); ok {
+		t.Error("Lookup(stripe) after Close = true, want false")
+	}
+}
+
+func TestWithName_Replaced(t *testing.T) {
+	old := New(WithName("api"))
+	replacement := New(WithName("api"))
+	defer replacement.Close()
+
+	if ct, _ := Lookup("api"); ct != replacement {
+		t.Errorf("Lookup(api) = %p, want most recent transport %p", ct, replacement)
+	}
+	old.Close()
+	if ct, _ := Lookup("api"); ct != replacement {
+		t.Errorf("Lookup(api) after closing old transport = %p, want %p", ct, replacement)
+	}
+}
@@ -0,0 +1,29 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WithRequestID is a CurlTransportOption that assigns each request an ID
+// generated by gen. The ID precedes the request's curl output as a
+// "# request <id>" comment line and labels the lines logged about its
+// response, so that the output of concurrent requests can be matched up.
+// A nil gen is ignored.
+func WithRequestID(gen func() string) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if gen != nil {
+			ct.RequestID = gen
+		}
+	}
+}
+
+// responseSummary returns a one-line summary of the outcome of the
+// request with the provided label (see Entry.label).
+func (t *CurlTransport) responseSummary(label string, resp *http.Response, err error, elapsed time.Duration) string {
+	if err != nil {
+		return fmt.Sprintf("# response%v: error after %v: %v", label, t.duration(elapsed), err)
+	}
+	return fmt.Sprintf("# response%v: %v in %v", label, resp.Status, t.duration(elapsed))
+}
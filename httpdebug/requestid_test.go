@@ -0,0 +1,80 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithRequestID(t *testing.T) {
+	if ct := New(WithRequestID(nil)); ct.RequestID != nil {
+		t.Error("WithRequestID(nil) set RequestID, want nil")
+	}
+	if ct := New(WithRequestID(func() string { return "x" })); ct.RequestID == nil {
+		t.Error("WithRequestID did not set RequestID")
+	}
+}
+
+// sequentialIDs returns a generator of the IDs "req-1", "req-2", ...
+func sequentialIDs() func() string {
+	var n int
+	return func() string {
+		n++
+		return fmt.Sprintf("req-%v", n)
+	}
+}
+
+func TestRoundTrip_RequestID(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithRequestID(sequentialIDs()), WithServerTiming())
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(logged) != 6 {
+		t.Fatalf("logged = %#v, want 6 lines", logged)
+	}
+	for i, id := range []string{"req-1", "req-2"} {
+		lines := logged[3*i : 3*i+3]
+		if want := "# request " + id + "\ncurl \\\n"; !strings.HasPrefix(lines[0], want) {
+			t.Errorf("request line = %q, want prefix %q", lines[0], want)
+		}
+		if want := "# timing " + id + ": client="; !strings.HasPrefix(lines[1], want) {
+			t.Errorf("timing line = %q, want prefix %q", lines[1], want)
+		}
+		if want := "# response " + id + ": 200 OK in "; !strings.HasPrefix(lines[2], want) {
+			t.Errorf("response line = %q, want prefix %q", lines[2], want)
+		}
+	}
+}
+
+func TestRoundTrip_RequestIDError(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithRequestID(sequentialIDs()), WithTransport(errTransport{err: errors.New("boom")}))
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	if _, err := ct.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip = nil error, want error")
+	}
+
+	if len(logged) != 2 || !strings.HasPrefix(logged[1], "# response req-1: error after ") || !strings.HasSuffix(logged[1], ": boom") {
+		t.Errorf("logged = %#v, want curl command followed by error summary", logged)
+	}
+}
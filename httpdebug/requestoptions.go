@@ -0,0 +1,85 @@
+package httpdebug
+
+import (
+	"context"
+	"reflect"
+)
+
+// requestOptionsKey is the context key set by WithRequestOptions.
+type requestOptionsKey struct{}
+
+// WithRequestOptions returns a copy of ctx that causes any CurlTransport
+// handling a request made with it to apply opts on top of its own
+// configuration for just that request, e.g. to dump one call with a
+// different verbosity, placeholder, or set of secrets:
+//
+//	ctx = httpdebug.WithRequestOptions(ctx, httpdebug.WithVerbosity(3))
+//	req = req.WithContext(ctx)
+//
+// The options are applied when the request is dumped. Options already in
+// ctx are kept, and opts are applied after them. State such as sequence
+// numbers, sampling, and statistics stays shared with the transport.
+func WithRequestOptions(ctx context.Context, opts ...CurlTransportOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	prev := requestOptions(ctx)
+	all := make([]CurlTransportOption, 0, len(prev)+len(opts))
+	all = append(append(all, prev...), opts...)
+	return context.WithValue(ctx, requestOptionsKey{}, all)
+}
+
+// requestOptions returns the options set in ctx by WithRequestOptions.
+func requestOptions(ctx context.Context) []CurlTransportOption {
+	opts, _ := ctx.Value(requestOptionsKey{}).([]CurlTransportOption)
+	return opts
+}
+
+// withOptions returns a copy of the configuration of t with opts applied
+// that shares the state of t (see root).
+func (t *CurlTransport) withOptions(opts []CurlTransportOption) *CurlTransport {
+	c := &CurlTransport{parent: t.root()}
+	t.copyConfig(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// copyConfig sets the exported fields of c to those of t. Slices are
+// capped and maps copied, so that options applied to c cannot modify
+// the configuration of t.
+func (t *CurlTransport) copyConfig(c *CurlTransport) {
+	src, dst := reflect.ValueOf(t).Elem(), reflect.ValueOf(c).Elem()
+	t.listMu.RLock()
+	defer t.listMu.RUnlock()
+	for i := 0; i < src.NumField(); i++ {
+		if !src.Type().Field(i).IsExported() {
+			continue
+		}
+		v := src.Field(i)
+		switch v.Kind() {
+		case reflect.Slice:
+			// Make appending by opts copy rather than modify t's slice.
+			v = v.Slice3(0, v.Len(), v.Len())
+		case reflect.Map:
+			if !v.IsNil() {
+				m := reflect.MakeMapWithSize(v.Type(), v.Len())
+				for iter := v.MapRange(); iter.Next(); {
+					m.SetMapIndex(iter.Key(), iter.Value())
+				}
+				v = m
+			}
+		}
+		dst.Field(i).Set(v)
+	}
+}
+
+// root returns the transport that holds the state of t: t itself, or
+// the transport it was derived from by withOptions.
+func (t *CurlTransport) root() *CurlTransport {
+	if t.parent != nil {
+		return t.parent
+	}
+	return t
+}
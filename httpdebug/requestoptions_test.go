@@ -0,0 +1,90 @@
+package httpdebug
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithRequestOptions(t *testing.T) {
+	if ctx := context.Background(); WithRequestOptions(ctx) != ctx {
+		t.Error("WithRequestOptions without options returned a new context")
+	}
+
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithSequence())
+	client.Transport = ct
+
+	get := func(ctx context.Context) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		req.Header.Set("X-Secret", "s3cr3t")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("client.Do = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	ctx := WithRequestOptions(context.Background(), WithSecretHeader("X-Secret"))
+	ctx = WithRequestOptions(ctx, WithVerbosity(0))
+	get(ctx)
+	get(context.Background())
+
+	if len(logged) != 3 {
+		t.Fatalf("logged = %#v, want 3 lines", logged)
+	}
+	if want := "#0001 GET " + url; logged[0] != want {
+		t.Errorf("logged[0] = %q, want %q", logged[0], want)
+	}
+	if want := "# request #0002\ncurl \\\n  " + url + " \\\n  -H 'X-Secret: s3cr3t'"; logged[1] != want {
+		t.Errorf("logged[1] = %q, want %q", logged[1], want)
+	}
+	if !strings.HasPrefix(logged[2], "# response #0002: 200 OK") {
+		t.Errorf("logged[2] = %q, want the response summary", logged[2])
+	}
+
+	if want := defaultSecretHeaders(); !reflect.DeepEqual(ct.SecretHeaders, want) {
+		t.Errorf("SecretHeaders = %q, want %q", ct.SecretHeaders, want)
+	}
+	if ct.Verbosity != VerbosityCurl {
+		t.Errorf("Verbosity = %v, want %v", ct.Verbosity, VerbosityCurl)
+	}
+}
+
+func TestCurlTransport_withOptions(t *testing.T) {
+	parent := New(WithReplaceSecretHeaders("authorization", "X-One"))
+	parent.SecretHeaders = parent.SecretHeaders[:1:2] // leave room to append in place
+	c := parent.withOptions([]CurlTransportOption{WithSecretHeader("X-Two")})
+	if c.root() != parent {
+		t.Error("withOptions did not share the parent's state")
+	}
+	if want := []string{"authorization", "X-Two"}; !reflect.DeepEqual(c.SecretHeaders, want) {
+		t.Errorf("SecretHeaders = %q, want %q", c.SecretHeaders, want)
+	}
+	if got := parent.SecretHeaders[:2]; got[1] != "X-One" {
+		t.Errorf("withOptions modified the parent's SecretHeaders: %q", got)
+	}
+
+	parent.BodyDecoders = map[string]BodyDecoder{"application/x-a": decodeFields}
+	c = parent.withOptions([]CurlTransportOption{WithBodyDecoder("application/x-b", decodeFields)})
+	if len(c.BodyDecoders) != 2 || len(parent.BodyDecoders) != 1 {
+		t.Errorf("BodyDecoders = %v, parent's = %v, want 2 and 1 decoders", c.BodyDecoders, parent.BodyDecoders)
+	}
+	if d := c.withOptions([]CurlTransportOption{WithSequence()}); d.root() != parent {
+		t.Error("withOptions of a derived transport did not share the root's state")
+	}
+}
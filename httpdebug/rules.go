@@ -0,0 +1,226 @@
+package httpdebug
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rulesFile is the form of the redaction rules in a rules file.
+type rulesFile struct {
+	RedactEntireJWT  bool     `yaml:"redact_entire_jwt"`
+	ShowJWTClaims    bool     `yaml:"show_jwt_claims"`
+	SecretHeaders    []string `yaml:"secret_headers"`
+	HeaderAllowlist  []string `yaml:"header_allowlist"`
+	SecretCookies    []string `yaml:"secret_cookies"`
+	SecretParams     []string `yaml:"secret_params"`
+	ParamAllowlist   []string `yaml:"param_allowlist"`
+	RedactAllParams  bool     `yaml:"redact_all_params"`
+	SecretBodyFields []string `yaml:"secret_body_fields"`
+	KeepUsername     bool     `yaml:"keep_username"`
+	Placeholder      string   `yaml:"placeholder"`
+	HashSecrets      bool     `yaml:"hash_secrets"`
+
+	SecretPathPatterns []string `yaml:"secret_path_patterns"`
+	pathPatterns       []*regexp.Regexp
+}
+
+// defaultRules returns the redaction rules of a transport created by
+// New without options.
+func defaultRules() *Redactor {
+	return &Redactor{
+		SecretHeaders: slices.Clone(DefaultSecretHeaders),
+		SecretParams:  slices.Clone(DefaultSecretParams),
+	}
+}
+
+// ReadRules reads the redaction rules in the YAML (or JSON) file at
+// path. See ParseRules.
+func ReadRules(path string) (*Redactor, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseRules(buf)
+	if err != nil {
+		return nil, fmt.Errorf("httpdebug: rules %v: %w", path, err)
+	}
+	return r, nil
+}
+
+// ParseRules returns a Redactor applying the redaction rules in buf, a
+// YAML (or JSON) document whose keys are the Redactor fields in
+// snake_case, e.g.
+//
+//	secret_headers: [X-Api-Key]
+//	secret_cookies: [session]
+//	secret_params: [api_key, sig]
+//	secret_body_fields: [password, ssn]
+//	secret_path_patterns: ['^/webhooks/([^/]+)/']
+//	redact_entire_jwt: true
+//
+// The lists extend the defaults of New, like the corresponding
+// options. Unknown keys are an error, so that a misspelled rule cannot
+// silently leave secrets in the clear.
+func ParseRules(buf []byte) (*Redactor, error) {
+	f, err := parseRulesFile(buf)
+	if err != nil {
+		return nil, err
+	}
+	r := defaultRules()
+	f.apply(r)
+	r.Placeholder = f.Placeholder
+	return r, nil
+}
+
+// parseRulesFile returns the redaction rules in buf.
+func parseRulesFile(buf []byte) (*rulesFile, error) {
+	var f rulesFile
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for _, expr := range f.SecretPathPatterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("secret_path_patterns: %w", err)
+		}
+		f.pathPatterns = append(f.pathPatterns, re)
+	}
+	return &f, nil
+}
+
+// readRulesFile returns the redaction rules in the file at path.
+func readRulesFile(path string) (*rulesFile, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseRulesFile(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	return f, nil
+}
+
+// apply adds the rules of f to those of r.
+func (f *rulesFile) apply(r *Redactor) {
+	r.RedactEntireJWT = r.RedactEntireJWT || f.RedactEntireJWT
+	r.ShowJWTClaims = r.ShowJWTClaims || f.ShowJWTClaims
+	r.SecretHeaders = concatNonEmpty(r.SecretHeaders, f.SecretHeaders)
+	r.HeaderAllowlist = concatNonEmpty(r.HeaderAllowlist, f.HeaderAllowlist)
+	r.SecretCookies = concatNonEmpty(r.SecretCookies, f.SecretCookies)
+	r.SecretParams = concatNonEmpty(r.SecretParams, f.SecretParams)
+	r.ParamAllowlist = concatNonEmpty(r.ParamAllowlist, f.ParamAllowlist)
+	r.RedactAllParams = r.RedactAllParams || f.RedactAllParams
+	r.SecretBodyFields = concatNonEmpty(r.SecretBodyFields, f.SecretBodyFields)
+	r.KeepUsername = r.KeepUsername || f.KeepUsername
+	r.HashSecrets = r.HashSecrets || f.HashSecrets
+	if len(f.pathPatterns) > 0 {
+		r.SecretPathPatterns = slices.Concat(r.SecretPathPatterns, f.pathPatterns)
+	}
+}
+
+// concatNonEmpty returns a new slice of the names in a followed by the
+// non-empty names in b, or a itself if b has none.
+func concatNonEmpty(a, b []string) []string {
+	result := a
+	for _, name := range b {
+		if name == "" {
+			continue
+		}
+		if len(result) == len(a) {
+			result = slices.Clone(a)
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// loadedRules are the rules of a RulesFile as last loaded.
+type loadedRules struct {
+	file *rulesFile // nil if the file has never been loaded
+	err  error      // why the last load failed, if it did
+}
+
+// WithRulesFile is a CurlTransportOption that adds the redaction rules
+// in the YAML (or JSON) file at path, in the form read by ParseRules, to
+// those of the transport, so that one redaction policy maintained by a
+// security team can be shared across services instead of being repeated
+// in option calls. Its lists extend those set by other options and its
+// flags turn the corresponding settings on; a placeholder only applies
+// to scrubbed archives. The file can be reloaded with ReloadRules. A file
+// that cannot be read or parsed is logged by New and reported by
+// Validate.
+func WithRulesFile(path string) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.RulesFile = path
+		f, err := readRulesFile(path)
+		ct.fileRules.Store(&loadedRules{file: f, err: err})
+	}
+}
+
+// ReloadRules re-reads the RulesFile, so that updates to a shared
+// redaction policy take effect in a running service without a restart,
+// e.g. on SIGHUP or from ReloadRulesHandler. It is safe to call
+// concurrently with requests. If the file cannot be read or parsed, the
+// rules loaded before stay in effect, and the error is returned and
+// reported by Validate.
+func (t *CurlTransport) ReloadRules() error {
+	if t.RulesFile == "" {
+		return errors.New("httpdebug: no RulesFile to reload")
+	}
+	f, err := readRulesFile(t.RulesFile)
+	if err != nil {
+		var prev *rulesFile
+		if l := t.fileRules.Load(); l != nil {
+			prev = l.file
+		}
+		t.fileRules.Store(&loadedRules{file: prev, err: err})
+		return fmt.Errorf("httpdebug: rules: %w", err)
+	}
+	t.fileRules.Store(&loadedRules{file: f})
+	return nil
+}
+
+// ReloadRulesHandler returns an http.Handler that calls ReloadRules for
+// a POST request, so that policy updates can be applied from an admin
+// endpoint:
+//
+//	http.Handle("/debug/httpdebug/reload-rules", ct.ReloadRulesHandler())
+//	$ curl -X POST localhost:6060/debug/httpdebug/reload-rules
+//
+// It responds with an Internal Server Error if the rules could not be
+// reloaded.
+func (t *CurlTransport) ReloadRulesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := t.ReloadRules(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "reloaded", t.RulesFile)
+	})
+}
+
+// loadedRules returns the rules last loaded from the RulesFile given to
+// t, or to the transport it was derived from, or nil if there are none.
+func (t *CurlTransport) loadedRules() *loadedRules {
+	if l := t.fileRules.Load(); l != nil {
+		return l
+	}
+	return t.root().fileRules.Load()
+}
@@ -0,0 +1,218 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	rules := `# Rules for sharing the staging archives.
+secret_headers: [X-Api-Key]
+secret_cookies:
+  - session
+secret_params: [api_key]
+secret_body_fields: [password]
+redact_entire_jwt: true
+placeholder: dummy
+`
+	if err := os.WriteFile(path, []byte(rules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadRules(path)
+	if err != nil {
+		t.Fatalf("ReadRules = %v", err)
+	}
+	want := &Redactor{
+		RedactEntireJWT:  true,
+		SecretHeaders:    defaultSecretHeaders("X-Api-Key"),
+		SecretCookies:    []string{"session"},
+		SecretParams:     defaultSecretParams("api_key"),
+		SecretBodyFields: []string{"password"},
+		Placeholder:      "dummy",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadRules =\n%#v\nwant:\n%#v", got, want)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	got, err := ParseRules(nil)
+	if err != nil {
+		t.Fatalf("ParseRules(nil) = %v", err)
+	}
+	if want := New().Redactor(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRules(nil) = %#v, want the defaults %#v", got, want)
+	}
+
+	got, err = ParseRules([]byte(`{"secret_params": ["sig"], "redact_all_params": true}`))
+	if err != nil {
+		t.Fatalf("ParseRules(JSON) = %v", err)
+	}
+	if want := defaultSecretParams("sig"); !reflect.DeepEqual(got.SecretParams, want) || !got.RedactAllParams {
+		t.Errorf("ParseRules(JSON) = %#v, want SecretParams %q and RedactAllParams", got, want)
+	}
+
+	got, err = ParseRules([]byte("secret_path_patterns: ['^/webhooks/([^/]+)']\n"))
+	if err != nil {
+		t.Fatalf("ParseRules(secret_path_patterns) = %v", err)
+	}
+	if u, _ := url.Parse("https://x/webhooks/s3cr3t/run"); got.URL(u) != "https://x/webhooks/REDACTED/run" {
+		t.Errorf("URL = %v, want its webhook secret redacted", got.URL(u))
+	}
+	if _, err := ParseRules([]byte("secret_path_patterns: ['(']\n")); err == nil || !strings.Contains(err.Error(), "secret_path_patterns") {
+		t.Errorf("ParseRules(invalid pattern) = %v, want an error", err)
+	}
+
+	if _, err := ParseRules([]byte("secret_header: [X-Api-Key]\n")); err == nil || !strings.Contains(err.Error(), "secret_header") {
+		t.Errorf("ParseRules(misspelled) = %v, want an error naming the unknown key", err)
+	}
+}
+
+func TestWithRulesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	rules := `{"secret_headers": ["X-Tenant-Key"], "secret_params": ["sas"], "secret_cookies": ["sid"], "secret_body_fields": ["pin"], "redact_entire_jwt": true}`
+	if err := os.WriteFile(path, []byte(rules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := New(WithSecretHeader("X-One"), WithRulesFile(path))
+	want := &Redactor{
+		RedactEntireJWT:  true,
+		SecretHeaders:    defaultSecretHeaders("X-One", "X-Tenant-Key"),
+		SecretCookies:    []string{"sid"},
+		SecretParams:     defaultSecretParams("sas"),
+		SecretBodyFields: []string{"pin"},
+	}
+	if got := ct.Redactor(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Redactor =\n%+v\nwant:\n%+v", got, want)
+	}
+	if want := defaultSecretHeaders("X-One"); !reflect.DeepEqual(ct.SecretHeaders, want) || ct.RulesFile != path {
+		t.Errorf("SecretHeaders = %q, RulesFile = %q, want %q and %q", ct.SecretHeaders, ct.RulesFile, want, path)
+	}
+	if err := ct.Validate(); err != nil || len(logged) != 0 {
+		t.Errorf("Validate = %v, logged = %q, want neither", err, logged)
+	}
+
+	for _, tt := range []struct {
+		name, contents, want string
+	}{
+		{name: "missing", want: "no such file"},
+		{name: "invalid", contents: "secret_headers: X-Api-Key: 1\n", want: "rules "},
+		{name: "unknown key", contents: "secret_header: [X-Api-Key]\n", want: "field secret_header not found"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			logged = nil
+			path := filepath.Join(t.TempDir(), "rules.yaml")
+			if tt.contents != "" {
+				if err := os.WriteFile(path, []byte(tt.contents), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ct := New(WithRulesFile(path))
+			if got := ct.Redactor().SecretHeaders; !reflect.DeepEqual(got, DefaultSecretHeaders) {
+				t.Errorf("SecretHeaders = %q, want the defaults", got)
+			}
+			if len(logged) != 1 || !strings.HasPrefix(logged[0], "# httpdebug: ") || !strings.Contains(logged[0], tt.want) {
+				t.Errorf("logged = %q, want a line containing %q", logged, tt.want)
+			}
+			err := ct.Validate()
+			if !errors.Is(err, ErrInvalidOption) || !strings.Contains(fmt.Sprint(err), "RulesFile not applied: ") {
+				t.Errorf("Validate = %v, want RulesFile not applied", err)
+			}
+		})
+	}
+}
+
+func TestCurlTransport_ReloadRules(t *testing.T) {
+	if err := New().ReloadRules(); err == nil {
+		t.Error("ReloadRules without a RulesFile = nil, want an error")
+	}
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	write := func(rules string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(rules), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("secret_headers: [X-One]\n")
+	ct := New(WithRulesFile(path))
+	header := func(key string) string { return ct.redactHeader(key, []string{"v"}) }
+	if header("X-One") != "<REDACTED>" || header("X-Two") != "v" {
+		t.Fatalf("X-One = %q, X-Two = %q, want only X-One redacted", header("X-One"), header("X-Two"))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			header("X-Two")
+		}
+	}()
+	write("secret_headers: [X-Two]\n")
+	if err := ct.ReloadRules(); err != nil {
+		t.Fatalf("ReloadRules = %v", err)
+	}
+	<-done
+	if header("X-One") != "v" || header("X-Two") != "<REDACTED>" {
+		t.Errorf("after reload X-One = %q, X-Two = %q, want only X-Two redacted", header("X-One"), header("X-Two"))
+	}
+
+	write("secret_headers: [\n")
+	if err := ct.ReloadRules(); err == nil || !strings.HasPrefix(err.Error(), "httpdebug: rules: "+path) {
+		t.Errorf("ReloadRules = %v, want a parse error", err)
+	}
+	if header("X-Two") != "<REDACTED>" {
+		t.Errorf("X-Two = %q after a failed reload, want the previous rules kept", header("X-Two"))
+	}
+	if err := ct.Validate(); !strings.Contains(fmt.Sprint(err), "RulesFile not reloaded: ") {
+		t.Errorf("Validate = %v, want RulesFile not reloaded", err)
+	}
+	write("{}")
+	if err := ct.ReloadRules(); err != nil || ct.Validate() != nil {
+		t.Errorf("ReloadRules = %v, Validate = %v, want nil", err, ct.Validate())
+	}
+}
+
+func TestReloadRulesHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte("secret_params: [p]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ct := New(WithRulesFile(path))
+	h := ct.ReloadRulesHandler()
+
+	for _, tt := range []struct {
+		method string
+		want   int
+	}{
+		{"GET", http.StatusMethodNotAllowed},
+		{"POST", http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, "/reload-rules", nil))
+		if w.Code != tt.want {
+			t.Errorf("%v = %v %q, want %v", tt.method, w.Code, w.Body, tt.want)
+		}
+	}
+
+	os.Remove(path)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/reload-rules", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "no such file") {
+		t.Errorf("POST with a missing file = %v %q, want an Internal Server Error", w.Code, w.Body)
+	}
+}
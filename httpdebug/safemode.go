@@ -0,0 +1,115 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrBlocked is returned by RoundTrip in safe mode (see WithSafeMode) for
+// requests that match none of the allowed URL patterns.
+var ErrBlocked = errors.New("httpdebug: request blocked by safe mode")
+
+// WithSafeMode is a CurlTransportOption that lets through only requests
+// matching one of the given URL patterns, as a safety net when running
+// unfamiliar code against production credentials. Every other request is
+// dumped, with a "# blocked" line, and fails with ErrBlocked without ever
+// being sent. This applies even while the transport is disabled. Without
+// any patterns, every request is blocked.
+//
+// A pattern has the form "[METHOD ]HOST[PATH]", e.g.
+// "GET api.github.com/repos/". HOST, which includes the port only if the
+// pattern's does, and each segment of PATH are matched with path.Match,
+// so "*.example.com" and "/users/*/orgs" work as expected. A PATH ending
+// in "/" matches everything below it and an empty PATH matches any path.
+func WithSafeMode(patterns ...string) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.SafeMode = true
+		ct.SafeModeAllowlist = append(ct.SafeModeAllowlist, patterns...)
+	}
+}
+
+// allowed reports whether req matches one of the SafeModeAllowlist.
+func (t *CurlTransport) allowed(req *http.Request) bool {
+	for _, pattern := range t.list(&t.SafeModeAllowlist) {
+		if matchURLPattern(pattern, req) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchURLPattern reports whether req matches pattern (see WithSafeMode).
+func matchURLPattern(pattern string, req *http.Request) bool {
+	if method, rest, ok := strings.Cut(pattern, " "); ok {
+		if method != req.Method {
+			return false
+		}
+		pattern = strings.TrimSpace(rest)
+	}
+
+	hostPattern, pathPattern := pattern, ""
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		hostPattern, pathPattern = pattern[:i], pattern[i:]
+	}
+	host := req.URL.Host
+	if !strings.Contains(hostPattern, ":") {
+		host = req.URL.Hostname()
+	}
+	if ok, _ := path.Match(strings.ToLower(hostPattern), strings.ToLower(host)); !ok {
+		return false
+	}
+	if pathPattern == "" {
+		return true
+	}
+
+	reqPath := req.URL.Path
+	if reqPath == "" {
+		reqPath = "/"
+	}
+	if strings.HasSuffix(pathPattern, "/") {
+		// Match the leading segments of the request path against all
+		// but the empty segment after the trailing slash.
+		n := strings.Count(pathPattern, "/")
+		segments := strings.SplitAfterN(reqPath, "/", n+1)
+		if len(segments) <= n {
+			return false
+		}
+		reqPath = strings.Join(segments[:n], "")
+	}
+	ok, _ := path.Match(pathPattern, reqPath)
+	return ok
+}
+
+// block dumps req, which safe mode does not allow, and returns the error
+// that RoundTrip returns for it instead of sending it.
+func (t *CurlTransport) block(req *http.Request) error {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	sanitizedURL := t.sanitizeURL(req.URL)
+	err := fmt.Errorf("%w: %v %v", ErrBlocked, req.Method, sanitizedURL)
+
+	s, dumpErr := t.dumpRequestAsCurl(req)
+	if dumpErr != nil {
+		return errors.Join(err, &DumpError{Err: dumpErr})
+	}
+	entry := &Entry{
+		Time:    time.Now(),
+		Attempt: attempt(req.Context()),
+		Method:  req.Method,
+		URL:     sanitizedURL,
+		Curl:    s,
+	}
+	out := t.newOutput()
+	defer out.flush()
+	t.writeEntry(out, entry)
+	out.log(fmt.Sprintf("# blocked%v: not allowed by safe mode", entry.label()))
+	if t.Capture != nil {
+		t.capture(entry, req, requestBody{}, nil, err, 0)
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMatchURLPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		method  string
+		url     string
+		want    bool
+	}{
+		{pattern: "api.example.com", method: "DELETE", url: "https://api.example.com/anything", want: true},
+		{pattern: "api.example.com", method: "GET", url: "https://API.example.com:8443/", want: true},
+		{pattern: "api.example.com:8443", method: "GET", url: "https://api.example.com/", want: false},
+		{pattern: "api.example.com:8443/", method: "GET", url: "https://api.example.com:8443/", want: true},
+		{pattern: "*.example.com", method: "GET", url: "https://a.example.com/", want: true},
+		{pattern: "*.example.com", method: "GET", url: "https://example.com/", want: false},
+		{pattern: "GET api.example.com/repos/", method: "GET", url: "https://api.example.com/repos/a/b", want: true},
+		{pattern: "GET api.example.com/repos/", method: "GET", url: "https://api.example.com/repos/", want: true},
+		{pattern: "GET api.example.com/repos/", method: "GET", url: "https://api.example.com/repos", want: false},
+		{pattern: "GET api.example.com/repos/", method: "POST", url: "https://api.example.com/repos/a", want: false},
+		{pattern: "api.example.com/users/*/orgs", method: "GET", url: "https://api.example.com/users/bob/orgs", want: true},
+		{pattern: "api.example.com/users/*/orgs", method: "GET", url: "https://api.example.com/users/bob/orgs/1", want: false},
+		{pattern: "api.example.com/", method: "GET", url: "https://api.example.com", want: true},
+		{pattern: "other.example.com", method: "GET", url: "https://api.example.com/", want: false},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, tt.url, nil)
+		if got := matchURLPattern(tt.pattern, req); got != tt.want {
+			t.Errorf("matchURLPattern(%q, %v %v) = %v, want %v", tt.pattern, tt.method, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestWithSafeMode(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	var served []string
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		served = append(served, r.Method+" "+r.URL.Path)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	host := strings.TrimPrefix(url, "http://")
+	ct := New(WithSafeMode("GET "+host+"/read/"), WithCapture(10))
+	ct.SetEnabled(false)
+	client.Transport = ct
+
+	resp, err := client.Get(url + "/read/1")
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	_, err = client.Post(url+"/write?client_secret=abc", "application/json", strings.NewReader(`{"a":1}`))
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("client.Post = %v, want ErrBlocked", err)
+	}
+	if want := "POST " + url + "/write?client_secret=REDACTED"; !strings.Contains(err.Error(), want) {
+		t.Errorf("client.Post = %v, want it to mention %q", err, want)
+	}
+
+	if want := []string{"GET /read/1"}; fmt.Sprint(served) != fmt.Sprint(want) {
+		t.Errorf("served = %q, want %q", served, want)
+	}
+	want := "curl -X POST \\\n  '" + url + "/write?client_secret=REDACTED' \\\n  -H 'Content-Type: application/json' \\\n  --data-raw '{\"a\":1}'"
+	if len(logged) != 2 || logged[0] != want || logged[1] != "# blocked: not allowed by safe mode" {
+		t.Errorf("logged = %#v, want the blocked request", logged)
+	}
+	if x, ok := ct.Last(); !ok || !strings.Contains(x.Err, "blocked by safe mode") {
+		t.Errorf("Last = %#v, want the blocked request", x)
+	}
+}
+
+func TestWithSafeMode_BlockAll(t *testing.T) {
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	ct := New(WithSafeMode(), WithTransport(errTransport{err: errors.New("sent")}))
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	if _, err := ct.RoundTrip(req); !errors.Is(err, ErrBlocked) {
+		t.Errorf("RoundTrip = %v, want ErrBlocked", err)
+	}
+}
@@ -0,0 +1,42 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// safeRedactor redacts every query parameter value and any userinfo.
+var safeRedactor = &Redactor{RedactAllParams: true}
+
+// SafeString returns a single-line, fully redacted summary of req that
+// is suitable for embedding in arbitrary error messages, for example:
+//
+//	POST https://api.example.com/v1/items?page=REDACTED [headers: Accept, Authorization] [body: 42 bytes]
+//
+// Every query parameter value and any userinfo in the URL is redacted,
+// only the names of the headers are included, and the body is neither
+// read nor included. Use it instead of formatting requests with %v.
+func SafeString(req *http.Request) string {
+	if req == nil {
+		return "<nil>"
+	}
+
+	s := fmt.Sprintf("%v %v", req.Method, safeRedactor.URL(req.URL))
+	if len(req.Header) > 0 {
+		keys := make([]string, 0, len(req.Header))
+		for k := range req.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		s += fmt.Sprintf(" [headers: %v]", strings.Join(keys, ", "))
+	}
+	switch {
+	case req.ContentLength > 0:
+		s += fmt.Sprintf(" [body: %v bytes]", req.ContentLength)
+	case req.ContentLength < 0 || (req.Body != nil && req.Body != http.NoBody):
+		s += " [body: unknown length]"
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+package httpdebug
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sampleRand is used strictly for test purposes.
+var sampleRand = rand.Float64
+
+// WithSampleRate is a CurlTransportOption that dumps only the given
+// fraction (between 0 and 1) of requests, chosen at random, so that the
+// transport can stay enabled in a service making many requests per second.
+// Requests that are not sampled are passed straight through, as if the
+// transport were disabled (but see WithAdaptiveSampling). A rate outside
+// of (0, 1) dumps every request.
+func WithSampleRate(rate float64) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.SampleRate = rate
+	}
+}
+
+// WithEveryNth is a CurlTransportOption that dumps only the first of
+// every n requests. Requests that are not sampled are passed straight
+// through, as if the transport were disabled. An n of one or less dumps
+// every request.
+func WithEveryNth(n int) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.EveryNth = n
+	}
+}
+
+// sampled reports whether the next request should be dumped according
+// to the transport's EveryNth and SampleRate.
+func (t *CurlTransport) sampled() bool {
+	if t.EveryNth > 1 && (t.root().sampleCount.Add(1)-1)%uint64(t.EveryNth) != 0 {
+		return false
+	}
+	if t.SampleRate > 0 && t.SampleRate < 1 && sampleRand() >= t.SampleRate {
+		return false
+	}
+	return true
+}
+
+// WithAdaptiveSampling is a CurlTransportOption that keeps the interesting
+// traffic when sampling at a low rate: requests that are not sampled are
+// still dumped if they fail (with an error or a 4xx or 5xx status), take
+// longer than slow (if slow is greater than zero), or are the first
+// request to their host. To decide, the output about every request is
+// held back until its response has been received.
+func WithAdaptiveSampling(slow time.Duration) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.AdaptiveSampling = true
+		ct.SlowRequest = slow
+	}
+}
+
+// interesting reports whether adaptive sampling dumps a request with
+// the given outcome.
+func (t *CurlTransport) interesting(resp *http.Response, err error, elapsed time.Duration) bool {
+	return err != nil || resp.StatusCode >= 400 || t.SlowRequest > 0 && elapsed > t.SlowRequest
+}
+
+// maxSampledHosts is the maximum number of hosts remembered by adaptive
+// sampling. Requests to further hosts are no longer treated as new.
+const maxSampledHosts = 1024
+
+// hostSet is a set of hosts, such as those seen by adaptive sampling,
+// of at most maxSampledHosts.
+type hostSet struct {
+	mu    sync.Mutex
+	hosts map[string]bool
+}
+
+// add adds host to the set and reports whether it is new.
+func (s *hostSet) add(host string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hosts[host] || len(s.hosts) >= maxSampledHosts {
+		return false
+	}
+	if s.hosts == nil {
+		s.hosts = map[string]bool{}
+	}
+	s.hosts[host] = true
+	return true
+}
@@ -0,0 +1,161 @@
+package httpdebug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCurlTransport_sampled(t *testing.T) {
+	oldRand := sampleRand
+	defer func() { sampleRand = oldRand }()
+	rolls := []float64{0.1, 0.9, 0.2, 0.5, 0.3, 0.7}
+
+	tests := []struct {
+		name string
+		opts []CurlTransportOption
+		want []bool
+	}{
+		{
+			name: "default",
+			want: []bool{true, true, true, true, true, true},
+		},
+		{
+			name: "every 3rd",
+			opts: []CurlTransportOption{WithEveryNth(3)},
+			want: []bool{true, false, false, true, false, false},
+		},
+		{
+			name: "sample rate",
+			opts: []CurlTransportOption{WithSampleRate(0.5)},
+			want: []bool{true, false, true, false, true, false},
+		},
+		{
+			name: "every 2nd and sample rate",
+			opts: []CurlTransportOption{WithEveryNth(2), WithSampleRate(0.25)},
+			want: []bool{true, false, true, false, false, false},
+		},
+		{
+			name: "out of range rate",
+			opts: []CurlTransportOption{WithSampleRate(1.5), WithEveryNth(1)},
+			want: []bool{true, true, true, true, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := New(tt.opts...)
+			for n, want := range tt.want {
+				sampleRand = func() float64 { return rolls[n] }
+				if got := ct.sampled(); got != want {
+					t.Errorf("request %v: sampled = %v, want %v", n, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRoundTrip_EveryNth(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithEveryNth(10))
+	for i := 0; i < 20; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+	if len(logged) != 2 {
+		t.Errorf("got %v dumps, want 2", len(logged))
+	}
+
+	// FullDump bypasses sampling.
+	logged = nil
+	req, _ := http.NewRequestWithContext(FullDump(context.Background()), "GET", url, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("client.Do = %v", err)
+	}
+	resp.Body.Close()
+	if len(logged) == 0 {
+		t.Error("FullDump request was not dumped")
+	}
+}
+
+func TestRoundTrip_AdaptiveSampling(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+	oldRand := sampleRand
+	defer func() { sampleRand = oldRand }()
+	sampleRand = func() float64 { return 0.99 }
+
+	sink := &entryRecorder{}
+	ct := New(WithSampleRate(0.01), WithAdaptiveSampling(50*time.Millisecond), WithEntrySink(sink))
+	client.Transport = ct
+	for _, path := range []string{"/ok", "/ok", "/fail", "/ok", "/slow", "/ok"} {
+		resp, err := client.Get(url + path)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	var got []string
+	for _, e := range sink.entries {
+		got = append(got, strings.TrimPrefix(e.URL, url))
+	}
+	if want := []string{"/ok", "/fail", "/slow"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dumped %q, want %q", got, want)
+	}
+	if len(logged) != 3 {
+		t.Errorf("logged = %#v, want 3 dumps", logged)
+	}
+
+	// A transport error is always interesting.
+	ct.Transport = errTransport{err: errors.New("boom")}
+	req, _ := http.NewRequest("GET", url+"/ok", nil)
+	if _, err := ct.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip = nil, want error")
+	}
+	if len(sink.entries) != 4 {
+		t.Errorf("got %v entries, want the failed request dumped", len(sink.entries))
+	}
+}
+
+func TestHostSet(t *testing.T) {
+	var s hostSet
+	if !s.add("a") || s.add("a") || !s.add("b") {
+		t.Error("add did not report new hosts correctly")
+	}
+	for i := 0; i < maxSampledHosts; i++ {
+		s.add(fmt.Sprint(i))
+	}
+	if s.add("late") {
+		t.Error("add reported a new host beyond maxSampledHosts")
+	}
+}
@@ -0,0 +1,76 @@
+package httpdebug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteScript writes the retained exchanges to w as a bash script of their
+// curl commands, oldest first, so that a whole session can be replayed by
+// hand. Each command is preceded by a comment giving the time of the
+// request and its outcome, and markers (see Mark) become comments too.
+// If sleep is true, the commands are separated by sleeps matching the
+// gaps between the original requests. Secrets stay redacted, so they
+// must be filled in before the script is run.
+func (c *Capture) WriteScript(w io.Writer, sleep bool) error {
+	exchanges := c.Captured()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#!/usr/bin/env bash\n# %v requests captured by httpdebug. Secrets are REDACTED; fill them in\n# before running.\n", requestCount(exchanges))
+
+	var last time.Time
+	for _, x := range exchanges {
+		if x.Marker != nil {
+			fmt.Fprintf(bw, "\n# ---- %v ----\n", oneLine(x.Marker.Name))
+			continue
+		}
+		e := x.Request
+		if e == nil {
+			continue
+		}
+		if sleep && !last.IsZero() {
+			if gap := e.Time.Sub(last); gap >= time.Millisecond {
+				fmt.Fprintf(bw, "\nsleep %v\n", strconv.FormatFloat(gap.Seconds(), 'f', 3, 64))
+			}
+		}
+		last = e.Time
+
+		outcome := x.Status
+		if x.Err != "" {
+			outcome = "error: " + oneLine(x.Err)
+		}
+		fmt.Fprintf(bw, "\n# %v %v %v -> %v in %v\n", e.Time.UTC().Format(time.RFC3339Nano), e.Method, e.URL, outcome, FormatDuration(x.Duration))
+		fmt.Fprintln(bw, e.Curl)
+	}
+	return bw.Flush()
+}
+
+// WriteScript writes the exchanges retained by WithCapture to w as a bash
+// script (see Capture.WriteScript). It writes nothing if capturing is not
+// enabled.
+func (t *CurlTransport) WriteScript(w io.Writer, sleep bool) error {
+	if t.Capture == nil {
+		return nil
+	}
+	return t.Capture.WriteScript(w, sleep)
+}
+
+// requestCount returns the number of exchanges that are not markers.
+func requestCount(exchanges []Exchange) int {
+	var n int
+	for _, x := range exchanges {
+		if x.Marker == nil && x.Request != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// oneLine returns s with its newlines replaced by spaces, for use in a
+// comment.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
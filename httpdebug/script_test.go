@@ -0,0 +1,76 @@
+package httpdebug
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCapture_WriteScript(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCapture(10)
+	c.add(&Exchange{
+		Request:  &Entry{Time: start, Method: "GET", URL: "https://example.com/a", Curl: "curl \\\n  https://example.com/a"},
+		Status:   "200 OK",
+		Duration: 20 * time.Millisecond,
+	})
+	c.add(&Exchange{Marker: &Marker{Time: start.Add(time.Second), Name: "log\nin"}})
+	c.add(&Exchange{
+		Request:  &Entry{Time: start.Add(1500 * time.Millisecond), Method: "POST", URL: "https://example.com/b", Curl: "curl -X POST \\\n  https://example.com/b \\\n  --data-raw 'x'"},
+		Err:      "dial tcp: no route",
+		Duration: time.Millisecond,
+	})
+
+	var buf bytes.Buffer
+	if err := c.WriteScript(&buf, true); err != nil {
+		t.Fatalf("WriteScript = %v", err)
+	}
+	want := `#!/usr/bin/env bash
+# 2 requests captured by httpdebug. Secrets are REDACTED; fill them in
+# before running.
+
+# 2024-05-01T12:00:00Z GET https://example.com/a -> 200 OK in 20ms
+curl \
+  https://example.com/a
+
+# ---- log in ----
+
+sleep 1.500
+
+# 2024-05-01T12:00:01.5Z POST https://example.com/b -> error: dial tcp: no route in 1ms
+curl -X POST \
+  https://example.com/b \
+  --data-raw 'x'
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteScript =\n%v\nwant:\n%v", got, want)
+	}
+
+	buf.Reset()
+	if err := c.WriteScript(&buf, false); err != nil {
+		t.Fatalf("WriteScript = %v", err)
+	}
+	if strings.Contains(buf.String(), "sleep") {
+		t.Errorf("WriteScript without sleeps =\n%v", buf.String())
+	}
+
+	// The script runs, with curl replaced by a function.
+	if bash, err := exec.LookPath("bash"); err == nil {
+		out, err := exec.Command(bash, "-c", "curl() { echo \"$@\"; }\n"+buf.String()).CombinedOutput()
+		if want := "https://example.com/a\n-X POST https://example.com/b --data-raw x\n"; err != nil || string(out) != want {
+			t.Errorf("running the script = %q, %v, want %q", out, err, want)
+		}
+	}
+}
+
+func TestCurlTransport_WriteScript(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New().WriteScript(&buf, true); err != nil || buf.Len() != 0 {
+		t.Errorf("WriteScript without a Capture = %q, %v, want nothing", buf.String(), err)
+	}
+	if err := New(WithCapture(1)).WriteScript(&buf, true); err != nil || !strings.HasPrefix(buf.String(), "#!/usr/bin/env bash\n# 0 requests") {
+		t.Errorf("WriteScript = %q, %v, want an empty script", buf.String(), err)
+	}
+}
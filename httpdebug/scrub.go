@@ -0,0 +1,181 @@
+package httpdebug
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Scrub applies the redaction rules to buf, which holds a HAR file, a
+// cassette, or the JSON array of exchanges written by Capture.Archive,
+// and returns it re-encoded. This sanitizes archives recorded with
+// looser rules before they are shared; see ScrubHAR, ScrubInteractions,
+// and ScrubExchanges.
+func (r *Redactor) Scrub(buf []byte) ([]byte, error) {
+	var v interface{}
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte("[")) {
+		var exchanges []Exchange
+		if err := json.Unmarshal(buf, &exchanges); err != nil {
+			return nil, err
+		}
+		r.ScrubExchanges(exchanges)
+		v = exchanges
+	} else {
+		var doc struct {
+			Log          *HARLog        `json:"log"`
+			Interactions []*Interaction `json:"interactions"`
+		}
+		if err := json.Unmarshal(buf, &doc); err != nil {
+			return nil, err
+		}
+		switch {
+		case doc.Log != nil:
+			h := &HAR{Log: *doc.Log}
+			if err := r.ScrubHAR(h); err != nil {
+				return nil, err
+			}
+			v = h
+		case doc.Interactions != nil:
+			r.ScrubInteractions(doc.Interactions)
+			v = &Cassette{Interactions: doc.Interactions}
+		default:
+			return nil, errors.New("neither a HAR file, a cassette, nor a capture archive")
+		}
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// ScrubHAR applies the redaction rules to the URLs, headers, query
+// strings, and bodies of the entries in h. Only the fields represented
+// by HAR survive, so cookie lists and other data the package does not
+// know about are dropped when h is written back.
+func (r *Redactor) ScrubHAR(h *HAR) error {
+	for i := range h.Log.Entries {
+		req, resp := &h.Log.Entries[i].Request, &h.Log.Entries[i].Response
+		req.URL = r.scrubURL(req.URL)
+		req.Headers = r.scrubPairs(req.Headers)
+		var query []HARNameValue
+		for _, p := range req.QueryString {
+			if v := r.Param(p.Name, p.Value); v != "" || p.Value == "" {
+				query = append(query, HARNameValue{Name: p.Name, Value: v})
+			}
+		}
+		req.QueryString = query
+		if req.PostData != nil {
+			req.PostData.Text = string(r.Body(req.PostData.MimeType, []byte(req.PostData.Text)))
+		}
+
+		resp.Headers = r.scrubPairs(resp.Headers)
+		body, err := resp.Content.Body()
+		if err != nil {
+			return err
+		}
+		scrubbed := r.Body(resp.Content.MimeType, body)
+		if bytes.Equal(scrubbed, body) {
+			continue
+		}
+		resp.Content.Text = string(scrubbed)
+		if resp.Content.Encoding == "base64" {
+			resp.Content.Text = base64.StdEncoding.EncodeToString(scrubbed)
+		}
+		resp.Content.Size = int64(len(scrubbed))
+	}
+	return nil
+}
+
+// ScrubInteractions applies the redaction rules to the URLs, headers,
+// and bodies of the cassette interactions.
+func (r *Redactor) ScrubInteractions(interactions []*Interaction) {
+	for _, x := range interactions {
+		req, resp := &x.Request, &x.Response
+		req.URL = r.scrubURL(req.URL)
+		req.Body = r.Body(req.Header.Get("Content-Type"), req.Body)
+		req.Header = r.scrubHeader(req.Header)
+		resp.Body = r.Body(resp.Header.Get("Content-Type"), resp.Body)
+		resp.Header = r.scrubHeader(resp.Header)
+	}
+}
+
+// ScrubExchanges applies the redaction rules to the captured exchanges.
+// The curl command of each request is rebuilt from its scrubbed URL,
+// headers, and body, so flags that came from the original transport
+// (e.g. --proxy) are dropped, as is a body that was not captured.
+func (r *Redactor) ScrubExchanges(exchanges []Exchange) {
+	for i := range exchanges {
+		x := &exchanges[i]
+		if x.Request == nil {
+			continue
+		}
+		e := *x.Request
+		e.URL = r.scrubURL(e.URL)
+		x.RequestBody = r.Body(x.RequestHeader.Get("Content-Type"), x.RequestBody)
+		x.RequestHeader = r.scrubHeader(x.RequestHeader)
+		e.Curl = curlFor(e.Method, e.URL, x.RequestHeader, x.RequestBody)
+		x.Request = &e
+		x.Body = r.Body(x.Header.Get("Content-Type"), x.Body)
+		x.Header = r.scrubHeader(x.Header)
+	}
+}
+
+// scrubURL returns the URL s with the redaction rules applied, or s if
+// it cannot be parsed.
+func (r *Redactor) scrubURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return r.URL(u)
+}
+
+// scrubPairs returns the HAR headers with the redaction rules applied.
+func (r *Redactor) scrubPairs(pairs []HARNameValue) []HARNameValue {
+	var result []HARNameValue
+	for _, p := range pairs {
+		if v, ok := r.header(p.Name, []string{p.Value}); ok {
+			result = append(result, HARNameValue{Name: p.Name, Value: v})
+		}
+	}
+	return result
+}
+
+// scrubHeader returns a copy of h with any secrets scrubbed. Unlike
+// Headers, it keeps multiple values (e.g. of Set-Cookie) apart.
+func (r *Redactor) scrubHeader(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	result := make(http.Header, len(h))
+	for k, values := range h {
+		for _, v := range values {
+			if value, ok := r.header(k, []string{v}); ok {
+				result[k] = append(result[k], value)
+			}
+		}
+	}
+	return result
+}
+
+// curlFor returns the curl command sending a request with the already
+// scrubbed URL, headers, and body.
+func curlFor(method, u string, header http.Header, body []byte) string {
+	lines := []string{curlCommand(method, len(body) > 0), shellWord(u)}
+	var headers []string
+	for k, v := range header {
+		headers = append(headers, "-H "+shellQuote(k+": "+strings.Join(v, ", ")))
+	}
+	sort.Strings(headers)
+	lines = append(lines, headers...)
+	if len(body) > 0 {
+		lines = append(lines, curlData(body, false))
+	}
+	return strings.Join(lines, " \\\n  ")
+}
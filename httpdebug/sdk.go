@@ -0,0 +1,325 @@
+package httpdebug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SDKCall identifies the SDK that sent a request and, where it can be
+// derived, the API operation it performed.
+type SDKCall struct {
+	SDK       string // e.g. "aws-sdk-go-v2"
+	Version   string // e.g. "1.24.0", if known
+	Operation string // e.g. "dynamodb.PutItem", if derivable
+}
+
+// String returns the call as logged, e.g. "stripe-go 76.0.0 customers.create".
+func (c SDKCall) String() string {
+	s := c.SDK
+	for _, v := range []string{c.Version, c.Operation} {
+		if v != "" {
+			s += " " + v
+		}
+	}
+	return s
+}
+
+// SDKDetector recognizes the requests sent by an SDK, from their
+// headers and, for some protocols, from their (possibly truncated or
+// nil) body.
+type SDKDetector func(req *http.Request, body []byte) (SDKCall, bool)
+
+// DefaultSDKDetectors are the detectors added by WithSDKDetection.
+var DefaultSDKDetectors = []SDKDetector{GitHubSDK, AWSSDK, StripeSDK}
+
+// WithSDKDetection is a CurlTransportOption that recognizes requests
+// sent by the clients of popular SDKs (go-github, aws-sdk-go and
+// aws-sdk-go-v2, and stripe-go) and logs the SDK and operation after the
+// curl command, so that raw traffic can be read in terms of the API
+// calls made, e.g.:
+//
+//	# sdk: aws-sdk-go-v2 1.24.0 dynamodb.PutItem
+//
+// The call is also attached to the Entry as the "sdk", "sdk_version",
+// and "sdk_operation" Metadata. See WithSDKDetector to recognize other
+// SDKs.
+func WithSDKDetection() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.SDKDetectors = append(ct.SDKDetectors, DefaultSDKDetectors...)
+	}
+}
+
+// WithSDKDetector is a CurlTransportOption that adds an additional
+// SDKDetector, tried in turn after those before it. A nil detector is
+// ignored.
+func WithSDKDetector(detector SDKDetector) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		if detector != nil {
+			ct.SDKDetectors = append(ct.SDKDetectors, detector)
+		}
+	}
+}
+
+// detectSDK returns the call made by req according to the first
+// SDKDetector that recognizes it, and attaches it to the Metadata of e.
+func (t *CurlTransport) detectSDK(e *Entry, req *http.Request, body requestBody) (SDKCall, bool) {
+	for _, detect := range t.SDKDetectors {
+		call, ok := detect(req, body.data)
+		if !ok {
+			continue
+		}
+		if e.Metadata == nil {
+			e.Metadata = map[string]string{}
+		}
+		e.Metadata["sdk"] = call.SDK
+		if call.Version != "" {
+			e.Metadata["sdk_version"] = call.Version
+		}
+		if call.Operation != "" {
+			e.Metadata["sdk_operation"] = call.Operation
+		}
+		return call, true
+	}
+	return SDKCall{}, false
+}
+
+// userAgentProduct returns the version of the product name in the
+// User-Agent of req, e.g. "1.24.0" for "aws-sdk-go-v2" in
+// "aws-sdk-go-v2/1.24.0 os/linux", and reports whether it is there.
+func userAgentProduct(req *http.Request, name string) (string, bool) {
+	for _, field := range strings.Fields(req.Header.Get("User-Agent")) {
+		product, version, _ := strings.Cut(field, "/")
+		if strings.EqualFold(product, name) {
+			return version, true
+		}
+	}
+	return "", false
+}
+
+// GitHubSDK is an SDKDetector recognizing requests sent by go-github,
+// whose User-Agent is "go-github/v58.0.0". The operation is derived from
+// the path as the resource and the verb, e.g. "repos.issues.create" for
+// POST /repos/{owner}/{repo}/issues.
+func GitHubSDK(req *http.Request, body []byte) (SDKCall, bool) {
+	version, ok := userAgentProduct(req, "go-github")
+	if !ok {
+		return SDKCall{}, false
+	}
+	return SDKCall{SDK: "go-github", Version: version, Operation: gitHubOperation(req.Method, req.URL.Path)}, true
+}
+
+// gitHubOwned are the GitHub resources named by an owner and a name,
+// which are not taken for resource names.
+var gitHubOwned = map[string]bool{"repos": true}
+
+// gitHubPaths are the GitHub resources followed by a path, e.g. that of
+// a file in "contents/docs/README.md", rather than by a single name.
+var gitHubPaths = map[string]bool{"contents": true, "refs": true}
+
+// gitHubNamed are the GitHub resources named by a single path segment.
+var gitHubNamed = map[string]bool{"users": true, "orgs": true, "gists": true, "teams": true, "enterprises": true}
+
+// gitHubOperation returns the operation for the method and path of a
+// GitHub API request, or "" if none can be derived.
+func gitHubOperation(method, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if strings.HasPrefix(path, "/api/v3/") {
+		segments = segments[2:] // GitHub Enterprise Server
+	}
+	var names []string
+	last := true // whether the last segment is a resource rather than an ID
+	for i := 0; i < len(segments); i++ {
+		seg := segments[i]
+		if seg == "" {
+			return ""
+		}
+		names = append(names, seg)
+		last = true
+		switch {
+		case gitHubOwned[seg]:
+			i += 2
+		case gitHubPaths[seg]:
+			i = len(segments) - 1
+		case gitHubNamed[seg] || isPathID(peek(segments, i+1)):
+			i++
+		default:
+			continue
+		}
+		if i < len(segments) {
+			last = false
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return strings.Join(names, ".") + "." + restVerb(method, last)
+}
+
+// peek returns segments[i], or "" if i is out of range.
+func peek(segments []string, i int) string {
+	if i < len(segments) {
+		return segments[i]
+	}
+	return ""
+}
+
+// restVerb returns the conventional name of a REST operation on a
+// collection, if collection is true, or on one of its members.
+func restVerb(method string, collection bool) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, "":
+		if collection {
+			return "list"
+		}
+		return "get"
+	case http.MethodPost:
+		if collection {
+			return "create"
+		}
+		return "update"
+	case http.MethodPut, http.MethodPatch:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	}
+	return strings.ToLower(method)
+}
+
+// AWSSDK is an SDKDetector recognizing requests sent by aws-sdk-go and
+// aws-sdk-go-v2, whose User-Agents start with "aws-sdk-go/1.44.0" and
+// "aws-sdk-go-v2/1.24.0". The operation is "service.Action", from the
+// X-Amz-Target header of the JSON protocols or the Action parameter of
+// the query protocol, or just the service (from the signing scope) for
+// REST APIs such as S3, e.g. "dynamodb.PutItem" or "s3".
+func AWSSDK(req *http.Request, body []byte) (SDKCall, bool) {
+	call := SDKCall{SDK: "aws-sdk-go-v2"}
+	version, ok := userAgentProduct(req, call.SDK)
+	if !ok {
+		call.SDK = "aws-sdk-go"
+		if version, ok = userAgentProduct(req, call.SDK); !ok {
+			return SDKCall{}, false
+		}
+	}
+	call.Version = version
+	service := awsService(req)
+	action := awsAction(req, body)
+	switch {
+	case service != "" && action != "":
+		call.Operation = service + "." + action
+	case action != "":
+		call.Operation = action
+	default:
+		call.Operation = service
+	}
+	return call, true
+}
+
+// awsCredentialScopeRE matches the credential scope of a SigV4
+// Authorization header, capturing the service.
+var awsCredentialScopeRE = regexp.MustCompile(`Credential=[^/,]+/\d{8}/[^/,]+/([^/,]+)/aws4_request`)
+
+// awsService returns the name of the AWS service called by req, or "".
+func awsService(req *http.Request) string {
+	if m := awsCredentialScopeRE.FindStringSubmatch(req.Header.Get("Authorization")); m != nil {
+		return m[1]
+	}
+	if m := awsCredentialScopeRE.FindStringSubmatch("Credential=" + req.URL.Query().Get("X-Amz-Credential")); m != nil {
+		return m[1] // presigned URL
+	}
+	// aws-sdk-go-v2 names the service client in the User-Agent, e.g. "api/s3#1.47.0".
+	for _, field := range strings.Fields(req.Header.Get("User-Agent")) {
+		if api, ok := strings.CutPrefix(field, "api/"); ok {
+			name, _, _ := strings.Cut(api, "#")
+			return strings.ToLower(name)
+		}
+	}
+	return ""
+}
+
+// awsAction returns the API action of req, or "" if it is not named.
+func awsAction(req *http.Request, body []byte) string {
+	if target := req.Header.Get("X-Amz-Target"); target != "" {
+		// e.g. "DynamoDB_20120810.PutItem"
+		if i := strings.LastIndex(target, "."); i >= 0 {
+			return target[i+1:]
+		}
+		return target
+	}
+	if action := req.URL.Query().Get("Action"); action != "" {
+		return action
+	}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			return form.Get("Action")
+		}
+	}
+	return ""
+}
+
+// StripeSDK is an SDKDetector recognizing requests sent by stripe-go,
+// whose User-Agent is "Stripe/v1 GoBindings/76.0.0". The operation is
+// named as in the Stripe API reference, e.g. "customers.create" for
+// POST /v1/customers, "customers.retrieve" for GET /v1/customers/cus_123,
+// or "payment_intents.confirm" for POST /v1/payment_intents/pi_123/confirm.
+func StripeSDK(req *http.Request, body []byte) (SDKCall, bool) {
+	version, ok := userAgentProduct(req, "GoBindings")
+	if !ok {
+		var client struct {
+			Bindings string `json:"bindings_version"`
+			Lang     string `json:"lang"`
+		}
+		if json.Unmarshal([]byte(req.Header.Get("X-Stripe-Client-User-Agent")), &client) != nil || client.Lang != "go" {
+			return SDKCall{}, false
+		}
+		version = client.Bindings
+	}
+	return SDKCall{SDK: "stripe-go", Version: version, Operation: stripeOperation(req.Method, req.URL.Path)}, true
+}
+
+// stripeIDRE matches the IDs of Stripe objects, e.g. "cus_NffrFeUfNV2Hib",
+// which have a type prefix and a mixed-case suffix, unlike the names of
+// resources, e.g. "payment_intents".
+var stripeIDRE = regexp.MustCompile(`^[a-z]+(_[a-z]+)*_[A-Za-z0-9]*[A-Z0-9][A-Za-z0-9]*$`)
+
+// stripeOperation returns the operation for the method and path of a
+// Stripe API request, or "" if none can be derived.
+func stripeOperation(method, path string) string {
+	rest, ok := strings.CutPrefix(path, "/v1/")
+	if !ok || rest == "" {
+		return ""
+	}
+	var names []string
+	var afterID, lastID bool
+	for _, seg := range strings.Split(rest, "/") {
+		lastID = stripeIDRE.MatchString(seg) || isPathID(seg)
+		if lastID {
+			afterID = true
+			continue
+		}
+		names = append(names, seg)
+	}
+	op := strings.Join(names, ".")
+	switch {
+	case lastID:
+		switch method {
+		case http.MethodGet, "":
+			return op + ".retrieve"
+		case http.MethodPost:
+			return op + ".update"
+		case http.MethodDelete:
+			return op + ".delete"
+		}
+	case afterID && method == http.MethodPost:
+		// An action on an object, e.g. ".../pi_123/confirm", or the
+		// creation of a nested object, e.g. ".../cus_123/sources".
+		return op
+	case method == http.MethodGet || method == "":
+		return op + ".list"
+	case method == http.MethodPost:
+		return op + ".create"
+	}
+	return op + "." + strings.ToLower(method)
+}
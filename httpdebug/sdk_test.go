@@ -0,0 +1,191 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDefaultSDKDetectors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		header http.Header
+		body   string
+		want   SDKCall
+		ok     bool
+	}{
+		{
+			name:   "go-github issue",
+			method: "GET",
+			url:    "https://api.github.com/repos/gmlewis/go-httpdebug/issues/12",
+			header: http.Header{"User-Agent": {"go-github/v58.0.0"}},
+			want:   SDKCall{SDK: "go-github", Version: "v58.0.0", Operation: "repos.issues.get"},
+			ok:     true,
+		},
+		{
+			name:   "go-github nested collection",
+			method: "POST",
+			url:    "https://api.github.com/repos/o/r/issues/12/comments",
+			header: http.Header{"User-Agent": {"go-github/v58.0.0"}},
+			want:   SDKCall{SDK: "go-github", Version: "v58.0.0", Operation: "repos.issues.comments.create"},
+			ok:     true,
+		},
+		{
+			name:   "go-github contents on Enterprise Server",
+			method: "PUT",
+			url:    "https://ghe.example.com/api/v3/repos/o/r/contents/docs/README.md",
+			header: http.Header{"User-Agent": {"go-github/v58.0.0"}},
+			want:   SDKCall{SDK: "go-github", Version: "v58.0.0", Operation: "repos.contents.update"},
+			ok:     true,
+		},
+		{
+			name:   "go-github user",
+			method: "GET",
+			url:    "https://api.github.com/users/octocat/repos",
+			header: http.Header{"User-Agent": {"go-github/v58.0.0"}},
+			want:   SDKCall{SDK: "go-github", Version: "v58.0.0", Operation: "users.repos.list"},
+			ok:     true,
+		},
+		{
+			name:   "aws-sdk-go-v2 JSON protocol",
+			method: "POST",
+			url:    "https://dynamodb.us-east-1.amazonaws.com/",
+			header: http.Header{
+				"User-Agent":    {"aws-sdk-go-v2/1.24.0 os/linux lang/go#1.21.5 md/GOOS#linux api/dynamodb#1.26.6"},
+				"X-Amz-Target":  {"DynamoDB_20120810.PutItem"},
+				"Authorization": {"AWS4-HMAC-SHA256 Credential=AKID/20240101/us-east-1/dynamodb/aws4_request, SignedHeaders=host, Signature=abc"},
+			},
+			want: SDKCall{SDK: "aws-sdk-go-v2", Version: "1.24.0", Operation: "dynamodb.PutItem"},
+			ok:   true,
+		},
+		{
+			name:   "aws-sdk-go query protocol",
+			method: "POST",
+			url:    "https://sts.amazonaws.com/",
+			header: http.Header{
+				"User-Agent":   {"aws-sdk-go/1.44.0 (go1.21.5; linux; amd64)"},
+				"Content-Type": {"application/x-www-form-urlencoded; charset=utf-8"},
+			},
+			body: "Action=GetCallerIdentity&Version=2011-06-15",
+			want: SDKCall{SDK: "aws-sdk-go", Version: "1.44.0", Operation: "GetCallerIdentity"},
+			ok:   true,
+		},
+		{
+			name:   "aws-sdk-go-v2 REST",
+			method: "PUT",
+			url:    "https://bucket.s3.us-east-1.amazonaws.com/key",
+			header: http.Header{"User-Agent": {"aws-sdk-go-v2/1.24.0 os/linux api/s3#1.47.0"}},
+			want:   SDKCall{SDK: "aws-sdk-go-v2", Version: "1.24.0", Operation: "s3"},
+			ok:     true,
+		},
+		{
+			name:   "stripe-go create",
+			method: "POST",
+			url:    "https://api.stripe.com/v1/customers",
+			header: http.Header{"User-Agent": {"Stripe/v1 GoBindings/76.0.0"}},
+			want:   SDKCall{SDK: "stripe-go", Version: "76.0.0", Operation: "customers.create"},
+			ok:     true,
+		},
+		{
+			name:   "stripe-go retrieve",
+			method: "GET",
+			url:    "https://api.stripe.com/v1/customers/cus_NffrFeUfNV2Hib",
+			header: http.Header{"User-Agent": {"Stripe/v1 GoBindings/76.0.0"}},
+			want:   SDKCall{SDK: "stripe-go", Version: "76.0.0", Operation: "customers.retrieve"},
+			ok:     true,
+		},
+		{
+			name:   "stripe-go action",
+			method: "POST",
+			url:    "https://api.stripe.com/v1/payment_intents/pi_3MtwBwLkdIwHu7ix28a3tqPa/confirm",
+			header: http.Header{"X-Stripe-Client-User-Agent": {`{"bindings_version":"76.0.0","lang":"go"}`}},
+			want:   SDKCall{SDK: "stripe-go", Version: "76.0.0", Operation: "payment_intents.confirm"},
+			ok:     true,
+		},
+		{
+			name:   "stripe-go list",
+			method: "GET",
+			url:    "https://api.stripe.com/v1/balance_transactions?limit=3",
+			header: http.Header{"User-Agent": {"Stripe/v1 GoBindings/76.0.0"}},
+			want:   SDKCall{SDK: "stripe-go", Version: "76.0.0", Operation: "balance_transactions.list"},
+			ok:     true,
+		},
+		{
+			name:   "other client",
+			method: "GET",
+			url:    "https://api.github.com/repos/o/r",
+			header: http.Header{"User-Agent": {"Go-http-client/1.1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header = tt.header
+			var got SDKCall
+			var ok bool
+			for _, detect := range DefaultSDKDetectors {
+				if got, ok = detect(req, []byte(tt.body)); ok {
+					break
+				}
+			}
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("detected (%+v, %v), want (%+v, %v)", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_SDKDetection(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	r := &entryRecorder{}
+	client.Transport = New(WithSDKDetection(), WithSequence(), WithEntrySink(r))
+	for _, ua := range []string{"Stripe/v1 GoBindings/76.0.0", "curl/8.0"} {
+		req, err := http.NewRequest("POST", url+"/v1/customers", strings.NewReader("name=bob"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("User-Agent", ua)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("client.Do = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(logged) != 5 || logged[1] != "# sdk #0001: stripe-go 76.0.0 customers.create" || strings.HasPrefix(logged[4], "# sdk") {
+		t.Errorf("logged = %#v, want the SDK call after the first curl command only", logged)
+	}
+	if len(r.entries) != 2 {
+		t.Fatalf("got %v entries, want 2", len(r.entries))
+	}
+	want := map[string]string{"sdk": "stripe-go", "sdk_version": "76.0.0", "sdk_operation": "customers.create"}
+	if got := r.entries[0].Metadata; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Metadata = %v, want %v", got, want)
+	}
+	if got := r.entries[1].Metadata; got != nil {
+		t.Errorf("Metadata = %v, want nil", got)
+	}
+}
+
+func TestWithSDKDetector(t *testing.T) {
+	detect := func(req *http.Request, body []byte) (SDKCall, bool) { return SDKCall{}, false }
+	ct := New(WithSDKDetector(nil), WithSDKDetector(detect))
+	if len(ct.SDKDetectors) != 1 {
+		t.Errorf("SDKDetectors = %v, want 1 detector", ct.SDKDetectors)
+	}
+}
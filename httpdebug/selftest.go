@@ -0,0 +1,344 @@
+package httpdebug
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SelfTestResult is the outcome of replaying one request of SelfTest
+// with its generated curl command.
+type SelfTestResult struct {
+	// Name describes the request, e.g. "quotes".
+	Name string
+	// Curl is the generated command.
+	Curl string
+	// Err describes how the request sent by Curl differs from the
+	// original, or why it could not be run. It is nil if they match.
+	Err error
+}
+
+// SelfTest checks that the curl commands dumped by a transport configured
+// with opts are runnable and faithful. It sends a matrix of tricky
+// requests (quotes and shell metacharacters, unicode, multipart and
+// binary bodies, gzip, and nonstandard methods) through the transport to
+// a local echo server, runs each generated command with bash and the
+// curl in the PATH, and compares the request the echo server received
+// from curl with the original. Headers that curl and Go set on their own
+// (User-Agent, Accept, Accept-Encoding, Content-Length, and Connection)
+// are not compared, and compressed request bodies are compared
+// decompressed, as the commands send them.
+//
+// The requests are dumped as usual. It returns an error only if bash or
+// curl cannot be found or the echo server cannot be reached; failing
+// commands are reported in the results.
+func SelfTest(ctx context.Context, opts ...CurlTransportOption) ([]SelfTestResult, error) {
+	for _, name := range []string{"bash", "curl"} {
+		if _, err := exec.LookPath(name); err != nil {
+			return nil, err
+		}
+	}
+
+	echo := &echoServer{}
+	ts := httptest.NewServer(echo)
+	defer ts.Close()
+
+	ct := New(append([]CurlTransportOption{WithTransport(&http.Transport{}), WithCapture(1)}, opts...)...)
+	ct.SetEnabled(true)
+	client := &http.Client{Transport: ct}
+	defer ct.Close()
+
+	var results []SelfTestResult
+	for _, c := range selfTestCases {
+		req, err := c.request(ctx, ts.URL)
+		if err != nil {
+			return results, fmt.Errorf("%v: %w", c.name, err)
+		}
+		echo.reset()
+		resp, err := client.Do(req)
+		if err != nil {
+			return results, fmt.Errorf("%v: %w", c.name, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		want := echo.received()
+		x, ok := ct.Last()
+		if len(want) != 1 || !ok || x.Request == nil {
+			return results, fmt.Errorf("%v: request was not dumped and echoed", c.name)
+		}
+
+		result := SelfTestResult{Name: c.name, Curl: x.Request.Curl}
+		result.Err = runSelfTestCurl(ctx, echo, result.Curl, req.Method, want[0])
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// runSelfTestCurl runs the curl command and returns an error if the
+// request it sends is not echoed as want, or if its output is not the
+// echo.
+func runSelfTestCurl(ctx context.Context, echo *echoServer, curl, method, want string) error {
+	echo.reset()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "bash", "-c", curl)
+	cmd.Env = withoutProxies(os.Environ())
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %v", err, strings.TrimSpace(stderr.String()))
+	}
+
+	got := echo.received()
+	switch {
+	case len(got) != 1:
+		return fmt.Errorf("curl sent %v requests, want 1", len(got))
+	case got[0] != want:
+		return fmt.Errorf("curl sent\n%v\nwant\n%v", got[0], want)
+	case method != http.MethodHead && stdout.String() != want:
+		// Compressed responses must be decoded, e.g. by --compressed.
+		return fmt.Errorf("curl printed\n%q\nwant\n%q", stdout.String(), want)
+	}
+	return nil
+}
+
+// withoutProxies returns environ without the proxy variables, so that
+// curl reaches the local echo server directly, as the transport does.
+func withoutProxies(environ []string) []string {
+	var env []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		switch strings.ToLower(name) {
+		case "http_proxy", "https_proxy", "all_proxy":
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// selfTestCase is a request sent by SelfTest.
+type selfTestCase struct {
+	name        string
+	method      string
+	path        string
+	header      http.Header
+	contentType string
+	body        func() ([]byte, string, error)
+}
+
+// request returns the request of the case to the server at base.
+func (c *selfTestCase) request(ctx context.Context, base string) (*http.Request, error) {
+	var body io.Reader
+	contentType := c.contentType
+	if c.body != nil {
+		buf, ct, err := c.body()
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(buf)
+		if ct != "" {
+			contentType = ct
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, c.method, base+c.path, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// selfTestBody returns a body function for s.
+func selfTestBody(s string) func() ([]byte, string, error) {
+	return func() ([]byte, string, error) { return []byte(s), "", nil }
+}
+
+var selfTestCases = []*selfTestCase{
+	{
+		name:        "quotes",
+		method:      http.MethodPost,
+		path:        "/quotes?q=" + url.QueryEscape(`it's "quoted" $HOME`),
+		header:      http.Header{"X-Note": {"it's a \"test\" of $HOME, `date`, and \\"}},
+		contentType: "application/json",
+		body:        selfTestBody(`{"msg":"it's $(date) and \"quoted\" \\ !"}`),
+	},
+	{
+		name:        "unicode",
+		method:      http.MethodPut,
+		path:        "/caf%C3%A9?name=" + url.QueryEscape("✓ 日本語"),
+		header:      http.Header{"X-Greeting": {"héllo wörld"}},
+		contentType: "text/plain; charset=utf-8",
+		body:        selfTestBody("naïve ☃ 日本語 🚀"),
+	},
+	{
+		name:        "newlines",
+		method:      http.MethodPost,
+		path:        "/newlines",
+		contentType: "text/plain",
+		body:        selfTestBody("line 1\nline 2\r\n\tindented\n\n"),
+	},
+	{
+		name:        "form",
+		method:      http.MethodPost,
+		path:        "/form",
+		contentType: "application/x-www-form-urlencoded",
+		body:        selfTestBody(url.Values{"q": {"a&b=c d"}, "note": {"it's 100%"}}.Encode()),
+	},
+	{
+		name:   "multipart",
+		method: http.MethodPost,
+		path:   "/upload",
+		body: func() ([]byte, string, error) {
+			var b bytes.Buffer
+			w := multipart.NewWriter(&b)
+			w.WriteField("note", "it's\r\nmultiline")
+			f, err := w.CreateFormFile("file", "data.bin")
+			if err != nil {
+				return nil, "", err
+			}
+			f.Write([]byte{0, 1, 2, '\'', '\\', 0x7f, 0x80, 0xff, '\n'})
+			if err := w.Close(); err != nil {
+				return nil, "", err
+			}
+			return b.Bytes(), w.FormDataContentType(), nil
+		},
+	},
+	{
+		name:        "gzip request",
+		method:      http.MethodPost,
+		path:        "/gzip",
+		header:      http.Header{"Content-Encoding": {"gzip"}},
+		contentType: "application/json",
+		body: func() ([]byte, string, error) {
+			var b bytes.Buffer
+			zw := gzip.NewWriter(&b)
+			zw.Write([]byte(`{"compressed":"it's true"}`))
+			if err := zw.Close(); err != nil {
+				return nil, "", err
+			}
+			return b.Bytes(), "", nil
+		},
+	},
+	{
+		name:   "gzip response",
+		method: http.MethodGet,
+		path:   "/gzip",
+		header: http.Header{"Accept-Encoding": {"gzip"}},
+	},
+	{
+		name:        "custom method",
+		method:      "PROPFIND",
+		path:        "/dav/",
+		header:      http.Header{"Depth": {"1"}},
+		contentType: "application/xml",
+		body:        selfTestBody(`<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:allprop/></d:propfind>`),
+	},
+	{
+		name:   "head",
+		method: http.MethodHead,
+		path:   "/head",
+	},
+}
+
+// selfTestIgnoredHeaders are the request headers that curl and Go set
+// on their own, which SelfTest does not compare.
+var selfTestIgnoredHeaders = map[string]bool{
+	"Accept":          true,
+	"Accept-Encoding": true,
+	"Connection":      true,
+	"Content-Length":  true,
+	"User-Agent":      true,
+}
+
+// echoServer is an http.Handler that describes each request it receives
+// in its response, gzipped if the request accepts it, and remembers the
+// descriptions. It is safe for concurrent use.
+type echoServer struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+// ServeHTTP implements http.Handler.
+func (s *echoServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	desc, err := describeRequest(r)
+	if err != nil {
+		desc = "error: " + err.Error()
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, desc)
+	s.mu.Unlock()
+
+	if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		io.WriteString(w, desc)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	zw := gzip.NewWriter(w)
+	io.WriteString(zw, desc)
+	zw.Close()
+}
+
+// reset forgets the requests received so far.
+func (s *echoServer) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// received returns the descriptions of the requests received since the
+// last reset.
+func (s *echoServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// describeRequest returns the method, URI, headers, and body of r as
+// text, with a gzipped body decompressed.
+func describeRequest(r *http.Request) (string, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	header := r.Header.Clone()
+	if strings.EqualFold(header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		if body, err = io.ReadAll(zr); err != nil {
+			return "", err
+		}
+		header.Del("Content-Encoding")
+	}
+
+	lines := []string{r.Method + " " + r.RequestURI}
+	var keys []string
+	for k := range header {
+		if !selfTestIgnoredHeaders[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			lines = append(lines, k+": "+v)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n\n" + fmt.Sprintf("%q", body), nil
+}
@@ -0,0 +1,50 @@
+package httpdebug
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	for _, name := range []string{"bash", "curl"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%v not found", name)
+		}
+	}
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	for _, opts := range [][]CurlTransportOption{nil, {WithSingleLine()}} {
+		results, err := SelfTest(context.Background(), opts...)
+		if err != nil {
+			t.Fatalf("SelfTest = %v", err)
+		}
+		if len(results) != len(selfTestCases) {
+			t.Errorf("got %v results, want %v", len(results), len(selfTestCases))
+		}
+		for _, r := range results {
+			if r.Err != nil {
+				t.Errorf("%v: %v\n%v", r.Name, r.Err, r.Curl)
+			}
+		}
+	}
+}
+
+func TestSelfTest_Mismatch(t *testing.T) {
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl not found")
+	}
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	logger = func(v ...interface{}) {}
+
+	results, err := SelfTest(context.Background(), WithSecretHeader("X-Note"))
+	if err != nil {
+		t.Fatalf("SelfTest = %v", err)
+	}
+	if results[0].Name != "quotes" || results[0].Err == nil {
+		t.Errorf("results[0] = %+v, want the redacted X-Note header to differ", results[0])
+	}
+}
@@ -0,0 +1,107 @@
+package httpdebug
+
+import "strings"
+
+// WithSequence is a CurlTransportOption that numbers each request made
+// through the transport (#0001, #0002, ...). The number precedes the
+// request's curl output as a "# request #0001" comment line and labels
+// the lines logged about its response.
+func WithSequence() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Sequence = true
+	}
+}
+
+// WithSerializedOutput is a CurlTransportOption that holds back the lines
+// logged about each request until its response has been received and
+// then writes them together, so that the multi-line output of concurrent
+// requests never interleaves. A response whose body is dumped (see
+// WithVerbosity and FullDump) is written with the rest once its body has
+// been read in full or closed.
+func WithSerializedOutput() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.Serialize = true
+	}
+}
+
+// output writes the lines logged about a single request, either
+// immediately or, if the transport serializes its output, all together
+// when flushed.
+type output struct {
+	t     *CurlTransport
+	lines []string
+	// held causes the lines and other side effects (see do) to be held
+	// back until flush, so that they can be discarded instead.
+	held    bool
+	pending []func()
+	// waiting is set while the dump of a response body that will end the
+	// lines is being recorded (see wait), and flushed once flush has been
+	// called in the meantime.
+	waiting, flushed bool
+}
+
+// newOutput returns a new output for a request made through t.
+func (t *CurlTransport) newOutput() *output {
+	return &output{t: t}
+}
+
+// log writes s, or holds it back until flush if output is serialized.
+func (o *output) log(s string) {
+	if o.t.Serialize || o.held {
+		o.lines = append(o.lines, s)
+		return
+	}
+	o.t.log(s)
+}
+
+// do calls f, or holds it back until flush if output is held.
+func (o *output) do(f func()) {
+	if o.held {
+		o.pending = append(o.pending, f)
+		return
+	}
+	f()
+}
+
+// discard drops all held-back lines and side effects.
+func (o *output) discard() {
+	o.lines, o.pending = nil, nil
+}
+
+// wait keeps flush from writing the held-back lines of serialized output
+// until release, so that the dump of a response body, logged as the
+// caller reads it, is written along with them.
+func (o *output) wait() {
+	o.waiting = o.t.Serialize
+}
+
+// release logs s, the dump of the response body, after the held-back
+// lines it waited for, writing them if flush has already been called.
+func (o *output) release(s string) {
+	if !o.waiting {
+		o.t.log(s)
+		return
+	}
+	o.lines = append(o.lines, s)
+	o.waiting = false
+	if o.flushed {
+		o.flush()
+	}
+}
+
+// flush performs all held-back side effects and writes all held-back
+// lines as a single log call, unless it must wait for a response body.
+func (o *output) flush() {
+	for _, f := range o.pending {
+		f()
+	}
+	o.pending = nil
+	if o.waiting {
+		o.flushed = true
+		return
+	}
+	if len(o.lines) > 0 {
+		o.t.log(strings.Join(o.lines, "\n"))
+		o.lines = nil
+	}
+}
@@ -0,0 +1,174 @@
+package httpdebug
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithSequence(t *testing.T) {
+	if ct := New(WithSequence()); !ct.Sequence {
+		t.Error("WithSequence did not set Sequence")
+	}
+	if ct := New(WithSerializedOutput()); !ct.Serialize {
+		t.Error("WithSerializedOutput did not set Serialize")
+	}
+}
+
+func TestEntry_label(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry *Entry
+		want  string
+	}{
+		{name: "none", entry: &Entry{}},
+		{name: "seq", entry: &Entry{Seq: 7}, want: " #0007"},
+		{name: "id", entry: &Entry{ID: "req-1"}, want: " req-1"},
+		{name: "both", entry: &Entry{Seq: 12345, ID: "req-1"}, want: " #12345 req-1"},
+		{name: "attempt", entry: &Entry{Attempt: 2}, want: " attempt=2"},
+		{name: "all", entry: &Entry{Seq: 3, ID: "req-1", Attempt: 2}, want: " #0003 req-1 attempt=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.label(); got != tt.want {
+				t.Errorf("label = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_Sequence(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithSequence())
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("client.Get = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(logged) != 4 {
+		t.Fatalf("logged = %#v, want 4 lines", logged)
+	}
+	for i, seq := range []string{"#0001", "#0002"} {
+		if want := "# request " + seq + "\ncurl \\\n"; !strings.HasPrefix(logged[2*i], want) {
+			t.Errorf("request line = %q, want prefix %q", logged[2*i], want)
+		}
+		if want := "# response " + seq + ": 200 OK in "; !strings.HasPrefix(logged[2*i+1], want) {
+			t.Errorf("response line = %q, want prefix %q", logged[2*i+1], want)
+		}
+	}
+}
+
+func TestRoundTrip_SerializedOutput(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var mu sync.Mutex
+	var logged []string
+	logger = func(v ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		logged = append(logged, fmt.Sprint(v...))
+	}
+
+	const n = 20
+	client.Transport = New(WithSequence(), WithSerializedOutput(), WithServerTiming())
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(url)
+			if err != nil {
+				t.Errorf("client.Get = %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if len(logged) != n {
+		t.Fatalf("got %v log calls, want %v", len(logged), n)
+	}
+	re := regexp.MustCompile(`^# request (#\d{4})\ncurl [^#]*\n# timing (#\d{4}): [^\n]*\n# response (#\d{4}): 200 OK in \S+$`)
+	seen := map[string]bool{}
+	for _, s := range logged {
+		m := re.FindStringSubmatch(s)
+		if m == nil || m[1] != m[2] || m[1] != m[3] {
+			t.Errorf("log call = %q, want one complete request", s)
+			continue
+		}
+		seen[m[1]] = true
+	}
+	if len(seen) != n {
+		t.Errorf("got %v distinct sequence numbers, want %v", len(seen), n)
+	}
+}
+
+func TestRoundTrip_SerializedOutputPrefixAndBody(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "body of %v", r.URL.Path)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var mu sync.Mutex
+	var logged []string
+	logger = func(v ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		logged = append(logged, fmt.Sprint(v...))
+	}
+
+	const n = 20
+	client.Transport = New(WithSequence(), WithSerializedOutput(), WithPrefix("[p] "), WithVerbosity(3))
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(fmt.Sprintf("%v/%v", url, i))
+			if err != nil {
+				t.Errorf("client.Get = %v", err)
+				return
+			}
+			io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	// Each log call holds one request, its response, and its body, with
+	// the prefix on every line.
+	if len(logged) != n {
+		t.Fatalf("got %v log calls, want %v", len(logged), n)
+	}
+	re := regexp.MustCompile(`^\[p\] # request (#\d{4})\n\[p\] curl \\\n\[p\]   \S+/(\d+)\n\[p\] # response (#\d{4}): HTTP/1.1 200 OK in \S+\n(?:\[p\] # [^\n]*\n)*\[p\] #\n\[p\] # body of /(\d+)$`)
+	for _, s := range logged {
+		if m := re.FindStringSubmatch(s); m == nil || m[1] != m[3] || m[2] != m[4] {
+			t.Errorf("log call = %q, want one complete request", s)
+		}
+	}
+}
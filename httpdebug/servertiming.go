@@ -0,0 +1,174 @@
+package httpdebug
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerTimingMetric is a single metric from a Server-Timing response header.
+type ServerTimingMetric struct {
+	Name        string
+	Duration    time.Duration
+	HasDuration bool
+	Description string
+}
+
+// String returns the metric in a human-readable form.
+func (m ServerTimingMetric) String() string {
+	return m.format(FormatDuration)
+}
+
+// format returns the metric with its duration formatted by duration.
+func (m ServerTimingMetric) format(duration func(time.Duration) string) string {
+	s := m.Name
+	if m.HasDuration {
+		s += "=" + duration(m.Duration)
+	}
+	if m.Description != "" {
+		s += " (" + m.Description + ")"
+	}
+	return s
+}
+
+// WithServerTiming is a CurlTransportOption that reports the client-side
+// round-trip time of each request, split into the time spent before the
+// request hit the wire and the time spent waiting for the server, together
+// with a breakdown of any Server-Timing metrics returned by the server.
+func WithServerTiming() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.ServerTiming = true
+	}
+}
+
+// ParseServerTiming parses all Server-Timing headers in h, for example:
+//
+//	Server-Timing: db;dur=53.2;desc="Database", cache;desc=hit, app;dur=47
+//
+// Malformed metrics are skipped.
+func ParseServerTiming(h http.Header) []ServerTimingMetric {
+	var metrics []ServerTimingMetric
+	for _, value := range h.Values("Server-Timing") {
+		for _, metric := range splitQuoted(value, ',') {
+			params := splitQuoted(metric, ';')
+			m := ServerTimingMetric{Name: strings.TrimSpace(params[0])}
+			if m.Name == "" {
+				continue
+			}
+			for _, param := range params[1:] {
+				key, val, _ := strings.Cut(param, "=")
+				val = strings.TrimSpace(val)
+				if uq, err := strconv.Unquote(val); err == nil {
+					val = uq
+				}
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "dur":
+					if ms, err := strconv.ParseFloat(val, 64); err == nil && !m.HasDuration {
+						m.Duration = time.Duration(ms * float64(time.Millisecond))
+						m.HasDuration = true
+					}
+				case "desc":
+					if m.Description == "" {
+						m.Description = val
+					}
+				}
+			}
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics
+}
+
+// splitQuoted splits s on sep, ignoring any sep within double quotes.
+func splitQuoted(s string, sep rune) []string {
+	var parts []string
+	var inQuotes, escaped bool
+	start := 0
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// phaseTrace records when the phases of a single round trip occurred.
+type phaseTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+// trace returns a context that records the request phases into p,
+// preserving any ClientTrace already present in ctx.
+func (p *phaseTrace) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			p.mu.Lock()
+			p.wroteRequest = time.Now()
+			p.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			p.mu.Lock()
+			p.firstByte = time.Now()
+			p.mu.Unlock()
+		},
+	})
+}
+
+// timings summarizes where the time of a round trip was spent.
+type timings struct {
+	// Client is the total time spent in the round trip.
+	Client time.Duration
+	// PreWire is the time spent before the request was fully written
+	// to the wire: waiting for a connection, dialing, proxies, and TLS.
+	PreWire time.Duration
+	// Server is the time from the request being written until the first
+	// response byte arrived: network latency plus server processing.
+	Server time.Duration
+	// HasPhases reports whether PreWire and Server are known. They are
+	// unknown when the underlying transport does not support httptrace.
+	HasPhases bool
+}
+
+// timings returns the timings of a round trip that ended at end.
+func (p *phaseTrace) timings(end time.Time) timings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := timings{Client: end.Sub(p.start)}
+	if !p.wroteRequest.IsZero() && !p.firstByte.IsZero() {
+		result.PreWire = p.wroteRequest.Sub(p.start)
+		result.Server = p.firstByte.Sub(p.wroteRequest)
+		result.HasPhases = true
+	}
+	return result
+}
+
+// timingReport returns the client-side timings followed by the
+// Server-Timing breakdown of resp, labeled with the request's label
+// (see Entry.label).
+func (t *CurlTransport) timingReport(label string, resp *http.Response, tm timings) string {
+	line := fmt.Sprintf("# timing%v: client=%v", label, t.duration(tm.Client))
+	if tm.HasPhases {
+		line += fmt.Sprintf(" pre_wire=%v server=%v", t.duration(tm.PreWire), t.duration(tm.Server))
+	}
+	lines := []string{line}
+	for _, m := range ParseServerTiming(resp.Header) {
+		lines = append(lines, "#   server "+m.format(t.duration))
+	}
+	return strings.Join(lines, "\n")
+}
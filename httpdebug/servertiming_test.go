@@ -0,0 +1,133 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithServerTiming(t *testing.T) {
+	if ct := New(WithServerTiming()); !ct.ServerTiming {
+		t.Error("WithServerTiming did not set ServerTiming")
+	}
+}
+
+func TestParseServerTiming(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []ServerTimingMetric
+	}{
+		{
+			name: "no header",
+		},
+		{
+			name:   "single metric",
+			values: []string{"db;dur=53.2"},
+			want:   []ServerTimingMetric{{Name: "db", Duration: 53200 * time.Microsecond, HasDuration: true}},
+		},
+		{
+			name:   "multiple metrics and headers",
+			values: []string{`db;dur=53;desc="Database, primary", cache;desc=hit`, " app ; DUR = 47.5 ", ", ;dur=1"},
+			want: []ServerTimingMetric{
+				{Name: "db", Duration: 53 * time.Millisecond, HasDuration: true, Description: "Database, primary"},
+				{Name: "cache", Description: "hit"},
+				{Name: "app", Duration: 47500 * time.Microsecond, HasDuration: true},
+			},
+		},
+		{
+			name:   "first duplicate param wins, bad duration ignored",
+			values: []string{"total;dur=abc;dur=10;dur=20;desc=a;desc=b"},
+			want:   []ServerTimingMetric{{Name: "total", Duration: 10 * time.Millisecond, HasDuration: true, Description: "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for _, v := range tt.values {
+				h.Add("Server-Timing", v)
+			}
+			if got := ParseServerTiming(h); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseServerTiming = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimingReport(t *testing.T) {
+	resp := &http.Response{Header: http.Header{
+		"Server-Timing": []string{`db;dur=53;desc="Database", cache`},
+	}}
+
+	tests := []struct {
+		name string
+		tm   timings
+		want string
+	}{
+		{
+			name: "without phases",
+			tm:   timings{Client: 120 * time.Millisecond},
+			want: `# timing: client=120ms
+#   server db=53ms (Database)
+#   server cache`,
+		},
+		{
+			name: "with phases",
+			tm:   timings{Client: 120 * time.Millisecond, PreWire: 15 * time.Millisecond, Server: 100 * time.Millisecond, HasPhases: true},
+			want: `# timing: client=120ms pre_wire=15ms server=100ms
+#   server db=53ms (Database)
+#   server cache`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New().timingReport("", resp, tt.tm); got != tt.want {
+				t.Errorf("timingReport =\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhaseTrace_timings(t *testing.T) {
+	start := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := &phaseTrace{start: start}
+	if got, want := p.timings(start.Add(time.Second)), (timings{Client: time.Second}); got != want {
+		t.Errorf("timings = %+v, want %+v", got, want)
+	}
+
+	p.wroteRequest = start.Add(100 * time.Millisecond)
+	p.firstByte = start.Add(900 * time.Millisecond)
+	want := timings{Client: time.Second, PreWire: 100 * time.Millisecond, Server: 800 * time.Millisecond, HasPhases: true}
+	if got := p.timings(start.Add(time.Second)); got != want {
+		t.Errorf("timings = %+v, want %+v", got, want)
+	}
+}
+
+func TestRoundTrip_ServerTiming(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server-Timing", "app;dur=1")
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithServerTiming())
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(logged) != 2 || !strings.HasPrefix(logged[1], "# timing: client=") || !strings.Contains(logged[1], " pre_wire=") || !strings.HasSuffix(logged[1], "\n#   server app=1ms") {
+		t.Errorf("logged = %#v, want curl command followed by timing report", logged)
+	}
+}
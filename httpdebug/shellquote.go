@@ -0,0 +1,40 @@
+package httpdebug
+
+import "strings"
+
+// shellQuote returns s quoted as a single word for a POSIX shell (sh,
+// bash, or zsh). It is enclosed in single quotes, within which every
+// character (including newlines, '$', '`', and '\') is taken literally.
+// A single quote cannot appear within them, so each one is written as
+//
+//	'\''
+//
+// instead: the quotes are closed, an escaped quote follows, and the
+// quotes are reopened.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellWord returns s unchanged if it is a non-empty word that no POSIX
+// shell treats specially (such as a URL without a query), and
+// shellQuote(s) otherwise.
+func shellWord(s string) string {
+	if s == "" || s[0] == '=' {
+		return shellQuote(s)
+	}
+	for i := 0; i < len(s); i++ {
+		if !isShellSafe(s[i]) {
+			return shellQuote(s)
+		}
+	}
+	return s
+}
+
+// isShellSafe reports whether c never needs quoting in a shell word.
+func isShellSafe(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-_./:@%+=,", c) >= 0
+}
@@ -0,0 +1,150 @@
+package httpdebug
+
+import (
+	"bytes"
+	"net/http"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "empty string", s: "", want: `''`},
+		{name: "no single quotes", s: "no single quotes", want: `'no single quotes'`},
+		{name: "one single quote", s: `I said, "I'd like that."`, want: `'I said, "I'\''d like that."'`},
+		{name: "multiple single quotes", s: `'I'd'`, want: `''\''I'\''d'\'''`},
+		{name: "shell syntax", s: "$HOME `id` \\n\n!", want: "'$HOME `id` \\n\n!'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shellQuote(tt.s); got != tt.want {
+				t.Errorf("shellQuote(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellWord(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "", want: `''`},
+		{s: "https://user@example.com:8443/a/b-c_d.e%20f,g+h", want: "https://user@example.com:8443/a/b-c_d.e%20f,g+h"},
+		{s: "/foo?bar=1&baz=2", want: `'/foo?bar=1&baz=2'`},
+		{s: "=cmd", want: `'=cmd'`},
+		{s: "~user", want: `'~user'`},
+		{s: "a*b", want: `'a*b'`},
+	}
+
+	for _, tt := range tests {
+		if got := shellWord(tt.s); got != tt.want {
+			t.Errorf("shellWord(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+// shellSplit splits s into words as a POSIX shell does for the quoting
+// that shellQuote and shellWord produce: single-quoted strings, backslash
+// escapes, backslash-newline continuations, and unquoted characters
+// separated by blanks. It reports false for anything it does not handle.
+func shellSplit(s string) ([]string, bool) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case ' ', '\t', '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, false
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case '\\':
+			if i+1 == len(s) {
+				return nil, false
+			}
+			i++
+			if s[i] != '\n' {
+				word.WriteByte(s[i])
+				inWord = true
+			}
+		default:
+			if !isShellSafe(c) && c != '=' {
+				return nil, false
+			}
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, true
+}
+
+func FuzzShellQuote(f *testing.F) {
+	for _, s := range []string{"", "a", "'", `'\''`, "$(id)", "`id`", "a\nb", "/foo?a=1&b=2", "=x", "\x00\xff"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, quote := range []func(string) string{shellQuote, shellWord} {
+			q := quote(s)
+			words, ok := shellSplit(q)
+			if !ok || len(words) != 1 || words[0] != s {
+				t.Fatalf("%q quoted as %v, which splits into %q (%v)", s, q, words, ok)
+			}
+		}
+	})
+}
+
+// TestDumpRequestAsCurl_Shells runs the curl output with each available
+// shell, with curl replaced by a function that prints its arguments.
+func TestDumpRequestAsCurl_Shells(t *testing.T) {
+	body := "line 1\nit's $HOME and `id` and $(id) \\ \"quoted\"\n"
+	req, _ := http.NewRequest("POST", "https://example.com/search?q=a+b&sort=*", strings.NewReader(body))
+	req.Header.Set("X-Note", `don't "expand" $PATH`)
+	ct := New(WithTransport(&http.Transport{}))
+	cmd, err := ct.dumpRequestAsCurl(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-X", "POST", "https://example.com/search?q=a+b&sort=*", "-H", `X-Note: don't "expand" $PATH`, "--data-raw", body}
+
+	var ran bool
+	for _, shell := range []string{"sh", "bash", "dash", "zsh"} {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		ran = true
+		script := "curl() { for arg in \"$@\"; do printf '%s\\0' \"$arg\"; done; }\n" + cmd + "\n"
+		out, err := exec.Command(path, "-c", script).Output()
+		if err != nil {
+			t.Errorf("%v: %v", shell, err)
+			continue
+		}
+		got := strings.Split(string(bytes.TrimSuffix(out, []byte{0})), "\x00")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v ran curl with %q, want %q", shell, got, want)
+		}
+	}
+	if !ran {
+		t.Skip("no shell found")
+	}
+}
@@ -0,0 +1,57 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WithSkewThreshold is a CurlTransportOption that warns whenever the
+// Date header of a response differs from the local clock by more than
+// threshold. Clock skew breaks signed requests and token validation and
+// is rarely suspected first.
+// A threshold of zero or less disables the check.
+func WithSkewThreshold(threshold time.Duration) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.SkewThreshold = threshold
+	}
+}
+
+// clockSkew returns how far the server clock (as reported by the Date
+// header of resp) is ahead of the local clock. Because the server may
+// have generated the header at any time between sent and received, and
+// the header only has a resolution of one second, only skew outside of
+// that window is reported.
+func clockSkew(resp *http.Response, sent, received time.Time) (time.Duration, bool) {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, false
+	}
+
+	switch {
+	case date.Before(sent.Truncate(time.Second)):
+		return date.Sub(sent.Truncate(time.Second)), true
+	case date.After(received):
+		return date.Sub(received), true
+	}
+	return 0, true
+}
+
+// checkSkew logs a warning to out if the clock skew indicated by resp
+// exceeds the transport's SkewThreshold.
+func (t *CurlTransport) checkSkew(out *output, resp *http.Response, sent, received time.Time) {
+	skew, ok := clockSkew(resp, sent, received)
+	if !ok {
+		return
+	}
+
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+		skew = -skew
+	}
+	if skew > t.SkewThreshold {
+		out.log(fmt.Sprintf("# WARNING: server clock is %v %v the local clock (Date: %v)",
+			skew.Round(time.Second), direction, resp.Header.Get("Date")))
+	}
+}
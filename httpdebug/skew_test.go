@@ -0,0 +1,77 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithSkewThreshold(t *testing.T) {
+	if ct := New(WithSkewThreshold(time.Minute)); ct.SkewThreshold != time.Minute {
+		t.Errorf("SkewThreshold = %v, want %v", ct.SkewThreshold, time.Minute)
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	sent := time.Date(2022, 1, 2, 3, 4, 5, 500e6, time.UTC)
+	received := sent.Add(2 * time.Second)
+
+	tests := []struct {
+		name   string
+		date   string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "missing Date"},
+		{name: "invalid Date", date: "yesterday"},
+		{name: "truncated to the second", date: "Sun, 02 Jan 2022 03:04:05 GMT", wantOK: true},
+		{name: "within round trip", date: "Sun, 02 Jan 2022 03:04:06 GMT", wantOK: true},
+		{name: "server ahead", date: "Sun, 02 Jan 2022 03:09:07 GMT", want: 4*time.Minute + 59500*time.Millisecond, wantOK: true},
+		{name: "server behind", date: "Sun, 02 Jan 2022 03:02:05 GMT", want: -2 * time.Minute, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.date != "" {
+				resp.Header.Set("Date", tt.date)
+			}
+			got, ok := clockSkew(resp, sent, received)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("clockSkew = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_SkewWarning(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+
+	date := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", date)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithSkewThreshold(time.Minute))
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	// Allow for the request straddling a second boundary.
+	want := []string{
+		fmt.Sprintf("# WARNING: server clock is 1h0m0s behind the local clock (Date: %v)", date),
+		fmt.Sprintf("# WARNING: server clock is 1h0m1s behind the local clock (Date: %v)", date),
+	}
+	if len(logged) != 2 || (logged[1] != want[0] && logged[1] != want[1]) {
+		t.Errorf("logged = %#v, want warning %q", logged, want[0])
+	}
+}
@@ -0,0 +1,145 @@
+package httpdebug
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// Storage reads and writes named files, such as cassettes, HTTP
+// Archives, and capture archives, so that fixtures can live on the local
+// filesystem (see Dir) or in object storage (see ObjectStorage) and be
+// pulled and pushed by CI. Names are slash-separated paths.
+//
+// ReadFile must return an error wrapping fs.ErrNotExist if the named
+// file does not exist. Implementations must be safe for concurrent use.
+type Storage interface {
+	ReadFile(ctx context.Context, name string) ([]byte, error)
+	WriteFile(ctx context.Context, name string, data []byte) error
+}
+
+// Dir is a Storage backed by the local filesystem, rooted at the
+// directory. An empty Dir resolves names relative to the current
+// directory, and absolute names are used as is.
+type Dir string
+
+var _ Storage = Dir("")
+
+// path returns the filesystem path of name.
+func (d Dir) path(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(name))
+}
+
+// ReadFile implements the Storage interface.
+func (d Dir) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(d.path(name))
+}
+
+// WriteFile implements the Storage interface. The file is replaced
+// atomically, so that readers never see a partially-written file.
+func (d Dir) WriteFile(ctx context.Context, name string, data []byte) error {
+	path := d.path(name)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ObjectStorage is a Storage backed by an object store that serves
+// objects with plain HTTP GET and PUT requests below BaseURL, such as
+// Google Cloud Storage and Amazon S3 (see NewGCSStorage and NewS3Storage)
+// or a simple WebDAV or artifact server.
+type ObjectStorage struct {
+	// BaseURL is the URL that object names are resolved against,
+	// e.g. "https://storage.googleapis.com/my-bucket/fixtures".
+	BaseURL string
+	// Client makes the requests, and is responsible for authenticating them.
+	// Default: http.DefaultClient.
+	Client *http.Client
+}
+
+var _ Storage = &ObjectStorage{}
+
+// NewGCSStorage returns an ObjectStorage for the Google Cloud Storage
+// bucket. The client must authenticate its requests, for example an
+// *http.Client returned by oauth2.NewClient with a token source from
+// golang.org/x/oauth2/google.
+func NewGCSStorage(client *http.Client, bucket string) *ObjectStorage {
+	return &ObjectStorage{
+		BaseURL: "https://storage.googleapis.com/" + url.PathEscape(bucket),
+		Client:  client,
+	}
+}
+
+// url returns the URL of the object name.
+func (s *ObjectStorage) url(name string) (string, error) {
+	return url.JoinPath(s.BaseURL, name)
+}
+
+// do sends a request for the object name and returns the response body
+// of a successful request.
+func (s *ObjectStorage) do(ctx context.Context, method, name string, data []byte) ([]byte, error) {
+	u, err := s.url(name)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("httpdebug: %v %v: %w", method, u, fs.ErrNotExist)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("httpdebug: %v %v: %v", method, u, resp.Status)
+	case err != nil:
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ReadFile implements the Storage interface.
+func (s *ObjectStorage) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	return s.do(ctx, http.MethodGet, name, nil)
+}
+
+// WriteFile implements the Storage interface.
+func (s *ObjectStorage) WriteFile(ctx context.Context, name string, data []byte) error {
+	if data == nil {
+		data = []byte{}
+	}
+	_, err := s.do(ctx, http.MethodPut, name, data)
+	return err
+}
@@ -0,0 +1,162 @@
+package httpdebug
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// objectServer is a minimal in-memory object store for tests.
+type objectServer struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (o *objectServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		buf, ok := o.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf)
+	case http.MethodPut:
+		buf, _ := io.ReadAll(r.Body)
+		o.objects[r.URL.Path] = buf
+	default:
+		http.Error(w, "nope", http.StatusMethodNotAllowed)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	objects := &objectServer{objects: map[string][]byte{}}
+	server := httptest.NewServer(objects)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		storage Storage
+	}{
+		{name: "Dir", storage: Dir(t.TempDir())},
+		{name: "ObjectStorage", storage: &ObjectStorage{BaseURL: server.URL + "/bucket/prefix"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := tt.storage.ReadFile(ctx, "missing.json"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("ReadFile(missing) = %v, want fs.ErrNotExist", err)
+			}
+			if err := tt.storage.WriteFile(ctx, "a.json", []byte("one")); err != nil {
+				t.Fatalf("WriteFile = %v", err)
+			}
+			if err := tt.storage.WriteFile(ctx, "a.json", []byte("two")); err != nil {
+				t.Fatalf("WriteFile = %v", err)
+			}
+			if got, err := tt.storage.ReadFile(ctx, "a.json"); err != nil || string(got) != "two" {
+				t.Errorf("ReadFile = (%q, %v), want two", got, err)
+			}
+		})
+	}
+
+	if _, ok := objects.objects["/bucket/prefix/a.json"]; !ok {
+		t.Errorf("objects = %v, want /bucket/prefix/a.json", objects.objects)
+	}
+}
+
+func TestObjectStorage_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	s := &ObjectStorage{BaseURL: server.URL}
+	err := s.WriteFile(context.Background(), "a.json", nil)
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden") || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("WriteFile = %v, want 403 error", err)
+	}
+}
+
+func TestNewGCSStorage(t *testing.T) {
+	s := NewGCSStorage(http.DefaultClient, "my bucket")
+	if got, err := s.url("fixtures/a.json"); err != nil || got != "https://storage.googleapis.com/my%20bucket/fixtures/a.json" {
+		t.Errorf("url = (%v, %v)", got, err)
+	}
+}
+
+func TestCassette_Storage(t *testing.T) {
+	objects := &objectServer{objects: map[string][]byte{}}
+	server := httptest.NewServer(objects)
+	defer server.Close()
+	s := &ObjectStorage{BaseURL: server.URL}
+
+	ctx := context.Background()
+	if _, err := LoadCassetteFrom(ctx, s, "api.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadCassetteFrom(missing) = %v, want fs.ErrNotExist", err)
+	}
+
+	c := &Cassette{Path: "api.json", Storage: s, Interactions: []*Interaction{{
+		Request:  CassetteRequest{Method: "GET", URL: "http://example.com/"},
+		Response: CassetteResponse{StatusCode: http.StatusOK, Body: CassetteBody("hi")},
+	}}}
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save = %v", err)
+	}
+	got, err := LoadCassetteFrom(ctx, s, "api.json")
+	if err != nil {
+		t.Fatalf("LoadCassetteFrom = %v", err)
+	}
+	if got.Storage != s || !reflect.DeepEqual(got.Interactions, c.Interactions) {
+		t.Errorf("LoadCassetteFrom = %+v, want %+v", got, c)
+	}
+}
+
+func TestHAR_Storage(t *testing.T) {
+	s := Dir(t.TempDir())
+	ctx := context.Background()
+	h := &HAR{Log: HARLog{Version: "1.2", Entries: []HAREntry{{Request: HARRequest{Method: "GET", URL: "http://example.com/"}}}}}
+	if err := WriteHAR(ctx, s, "a.har", h); err != nil {
+		t.Fatalf("WriteHAR = %v", err)
+	}
+	got, err := ReadHARFrom(ctx, s, "a.har")
+	if err != nil {
+		t.Fatalf("ReadHARFrom = %v", err)
+	}
+	if !reflect.DeepEqual(got.Log.Entries[0].Request.URL, "http://example.com/") {
+		t.Errorf("ReadHARFrom = %+v", got)
+	}
+	if _, err := ReadHAR(filepath.Join(string(s), "a.har")); err != nil {
+		t.Errorf("ReadHAR = %v", err)
+	}
+}
+
+func TestCapture_Archive(t *testing.T) {
+	s := Dir(t.TempDir())
+	c := NewCapture(2)
+	ctx := context.Background()
+	if err := c.Archive(ctx, s, "empty.json"); err != nil {
+		t.Fatalf("Archive = %v", err)
+	}
+	if buf, _ := s.ReadFile(ctx, "empty.json"); string(buf) != "[]\n" {
+		t.Errorf("empty archive = %q, want []", buf)
+	}
+
+	c.add(&Exchange{Request: &Entry{Method: "GET", URL: "/a"}, StatusCode: 200})
+	if err := c.Archive(ctx, s, "one.json"); err != nil {
+		t.Fatalf("Archive = %v", err)
+	}
+	if buf, _ := s.ReadFile(ctx, "one.json"); !strings.Contains(string(buf), `"url": "/a"`) {
+		t.Errorf("archive = %s, want /a", buf)
+	}
+}
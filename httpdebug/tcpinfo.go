@@ -0,0 +1,78 @@
+package httpdebug
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TCPStats holds socket-level statistics for a connection, allowing
+// network-level slowness to be told apart from server-side slowness.
+type TCPStats struct {
+	// RTT is the smoothed round-trip time estimated by the kernel.
+	RTT time.Duration
+	// RTTVar is the round-trip time variance.
+	RTTVar time.Duration
+	// Retransmits is the number of unrecovered retransmission timeouts.
+	Retransmits int
+	// TotalRetransmits is the total number of retransmitted segments.
+	TotalRetransmits int
+}
+
+// String returns the stats as a curl-style comment.
+func (s *TCPStats) String() string {
+	return s.format(FormatDuration)
+}
+
+// format returns the statistics with their durations formatted by
+// duration.
+func (s *TCPStats) format(duration func(time.Duration) string) string {
+	return fmt.Sprintf("# tcp_info: rtt=%v rttvar=%v retransmits=%v total_retrans=%v",
+		duration(s.RTT), duration(s.RTTVar), s.Retransmits, s.TotalRetransmits)
+}
+
+// WithTCPInfo is a CurlTransportOption that reports the TCP_INFO
+// statistics of the underlying connection after each exchange.
+// It is only supported on Linux and is ignored elsewhere.
+func WithTCPInfo() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.TCPInfo = true
+	}
+}
+
+// connRecorder records the connection used by a request.
+type connRecorder struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// trace returns a context that records the connection used by the
+// request into r, preserving any ClientTrace already present in ctx.
+func (r *connRecorder) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mu.Lock()
+			r.conn = info.Conn
+			r.mu.Unlock()
+		},
+	})
+}
+
+// tcpStats returns the TCP statistics of the recorded connection.
+func (r *connRecorder) tcpStats() (*TCPStats, bool) {
+	r.mu.Lock()
+	conn := r.conn
+	r.mu.Unlock()
+
+	if tc, ok := conn.(*tls.Conn); ok {
+		conn = tc.NetConn()
+	}
+	if conn == nil {
+		return nil, false
+	}
+	return tcpStats(conn)
+}
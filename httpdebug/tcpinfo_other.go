@@ -0,0 +1,10 @@
+//go:build !linux
+
+package httpdebug
+
+import "net"
+
+// tcpStats is not supported on this platform.
+func tcpStats(conn net.Conn) (*TCPStats, bool) {
+	return nil, false
+}
@@ -0,0 +1,50 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithTCPInfo(t *testing.T) {
+	if ct := New(WithTCPInfo()); !ct.TCPInfo {
+		t.Error("WithTCPInfo did not set TCPInfo")
+	}
+}
+
+func TestTCPStats_String(t *testing.T) {
+	s := &TCPStats{RTT: 1500 * time.Microsecond, RTTVar: 250 * time.Microsecond, Retransmits: 1, TotalRetransmits: 3}
+	want := "# tcp_info: rtt=1.5ms rttvar=250µs retransmits=1 total_retrans=3"
+	if got := s.String(); got != want {
+		t.Errorf("TCPStats.String = %q, want %q", got, want)
+	}
+}
+
+func TestRoundTrip_TCPInfo(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("TCP_INFO is only supported on Linux")
+	}
+
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	client.Transport = New(WithTCPInfo())
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(logged) != 2 || !strings.HasPrefix(logged[1], "# tcp_info: rtt=") {
+		t.Errorf("logged = %#v, want curl command followed by tcp_info", logged)
+	}
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Run displays the viewer on the terminal in and out until the user
+// quits or ctx is done. The terminal is put into raw mode and the
+// alternate screen for the duration of Run.
+func (v *Viewer) Run(ctx context.Context, in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("tui: %w", err)
+	}
+	defer term.Restore(fd, state)
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan []Key)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- parseKeys(buf[:n])
+		}
+	}()
+
+	for {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		draw(out, v.Render(width, height))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-v.Changed():
+		case ks, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, k := range ks {
+				if k == "c" && !v.isTyping() {
+					if err := v.CopyCurl(out); err != nil {
+						return err
+					}
+					continue
+				}
+				if v.HandleKey(k) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// isTyping reports whether keys are being typed into the filter or a
+// marker name.
+func (v *Viewer) isTyping() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.editing || v.marking
+}
+
+// draw writes rows to the terminal out, replacing the screen.
+func draw(out io.Writer, rows []string) {
+	var sb strings.Builder
+	sb.WriteString("\x1b[H")
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString(row)
+		sb.WriteString("\x1b[K")
+	}
+	sb.WriteString("\x1b[J")
+	io.WriteString(out, sb.String())
+}
@@ -0,0 +1,503 @@
+// Package tui implements an interactive terminal viewer for the exchanges
+// captured by httpdebug. It is used by the "httpdebug tui" command and
+// can be embedded in other programs:
+//
+//	v := tui.New()
+//	go func() {
+//		for range ticker.C {
+//			v.Set(ct.Captured())
+//		}
+//	}()
+//	err := v.Run(ctx, os.Stdin, os.Stdout)
+//
+// The list of exchanges can be filtered by typing "/" followed by text
+// to match against the method, URL, and status, Enter opens the detail
+// view of the selected exchange, "c" copies its curl command to the
+// clipboard of the terminal, and "m" followed by a name inserts a marker
+// into the timeline.
+package tui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	dbg "github.com/gmlewis/go-httpdebug/httpdebug"
+)
+
+// Key is a key press, either a printable character (e.g. "q") or the
+// name of a special key (e.g. KeyUp).
+type Key string
+
+// The special keys recognized by a Viewer.
+const (
+	KeyUp        Key = "up"
+	KeyDown      Key = "down"
+	KeyPageUp    Key = "pgup"
+	KeyPageDown  Key = "pgdown"
+	KeyHome      Key = "home"
+	KeyEnd       Key = "end"
+	KeyEnter     Key = "enter"
+	KeyEscape    Key = "esc"
+	KeyBackspace Key = "backspace"
+	KeyCtrlC     Key = "ctrl+c"
+)
+
+// Viewer is a scrollable, filterable view of exchanges, newest last.
+// Its methods are safe for concurrent use, so that exchanges can be
+// added while it is running.
+type Viewer struct {
+	// OnMark, if non-nil, is called with the name of each marker inserted
+	// with the "m" key, e.g. to forward it to the capture being viewed
+	// (see httpdebug.Capture.Mark). By default, the marker is added to
+	// the viewer itself.
+	OnMark func(name string)
+
+	mu        sync.Mutex
+	exchanges []dbg.Exchange
+	filter    string
+	editing   bool // whether keys are being typed into the filter
+	marking   bool // whether keys are being typed into a marker name
+	mark      string
+	cursor    int  // index into visible() of the selected exchange
+	follow    bool // whether the cursor follows new exchanges
+	offset    int  // first visible row of the list
+	detail    bool
+	scroll    int // first visible row of the detail view
+	status    string
+	height    int // rows available for the list or detail view
+	changed   chan struct{}
+}
+
+// New returns an empty Viewer.
+func New() *Viewer {
+	return &Viewer{follow: true, height: 20, changed: make(chan struct{}, 1)}
+}
+
+// Add appends exchanges to the viewer.
+func (v *Viewer) Add(exchanges ...dbg.Exchange) {
+	v.mu.Lock()
+	v.exchanges = append(v.exchanges, exchanges...)
+	v.update()
+	v.mu.Unlock()
+	v.notify()
+}
+
+// Set replaces the exchanges in the viewer, oldest first, e.g. with the
+// result of CurlTransport.Captured.
+func (v *Viewer) Set(exchanges []dbg.Exchange) {
+	v.mu.Lock()
+	v.exchanges = append([]dbg.Exchange(nil), exchanges...)
+	v.update()
+	v.mu.Unlock()
+	v.notify()
+}
+
+// Changed returns a channel that receives a value whenever the exchanges
+// change, so that an embedding program knows when to render again.
+func (v *Viewer) Changed() <-chan struct{} {
+	return v.changed
+}
+
+func (v *Viewer) notify() {
+	select {
+	case v.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Selected returns the selected exchange, if any.
+func (v *Viewer) Selected() (dbg.Exchange, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.selected()
+}
+
+func (v *Viewer) selected() (dbg.Exchange, bool) {
+	visible := v.visible()
+	if len(visible) == 0 {
+		return dbg.Exchange{}, false
+	}
+	return v.exchanges[visible[v.cursor]], true
+}
+
+// visible returns the indexes of the exchanges matching the filter.
+// The caller must hold v.mu.
+func (v *Viewer) visible() []int {
+	var result []int
+	filter := strings.ToLower(v.filter)
+	for i, x := range v.exchanges {
+		if filter == "" || strings.Contains(strings.ToLower(summary(x)), filter) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// update keeps the cursor and scroll offset within bounds after a change.
+// The caller must hold v.mu.
+func (v *Viewer) update() {
+	n := len(v.visible())
+	if v.follow && !v.detail || v.cursor >= n {
+		v.cursor = n - 1
+	}
+	v.cursor = max(v.cursor, 0)
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.height > 0 && v.cursor >= v.offset+v.height {
+		v.offset = v.cursor - v.height + 1
+	}
+}
+
+// move moves the cursor by delta rows.
+func (v *Viewer) move(delta int) {
+	n := len(v.visible())
+	v.cursor = min(max(v.cursor+delta, 0), max(n-1, 0))
+	v.follow = v.cursor == n-1
+	v.update()
+}
+
+// HandleKey updates the viewer for the key press k. It reports whether
+// the viewer should quit.
+func (v *Viewer) HandleKey(k Key) (quit bool) {
+	quit, mark := v.handleKey(k)
+	if mark == "" {
+		return quit
+	}
+	if v.OnMark != nil {
+		v.OnMark(mark)
+	} else {
+		v.Add(dbg.Exchange{Marker: &dbg.Marker{Time: time.Now(), Name: mark}})
+	}
+	return quit
+}
+
+// handleKey updates the viewer for the key press k. It reports whether
+// the viewer should quit and the name of the marker to insert, if any.
+func (v *Viewer) handleKey(k Key) (quit bool, mark string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.status = ""
+
+	if k == KeyCtrlC {
+		return true, ""
+	}
+
+	if v.marking {
+		switch k {
+		case KeyEnter:
+			v.marking = false
+			mark, v.mark = strings.TrimSpace(v.mark), ""
+			if mark != "" {
+				v.status = fmt.Sprintf("inserted marker %q", mark)
+			}
+			return false, mark
+		case KeyEscape:
+			v.marking = false
+			v.mark = ""
+		default:
+			v.mark = edit(v.mark, k)
+		}
+		return false, ""
+	}
+
+	if v.editing {
+		switch k {
+		case KeyEnter:
+			v.editing = false
+		case KeyEscape:
+			v.editing = false
+			v.filter = ""
+		default:
+			v.filter = edit(v.filter, k)
+		}
+		v.follow = true
+		v.update()
+		return false, ""
+	}
+
+	if v.detail {
+		switch k {
+		case "q", KeyEscape, KeyBackspace:
+			v.detail = false
+		case KeyUp, "k":
+			v.scroll = max(v.scroll-1, 0)
+		case KeyDown, "j":
+			v.scroll++
+		case KeyPageUp:
+			v.scroll = max(v.scroll-v.height, 0)
+		case KeyPageDown, " ":
+			v.scroll += v.height
+		case KeyHome, "g":
+			v.scroll = 0
+		}
+		return false, ""
+	}
+
+	switch k {
+	case "q":
+		return true, ""
+	case KeyEscape:
+		v.filter = ""
+		v.follow = true
+		v.update()
+	case "/":
+		v.editing = true
+	case "m":
+		v.marking = true
+	case KeyUp, "k":
+		v.move(-1)
+	case KeyDown, "j":
+		v.move(1)
+	case KeyPageUp:
+		v.move(-v.height)
+	case KeyPageDown, " ":
+		v.move(v.height)
+	case KeyHome, "g":
+		v.move(-len(v.exchanges))
+	case KeyEnd, "G":
+		v.move(len(v.exchanges))
+	case KeyEnter:
+		if _, ok := v.selected(); ok {
+			v.detail = true
+			v.scroll = 0
+		}
+	}
+	return false, ""
+}
+
+// edit returns text after typing the key k, which either deletes the
+// last character or appends a printable one.
+func edit(text string, k Key) string {
+	if k == KeyBackspace {
+		_, size := utf8.DecodeLastRuneInString(text)
+		return text[:len(text)-size]
+	}
+	if utf8.RuneCountInString(string(k)) == 1 {
+		return text + string(k)
+	}
+	return text
+}
+
+// CopyCurl copies the curl command of the selected exchange to the
+// clipboard of the terminal that w writes to, using the OSC 52 escape
+// sequence supported by most terminal emulators.
+func (v *Viewer) CopyCurl(w io.Writer) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	x, ok := v.selected()
+	if !ok || x.Request == nil {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "\x1b]52;c;%v\a", base64.StdEncoding.EncodeToString([]byte(x.Request.Curl)))
+	if err == nil {
+		v.status = "copied curl command to clipboard"
+	}
+	return err
+}
+
+// Render returns the screen for a terminal of the given size, one
+// string per row. Rows are truncated to width.
+func (v *Viewer) Render(width, height int) []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.height = max(height-2, 1)
+	v.update()
+
+	var title string
+	visible := v.visible()
+	switch {
+	case v.detail:
+		title = "httpdebug: exchange detail"
+	case v.filter != "" || v.editing:
+		title = fmt.Sprintf("httpdebug: %v of %v exchanges matching %q", len(visible), len(v.exchanges), v.filter)
+	default:
+		title = fmt.Sprintf("httpdebug: %v exchanges", len(v.exchanges))
+	}
+	rows := []string{"\x1b[1m" + truncate(title, width) + "\x1b[0m"}
+
+	if v.detail {
+		x, _ := v.selected()
+		lines := detail(x)
+		v.scroll = min(v.scroll, max(len(lines)-v.height, 0))
+		for _, line := range lines[v.scroll:min(v.scroll+v.height, len(lines))] {
+			rows = append(rows, truncate(line, width))
+		}
+	} else {
+		for i := v.offset; i < min(v.offset+v.height, len(visible)); i++ {
+			line := truncate(summary(v.exchanges[visible[i]]), width-2)
+			if i == v.cursor {
+				rows = append(rows, "\x1b[7m> "+line+"\x1b[0m")
+			} else {
+				rows = append(rows, "  "+line)
+			}
+		}
+	}
+	for len(rows) < height-1 {
+		rows = append(rows, "")
+	}
+
+	var footer string
+	switch {
+	case v.marking:
+		footer = "marker: " + v.mark
+	case v.editing:
+		footer = "/" + v.filter
+	case v.status != "":
+		footer = v.status
+	case v.detail:
+		footer = "up/down scroll  c copy curl  q back"
+	default:
+		footer = "up/down move  enter detail  / filter  c copy curl  m marker  q quit"
+	}
+	return append(rows, truncate(footer, width))
+}
+
+// summary returns the one-line summary of x shown in the list.
+func summary(x dbg.Exchange) string {
+	if x.Marker != nil {
+		return fmt.Sprintf("%v --- marker: %v ---", x.Marker.Time.Format("15:04:05.000"), x.Marker.Name)
+	}
+	var when, method, url string
+	if x.Request != nil {
+		when = x.Request.Time.Format("15:04:05.000")
+		method, url = x.Request.Method, x.Request.URL
+	}
+	status := fmt.Sprint(x.StatusCode)
+	switch {
+	case x.Err != "":
+		status = "ERR"
+	case x.StatusCode == 0:
+		status = "..."
+	}
+	return fmt.Sprintf("%v %-7v %-3v %8v  %v", when, method, status, dbg.FormatDuration(x.Duration), url)
+}
+
+// detail returns the lines of the detail view of x.
+func detail(x dbg.Exchange) []string {
+	if x.Marker != nil {
+		return []string{"Marker: " + x.Marker.Name, "Time: " + x.Marker.Time.Format(time.RFC3339Nano)}
+	}
+	var lines []string
+	if x.Request != nil {
+		lines = append(lines,
+			x.Request.Method+" "+x.Request.URL,
+			"Time: "+x.Request.Time.Format(time.RFC3339Nano),
+		)
+	}
+	switch {
+	case x.Err != "":
+		lines = append(lines, "Error: "+x.Err)
+	case x.Status != "":
+		lines = append(lines, "Status: "+x.Status)
+	}
+	lines = append(lines, "Duration: "+dbg.FormatDuration(x.Duration), "")
+	if x.Request != nil {
+		lines = append(lines, strings.Split(x.Request.Curl, "\n")...)
+		lines = append(lines, "")
+	}
+
+	keys := make([]string, 0, len(x.Header))
+	for k := range x.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, k+": "+strings.Join(x.Header[k], ", "))
+	}
+	if len(x.Body) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(strings.ToValidUTF8(string(x.Body), "�"), "\n")...)
+		if x.BodyTruncated {
+			lines = append(lines, "(truncated)")
+		}
+	}
+	return lines
+}
+
+// truncate returns s cut to at most width runes, with tabs expanded and
+// other control characters removed, so that it cannot break the layout.
+func truncate(s string, width int) string {
+	var sb strings.Builder
+	var n int
+	for _, r := range s {
+		if n >= width {
+			break
+		}
+		switch {
+		case r == '\t':
+			r = ' '
+		case r < ' ' || r == 0x7f:
+			continue
+		}
+		sb.WriteRune(r)
+		n++
+	}
+	return sb.String()
+}
+
+// parseKeys returns the key presses in buf, as read from a terminal in
+// raw mode.
+func parseKeys(buf []byte) []Key {
+	var keys []Key
+	for len(buf) > 0 {
+		if buf[0] == '\x1b' {
+			if k, n := parseEscape(buf); n > 0 {
+				keys = append(keys, k)
+				buf = buf[n:]
+				continue
+			}
+		}
+		switch buf[0] {
+		case '\r', '\n':
+			keys = append(keys, KeyEnter)
+		case 0x7f, '\b':
+			keys = append(keys, KeyBackspace)
+		case 0x03:
+			keys = append(keys, KeyCtrlC)
+		case '\x1b':
+			keys = append(keys, KeyEscape)
+		default:
+			r, size := utf8.DecodeRune(buf)
+			if r >= ' ' {
+				keys = append(keys, Key(string(r)))
+			}
+			buf = buf[size:]
+			continue
+		}
+		buf = buf[1:]
+	}
+	return keys
+}
+
+// escapes are the escape sequences of the special keys.
+var escapes = map[string]Key{
+	"\x1b[A":  KeyUp,
+	"\x1bOA":  KeyUp,
+	"\x1b[B":  KeyDown,
+	"\x1bOB":  KeyDown,
+	"\x1b[5~": KeyPageUp,
+	"\x1b[6~": KeyPageDown,
+	"\x1b[H":  KeyHome,
+	"\x1b[1~": KeyHome,
+	"\x1b[F":  KeyEnd,
+	"\x1b[4~": KeyEnd,
+}
+
+// parseEscape returns the special key at the start of buf and the length
+// of its escape sequence, or zero if buf does not start with one.
+func parseEscape(buf []byte) (Key, int) {
+	for seq, k := range escapes {
+		if strings.HasPrefix(string(buf), seq) {
+			return k, len(seq)
+		}
+	}
+	return "", 0
+}
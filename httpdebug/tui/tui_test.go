@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	dbg "github.com/gmlewis/go-httpdebug/httpdebug"
+)
+
+func exchange(method, url string, status int) dbg.Exchange {
+	return dbg.Exchange{
+		Request:    &dbg.Entry{Time: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), Method: method, URL: url, Curl: "curl \\\n  " + url},
+		StatusCode: status,
+		Duration:   12 * time.Millisecond,
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("a/\x1b[A\x1b[B\r\x7f\x1b\x03é\x1b[5~\x01"))
+	want := []Key{"a", "/", KeyUp, KeyDown, KeyEnter, KeyBackspace, KeyEscape, KeyCtrlC, "é", KeyPageUp}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys = %q, want %q", got, want)
+	}
+}
+
+func TestViewer_Navigation(t *testing.T) {
+	v := New()
+	v.Add(exchange("GET", "/a", 200), exchange("POST", "/b", 201), exchange("GET", "/c", 500))
+
+	// The cursor follows the newest exchange.
+	if x, _ := v.Selected(); x.Request.URL != "/c" {
+		t.Errorf("Selected = %v, want /c", x.Request.URL)
+	}
+	v.HandleKey(KeyUp)
+	v.HandleKey("k")
+	if x, _ := v.Selected(); x.Request.URL != "/a" {
+		t.Errorf("Selected after up = %v, want /a", x.Request.URL)
+	}
+	// It stays put when new exchanges arrive while scrolled back.
+	v.Add(exchange("GET", "/d", 200))
+	if x, _ := v.Selected(); x.Request.URL != "/a" {
+		t.Errorf("Selected after Add = %v, want /a", x.Request.URL)
+	}
+	v.HandleKey("G")
+	if x, _ := v.Selected(); x.Request.URL != "/d" {
+		t.Errorf("Selected after G = %v, want /d", x.Request.URL)
+	}
+	if v.HandleKey("x") {
+		t.Error("HandleKey(x) = true, want false")
+	}
+	if !v.HandleKey("q") {
+		t.Error("HandleKey(q) = false, want true")
+	}
+}
+
+func TestViewer_Filter(t *testing.T) {
+	v := New()
+	v.Add(exchange("GET", "/users", 200), exchange("POST", "/orders", 201), exchange("GET", "/users/1", 404))
+
+	for _, k := range []Key{"/", "U", "s", "e", "r", "x", KeyBackspace, KeyEnter} {
+		v.HandleKey(k)
+	}
+	rows := v.Render(60, 6)
+	if want := `httpdebug: 2 of 3 exchanges matching "User"`; !strings.Contains(rows[0], want) {
+		t.Errorf("title = %q, want %q", rows[0], want)
+	}
+	if !strings.Contains(rows[1], "/users") || !strings.Contains(rows[2], "/users/1") || rows[3] != "" {
+		t.Errorf("rows = %q, want the two /users exchanges", rows)
+	}
+	if !strings.HasPrefix(rows[2], "\x1b[7m> ") {
+		t.Errorf("rows[2] = %q, want it selected", rows[2])
+	}
+
+	// Escape in the list clears the filter.
+	v.HandleKey(KeyEscape)
+	if rows := v.Render(60, 6); !strings.Contains(rows[0], "httpdebug: 3 exchanges") {
+		t.Errorf("title after Escape = %q", rows[0])
+	}
+}
+
+func TestViewer_Detail(t *testing.T) {
+	v := New()
+	x := exchange("POST", "/items", 201)
+	x.Status = "201 Created"
+	x.Header = map[string][]string{"Content-Type": {"application/json"}}
+	x.Body = []byte("{\"id\":1}\n\x1b[31mred")
+	v.Add(x)
+
+	v.HandleKey(KeyEnter)
+	rows := v.Render(80, 20)
+	for _, want := range []string{"POST /items", "Status: 201 Created", "curl \\", "  /items", "Content-Type: application/json", `{"id":1}`, "[31mred"} {
+		var found bool
+		for _, row := range rows {
+			found = found || row == want || strings.HasPrefix(row, want)
+		}
+		if !found {
+			t.Errorf("detail rows = %q, want %q", rows, want)
+		}
+	}
+	for _, row := range rows[1:] {
+		if strings.Contains(row, "\x1b") {
+			t.Errorf("detail row %q contains an escape character", row)
+		}
+	}
+
+	v.HandleKey(KeyEscape)
+	if rows := v.Render(80, 20); !strings.Contains(rows[1], "POST") || !strings.Contains(rows[1], "201") {
+		t.Errorf("list rows = %q, want the list again", rows)
+	}
+}
+
+func TestViewer_CopyCurl(t *testing.T) {
+	v := New()
+	var buf bytes.Buffer
+	if err := v.CopyCurl(&buf); err != nil || buf.Len() != 0 {
+		t.Errorf("CopyCurl with no exchanges = %v, wrote %q", err, buf.String())
+	}
+
+	v.Add(exchange("GET", "/a", 200))
+	if err := v.CopyCurl(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("curl \\\n  /a")) + "\a"
+	if buf.String() != want {
+		t.Errorf("CopyCurl wrote %q, want %q", buf.String(), want)
+	}
+	if rows := v.Render(80, 5); rows[4] != "copied curl command to clipboard" {
+		t.Errorf("footer = %q", rows[4])
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got, want := truncate("a\tbé\x1b[0mcdef", 6), "a bé[0"; got != want {
+		t.Errorf("truncate = %q, want %q", got, want)
+	}
+}
+
+func TestViewer_Mark(t *testing.T) {
+	v := New()
+	v.Add(exchange("GET", "/a", 200))
+	for _, k := range []Key{"m", "c", "l", "i", "c", "k", "x", KeyBackspace} {
+		v.HandleKey(k)
+	}
+	if rows := v.Render(80, 5); rows[4] != "marker: click" {
+		t.Errorf("footer = %q, want the marker being typed", rows[4])
+	}
+	v.HandleKey(KeyEnter)
+
+	rows := v.Render(80, 5)
+	if !strings.Contains(rows[2], "--- marker: click ---") || !strings.HasPrefix(rows[2], "\x1b[7m> ") {
+		t.Errorf("rows = %q, want the selected marker after /a", rows)
+	}
+	if rows[4] != `inserted marker "click"` {
+		t.Errorf("footer = %q", rows[4])
+	}
+
+	// Escape cancels, and OnMark replaces the default insertion.
+	var marks []string
+	v.OnMark = func(name string) { marks = append(marks, name) }
+	for _, k := range []Key{"m", "x", KeyEscape, "m", "y", KeyEnter} {
+		v.HandleKey(k)
+	}
+	if !reflect.DeepEqual(marks, []string{"y"}) {
+		t.Errorf("OnMark called with %q, want [y]", marks)
+	}
+	if rows := v.Render(80, 5); !strings.Contains(rows[0], "2 exchanges") {
+		t.Errorf("title = %q, want 2 exchanges", rows[0])
+	}
+}
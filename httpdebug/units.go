@@ -0,0 +1,95 @@
+package httpdebug
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatSize returns n bytes as written in human-readable output, in SI
+// units with one decimal below ten and none above, e.g. "512 B", "1.4 kB",
+// or "23 MB".
+func FormatSize(n int64) string {
+	if n < 0 {
+		return "-" + FormatSize(-n)
+	}
+	if n < 1000 {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	v := float64(n)
+	for _, unit := range []string{"kB", "MB", "GB", "TB"} {
+		v /= 1000
+		if s, ok := scaled(v); ok || unit == "TB" {
+			return s + " " + unit
+		}
+	}
+	panic("unreachable")
+}
+
+// FormatDuration returns d as written in human-readable output, with one
+// decimal below ten of a unit and none above, e.g. "87µs", "235ms", or
+// "1.2s", and in whole seconds from a minute up, e.g. "2m5s".
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + FormatDuration(-d)
+	}
+	if d < time.Microsecond {
+		return strconv.FormatInt(int64(d), 10) + "ns"
+	}
+	if d >= time.Minute-time.Second/2 {
+		return d.Round(time.Second).String()
+	}
+	for _, u := range []struct {
+		name string
+		size time.Duration
+	}{{"µs", time.Microsecond}, {"ms", time.Millisecond}, {"s", time.Second}} {
+		if s, ok := scaled(float64(d) / float64(u.size)); ok || u.size == time.Second {
+			return s + u.name
+		}
+	}
+	panic("unreachable")
+}
+
+// scaled returns v with one decimal if it is below ten, or none, and
+// reports whether it is still below 1000 once rounded.
+func scaled(v float64) (string, bool) {
+	prec := 0
+	if v < 9.95 {
+		prec = 1
+	}
+	s := strings.TrimSuffix(strconv.FormatFloat(v, 'f', prec, 64), ".0")
+	return s, v < 999.5
+}
+
+// WithRawUnits is a CurlTransportOption that writes sizes and durations in
+// the log as exact numbers of bytes and seconds, e.g. "1400123 bytes" and
+// "0.235012s", which log pipelines can parse, instead of rounding them for
+// reading (see FormatSize and FormatDuration). This is implied by
+// FormatJSON.
+func WithRawUnits() func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		ct.RawUnits = true
+	}
+}
+
+// rawUnits reports whether sizes and durations are written exactly.
+func (t *CurlTransport) rawUnits() bool {
+	return t.RawUnits || t.Format == FormatJSON
+}
+
+// size returns n bytes as written in the log.
+func (t *CurlTransport) size(n int64) string {
+	if t.rawUnits() {
+		return strconv.FormatInt(n, 10) + " bytes"
+	}
+	return FormatSize(n)
+}
+
+// duration returns d as written in the log. Exact durations, in seconds,
+// can be parsed with time.ParseDuration.
+func (t *CurlTransport) duration(d time.Duration) string {
+	if t.rawUnits() {
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+	}
+	return FormatDuration(d)
+}
@@ -0,0 +1,79 @@
+package httpdebug
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{999, "999 B"},
+		{1000, "1 kB"},
+		{1400, "1.4 kB"},
+		{9960, "10 kB"},
+		{64 << 10, "66 kB"},
+		{999_600, "1 MB"},
+		{1_400_000, "1.4 MB"},
+		{23_000_000, "23 MB"},
+		{5_000_000_000_000_000, "5000 TB"},
+		{-1400, "-1.4 kB"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.n); got != tt.want {
+			t.Errorf("FormatSize(%v) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0ns"},
+		{999, "999ns"},
+		{1500 * time.Nanosecond, "1.5µs"},
+		{87 * time.Microsecond, "87µs"},
+		{999_700 * time.Nanosecond, "1ms"},
+		{235_412 * time.Microsecond, "235ms"},
+		{1234 * time.Millisecond, "1.2s"},
+		{12_340 * time.Millisecond, "12s"},
+		{59_400 * time.Millisecond, "59s"},
+		{59_600 * time.Millisecond, "1m0s"},
+		{125_300 * time.Millisecond, "2m5s"},
+		{-235 * time.Millisecond, "-235ms"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", int64(tt.d), got, tt.want)
+		}
+	}
+}
+
+func TestRawUnits(t *testing.T) {
+	human := New()
+	if got, want := human.size(1_400_123), "1.4 MB"; got != want {
+		t.Errorf("size = %q, want %q", got, want)
+	}
+	if got, want := human.duration(235_012*time.Microsecond), "235ms"; got != want {
+		t.Errorf("duration = %q, want %q", got, want)
+	}
+
+	for _, ct := range []*CurlTransport{New(WithRawUnits()), New(WithFormat(FormatJSON))} {
+		if got, want := ct.size(1_400_123), "1400123 bytes"; got != want {
+			t.Errorf("size = %q, want %q", got, want)
+		}
+		d := 235_012 * time.Microsecond
+		got := ct.duration(d)
+		if want := "0.235012s"; got != want {
+			t.Errorf("duration = %q, want %q", got, want)
+		}
+		if parsed, err := time.ParseDuration(got); err != nil || parsed != d {
+			t.Errorf("ParseDuration(%q) = (%v, %v), want %v", got, parsed, err, d)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package httpdebug
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrInvalidOption is wrapped by the errors returned by Validate for
+// settings that are out of range or have no effect in combination.
+var ErrInvalidOption = errors.New("httpdebug: invalid option")
+
+// NewStrict is like New, but returns an error describing every option
+// that New would silently ignore or that would behave surprisingly
+// (see CurlTransport.Validate).
+func NewStrict(opts ...CurlTransportOption) (*CurlTransport, error) {
+	ct := New(opts...)
+	if err := ct.Validate(); err != nil {
+		return nil, err
+	}
+	return ct, nil
+}
+
+// Validate checks the transport's settings, reporting those that are out
+// of range (e.g. a SampleRate above 1, which dumps every request) and
+// combinations in which one setting cancels another (e.g. PrettyJSON with
+// SingleLine). The result joins one error, wrapping ErrInvalidOption, for
+// each problem found, or is nil if there are none.
+func (t *CurlTransport) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidOption}, args...)...))
+	}
+
+	if t.SampleRate < 0 || t.SampleRate > 1 {
+		invalid("SampleRate %v is outside of [0, 1]", t.SampleRate)
+	}
+	if t.EveryNth < 0 {
+		invalid("EveryNth %v is negative", t.EveryNth)
+	}
+	sampling := t.SampleRate > 0 && t.SampleRate < 1 || t.EveryNth > 1
+	if t.AdaptiveSampling && !sampling {
+		invalid("AdaptiveSampling has no effect without SampleRate or EveryNth")
+	}
+	if t.SlowRequest < 0 {
+		invalid("SlowRequest %v is negative", t.SlowRequest)
+	}
+	if t.SlowRequest > 0 && !t.AdaptiveSampling {
+		invalid("SlowRequest has no effect without AdaptiveSampling")
+	}
+	if t.RateLimit < 0 || (t.RateLimit > 0) != (t.RateLimitPer > 0) {
+		invalid("RateLimit %v per %v needs both a positive count and a positive period", t.RateLimit, t.RateLimitPer)
+	}
+
+	if t.Format != FormatCurl && t.Format != FormatJSON {
+		invalid("unknown %v", t.Format)
+	}
+	if t.Verbosity < VerbosityCurl || t.Verbosity > VerbosityBody {
+		invalid("unknown %v", t.Verbosity)
+	}
+	if t.HTTPVersion < HTTPVersionAuto || t.HTTPVersion > HTTPVersion1_0 {
+		invalid("unknown HTTPVersion %d", int(t.HTTPVersion))
+	}
+	if t.PrettyJSON && t.SingleLine {
+		invalid("PrettyJSON has no effect with SingleLine")
+	}
+	if t.PrettyJSON && (t.Format == FormatJSON || t.Verbosity == VerbosityLine) {
+		invalid("PrettyJSON has no effect without curl output")
+	}
+	if t.PrettyXML && t.SingleLine {
+		invalid("PrettyXML has no effect with SingleLine")
+	}
+	if t.PrettyXML && (t.Format == FormatJSON || t.Verbosity == VerbosityLine) {
+		invalid("PrettyXML has no effect without curl output")
+	}
+	if t.Color && t.Format == FormatJSON {
+		invalid("Color has no effect with FormatJSON")
+	}
+	if t.OmitBody {
+		for _, setting := range []struct {
+			name string
+			set  bool
+		}{
+			{"MaxBodySize", t.MaxBodySize != 0},
+			{"PrettyJSON", t.PrettyJSON},
+			{"PrettyXML", t.PrettyXML},
+			{"GraphQL", t.GraphQL},
+			{"BodyDecoders", len(t.BodyDecoders) > 0},
+		} {
+			if setting.set {
+				invalid("%v has no effect with OmitBody", setting.name)
+			}
+		}
+	}
+
+	if t.Cassette == nil {
+		if t.CassettePlaceholder != "" {
+			invalid("CassettePlaceholder has no effect without a Cassette")
+		}
+		if t.ReplayLatency != 0 {
+			invalid("ReplayLatency has no effect without a Cassette")
+		}
+	}
+	if t.DriftNormalizers != nil && (t.Cassette == nil || t.CassetteMode != CassetteCompare) {
+		invalid("DriftNormalizers has no effect without a Cassette in CassetteCompare mode")
+	}
+	if t.ReplayLatency < 0 {
+		invalid("ReplayLatency %v is negative", t.ReplayLatency)
+	}
+	if l := t.fileRules.Load(); l != nil && l.err != nil {
+		if l.file == nil {
+			invalid("RulesFile not applied: %v", l.err)
+		} else {
+			invalid("RulesFile not reloaded: %v", l.err)
+		}
+	}
+	if len(t.AlertSinks) > 0 && len(t.ResponseValidators) == 0 {
+		invalid("AlertSinks has no effect without ResponseValidators")
+	}
+	if t.Backpressure != DropOnFull && t.AsyncBufferSize <= 0 {
+		invalid("Backpressure has no effect without AsyncBufferSize")
+	}
+
+	for _, list := range []struct {
+		name   string
+		values []string
+	}{
+		{"SecretHeaders", t.list(&t.SecretHeaders)},
+		{"HeaderAllowlist", t.list(&t.HeaderAllowlist)},
+		{"SecretCookies", t.list(&t.SecretCookies)},
+		{"SecretParams", t.list(&t.SecretParams)},
+		{"ParamAllowlist", t.list(&t.ParamAllowlist)},
+		{"SecretBodyFields", t.list(&t.SecretBodyFields)},
+	} {
+		for _, v := range list.values {
+			if strings.TrimSpace(v) == "" {
+				invalid("%v contains an empty name, which matches nothing", list.name)
+			}
+		}
+	}
+	safeModeAllowlist := t.list(&t.SafeModeAllowlist)
+	for _, pattern := range safeModeAllowlist {
+		if err := validURLPattern(pattern); err != nil {
+			invalid("SafeModeAllowlist pattern %q: %v", pattern, err)
+		}
+	}
+	if len(safeModeAllowlist) > 0 && !t.SafeMode {
+		invalid("SafeModeAllowlist has no effect without SafeMode")
+	}
+
+	return errors.Join(errs...)
+}
+
+// validURLPattern returns an error if pattern (see WithSafeMode) is
+// malformed.
+func validURLPattern(pattern string) error {
+	if _, rest, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimSpace(rest)
+	}
+	if pattern == "" || pattern[0] == '/' {
+		return errors.New("missing host")
+	}
+	for _, part := range strings.Split(pattern, "/") {
+		if _, err := path.Match(part, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
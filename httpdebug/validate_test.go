@@ -0,0 +1,105 @@
+package httpdebug
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewStrict(t *testing.T) {
+	ct, err := NewStrict(WithSampleRate(0.1), WithAdaptiveSampling(time.Second), WithPrettyJSON(), WithSafeMode("api.example.com/v1/"))
+	if err != nil || ct == nil {
+		t.Fatalf("NewStrict = (%v, %v), want a transport", ct, err)
+	}
+
+	tests := []struct {
+		name string
+		opts []CurlTransportOption
+		want []string
+	}{
+		{
+			name: "sample rate out of range",
+			opts: []CurlTransportOption{WithSampleRate(1.5)},
+			want: []string{"SampleRate 1.5 is outside of [0, 1]"},
+		},
+		{
+			name: "adaptive sampling without sampling",
+			opts: []CurlTransportOption{WithAdaptiveSampling(time.Second)},
+			want: []string{"AdaptiveSampling has no effect without SampleRate or EveryNth"},
+		},
+		{
+			name: "rate limit without period",
+			opts: []CurlTransportOption{WithRateLimit(5, 0)},
+			want: []string{"RateLimit 5 per 0s needs both a positive count and a positive period"},
+		},
+		{
+			name: "conflicting output options",
+			opts: []CurlTransportOption{WithPrettyJSON(), WithPrettyXML(), WithSingleLine(), WithColor(), WithFormat(FormatJSON)},
+			want: []string{
+				"PrettyJSON has no effect with SingleLine",
+				"PrettyJSON has no effect without curl output",
+				"PrettyXML has no effect with SingleLine",
+				"PrettyXML has no effect without curl output",
+				"Color has no effect with FormatJSON",
+			},
+		},
+		{
+			name: "body options without body",
+			opts: []CurlTransportOption{WithoutBody(), WithMaxBodySize(10), WithGraphQL()},
+			want: []string{"MaxBodySize has no effect with OmitBody", "GraphQL has no effect with OmitBody"},
+		},
+		{
+			name: "alert sink without validators",
+			opts: []CurlTransportOption{WithAlertSink(&entryRecorder{})},
+			want: []string{"AlertSinks has no effect without ResponseValidators"},
+		},
+		{
+			name: "drift normalizers without compare",
+			opts: []CurlTransportOption{WithCassette(NewCassette("c.json"), CassetteReplay), WithDriftNormalizers(DefaultNormalizers...)},
+			want: []string{"DriftNormalizers has no effect without a Cassette in CassetteCompare mode"},
+		},
+		{
+			name: "unknown enums",
+			opts: []CurlTransportOption{WithFormat(Format(7)), WithHTTPVersion(HTTPVersion(9))},
+			want: []string{"unknown Format(7)", "unknown HTTPVersion 9"},
+		},
+		{
+			name: "cassette options without cassette",
+			opts: []CurlTransportOption{WithCassettePlaceholder("X"), WithReplayLatency(1)},
+			want: []string{"CassettePlaceholder has no effect without a Cassette", "ReplayLatency has no effect without a Cassette"},
+		},
+		{
+			name: "backpressure without async",
+			opts: []CurlTransportOption{WithBackpressure(BlockOnFull)},
+			want: []string{"Backpressure has no effect without AsyncBufferSize"},
+		},
+		{
+			name: "bad safe mode patterns",
+			opts: []CurlTransportOption{WithSafeMode("GET /v1/", "api.example.com/[")},
+			want: []string{`SafeModeAllowlist pattern "GET /v1/": missing host`, `SafeModeAllowlist pattern "api.example.com/[": syntax error in pattern`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct, err := NewStrict(tt.opts...)
+			if ct != nil || !errors.Is(err, ErrInvalidOption) {
+				t.Fatalf("NewStrict = (%v, %v), want ErrInvalidOption", ct, err)
+			}
+			var want []string
+			for _, w := range tt.want {
+				want = append(want, "httpdebug: invalid option: "+w)
+			}
+			if got := err.Error(); got != strings.Join(want, "\n") {
+				t.Errorf("NewStrict error =\n%v\nwant:\n%v", got, strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCurlTransport_ValidateDefaults(t *testing.T) {
+	if err := New().Validate(); err != nil {
+		t.Errorf("New().Validate = %v, want nil", err)
+	}
+}
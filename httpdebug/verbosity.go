@@ -0,0 +1,70 @@
+package httpdebug
+
+import "fmt"
+
+// Verbosity selects how much is logged about each request.
+type Verbosity int
+
+const (
+	// VerbosityCurl logs the curl command of each request (level 1), and
+	// the status and latency of its response only if the request is
+	// labeled (e.g. by WithSequence). This is the default.
+	VerbosityCurl Verbosity = iota
+	// VerbosityLine logs only the method and URL of each request, on a
+	// single line (level 0).
+	VerbosityLine
+	// VerbosityStatus logs the curl command of each request and the
+	// status and latency of its response (level 2).
+	VerbosityStatus
+	// VerbosityBody logs the curl command of each request and the
+	// status, latency, headers, and body of its response (level 3),
+	// once the caller has read the body or closed it.
+	VerbosityBody
+)
+
+// String returns the name of the verbosity.
+func (v Verbosity) String() string {
+	switch v {
+	case VerbosityCurl:
+		return "curl"
+	case VerbosityLine:
+		return "line"
+	case VerbosityStatus:
+		return "status"
+	case VerbosityBody:
+		return "body"
+	}
+	return fmt.Sprintf("Verbosity(%d)", int(v))
+}
+
+// WithVerbosity is a CurlTransportOption that selects how much is logged
+// about each request by level: 0 for the method and URL on one line, 1
+// for the curl command (the default), 2 to add the status and latency of
+// the response, and 3 to add the response headers and body as well.
+// Levels below 0 or above 3 are treated as 0 or 3, respectively.
+func WithVerbosity(level int) func(*CurlTransport) {
+	return func(ct *CurlTransport) {
+		switch {
+		case level <= 0:
+			ct.Verbosity = VerbosityLine
+		case level == 1:
+			ct.Verbosity = VerbosityCurl
+		case level == 2:
+			ct.Verbosity = VerbosityStatus
+		default:
+			ct.Verbosity = VerbosityBody
+		}
+	}
+}
+
+// requestLine returns e as the single line logged by VerbosityLine.
+func (t *CurlTransport) requestLine(e *Entry) string {
+	s := e.Method + " " + e.URL
+	if label := e.label(); label != "" {
+		s = label[1:] + " " + s
+	}
+	if t.MarkMutating && e.Mutating() {
+		s += " # MUTATING"
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package httpdebug
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestVerbosity_String(t *testing.T) {
+	for v, want := range map[Verbosity]string{VerbosityCurl: "curl", VerbosityLine: "line", VerbosityStatus: "status", VerbosityBody: "body", Verbosity(9): "Verbosity(9)"} {
+		if got := v.String(); got != want {
+			t.Errorf("%d.String = %q, want %q", int(v), got, want)
+		}
+	}
+}
+
+func TestWithVerbosity(t *testing.T) {
+	for level, want := range map[int]Verbosity{-1: VerbosityLine, 0: VerbosityLine, 1: VerbosityCurl, 2: VerbosityStatus, 3: VerbosityBody, 4: VerbosityBody} {
+		if got := New(WithVerbosity(level)).Verbosity; got != want {
+			t.Errorf("WithVerbosity(%v) set Verbosity = %v, want %v", level, got, want)
+		}
+	}
+}
+
+func TestRoundTrip_Verbosity(t *testing.T) {
+	client, mux, url, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"token":"s3cr3t"}`)
+	})
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	latency := regexp.MustCompile(`in [0-9.]+[µnm]?s`)
+	date := regexp.MustCompile(`# Date: .*`)
+	tests := []struct {
+		name string
+		opts []CurlTransportOption
+		want []string
+	}{
+		{
+			name: "level 0",
+			opts: []CurlTransportOption{WithVerbosity(0)},
+			want: []string{"POST " + url + "/x # MUTATING"},
+		},
+		{
+			name: "level 0 with sequence",
+			opts: []CurlTransportOption{WithVerbosity(0), WithSequence()},
+			want: []string{"#0001 POST " + url + "/x # MUTATING"},
+		},
+		{
+			name: "level 1",
+			opts: []CurlTransportOption{WithVerbosity(1)},
+			want: []string{"# MUTATING\ncurl -X POST \\\n  " + url + "/x"},
+		},
+		{
+			name: "level 2",
+			opts: []CurlTransportOption{WithVerbosity(2)},
+			want: []string{"# MUTATING\ncurl -X POST \\\n  " + url + "/x", "# response: 200 OK in X"},
+		},
+		{
+			name: "level 3",
+			opts: []CurlTransportOption{WithVerbosity(3), WithSecretBodyField("token")},
+			want: []string{
+				"# MUTATING\ncurl -X POST \\\n  " + url + "/x",
+				"# response: HTTP/1.1 200 OK in X\n# Content-Length: 18\n# Content-Type: application/json\n# Date: X\n#\n# {\"token\":\"REDACTED\"}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged = nil
+			client.Transport = New(append(tt.opts, WithMutatingMarker())...)
+			req, _ := http.NewRequest("POST", url+"/x", nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("client.Do = %v", err)
+			}
+			io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			for i, l := range logged {
+				logged[i] = date.ReplaceAllString(latency.ReplaceAllString(l, "in X"), "# Date: X")
+			}
+			if strings.Join(logged, "\n---\n") != strings.Join(tt.want, "\n---\n") {
+				t.Errorf("logged =\n%v\nwant:\n%v", strings.Join(logged, "\n---\n"), strings.Join(tt.want, "\n---\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package httpdebug
+
+import (
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// WrapClient returns a copy of c (or of an empty http.Client if c is nil)
+// that dumps its requests with a CurlTransport configured by opts,
+// wrapping whatever transport c already has. The CurlTransport goes
+// beneath any oauth2.Transport and Retry wrappers that the transport
+// starts with, in the order VerifyChain recommends, so that the dumps
+// show the Authorization header and every attempt, e.g. for a client
+// made by oauth2.NewClient:
+//
+//	client := github.NewClient(httpdebug.WrapClient(oauth2.NewClient(ctx, ts)))
+//
+// A transport that already dumps its requests with a CurlTransport is
+// kept as it is, so that they are not dumped twice. Unless c has a
+// CheckRedirect, the copy diagnoses redirect loops like Client.
+func WrapClient(c *http.Client, opts ...CurlTransportOption) *http.Client {
+	wrapped := &http.Client{}
+	if c != nil {
+		*wrapped = *c
+	}
+	rt := wrapped.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	var ct *CurlTransport
+	wrapped.Transport, ct = wrapTransport(rt, opts)
+	if wrapped.CheckRedirect == nil {
+		wrapped.CheckRedirect = ct.checkRedirect
+	}
+	return wrapped
+}
+
+// WrapDefaultTransport replaces http.DefaultTransport, and so the
+// transport of http.DefaultClient and of every client without one of its
+// own, with one that dumps its requests with a CurlTransport configured
+// by opts, as WrapClient does, and returns the CurlTransport. Calling it
+// again returns the same CurlTransport. Since http.DefaultTransport is
+// not safe to replace while it is in use, it should be called early, e.g.
+// at the start of main.
+//
+// Code that asserts http.DefaultTransport to be an *http.Transport, as in
+// the common http.DefaultTransport.(*http.Transport).Clone(), panics once
+// it has been replaced; such code should use the comma-ok form, or the
+// Unwrap method of the returned CurlTransport. A CurlTransport without a
+// Transport of its own sends its requests with the original transport,
+// so that they are not dumped twice.
+func WrapDefaultTransport(opts ...CurlTransportOption) *CurlTransport {
+	rt, ct := wrapTransport(http.DefaultTransport, opts)
+	http.DefaultTransport = rt
+	return ct
+}
+
+// wrapTransport returns rt with a CurlTransport configured by opts
+// inserted beneath the oauth2.Transport and Retry wrappers it starts
+// with, which are copied rather than modified, along with the
+// CurlTransport. If the chain starting at rt already has a CurlTransport,
+// it returns rt and that CurlTransport.
+func wrapTransport(rt http.RoundTripper, opts []CurlTransportOption) (http.RoundTripper, *CurlTransport) {
+	if ct := findCurlTransport(rt); ct != nil {
+		return rt, ct
+	}
+	switch t := rt.(type) {
+	case *oauth2.Transport:
+		base, ct := wrapTransport(t.Base, opts)
+		return &oauth2.Transport{Source: t.Source, Base: base}, ct
+	case *retryTransport:
+		base, ct := wrapTransport(t.base, opts)
+		r := *t
+		r.base = base
+		return &r, ct
+	}
+	ct := New(append(opts[:len(opts):len(opts)], WithTransport(rt))...)
+	return ct, ct
+}
@@ -0,0 +1,122 @@
+package httpdebug
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestWrapClient_OAuth2(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer s3cr3t" {
+			t.Errorf("Authorization = %q, want Bearer s3cr3t", got)
+		}
+	}))
+	defer ts.Close()
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "s3cr3t"})
+	orig := &http.Client{Transport: Chain(&http.Transport{}, Retry(2, time.Millisecond), OAuth2(src))}
+	client := WrapClient(orig, WithPrefix("> "))
+	if client == orig || orig.CheckRedirect != nil || VerifyChain(orig.Transport) != nil || findCurlTransport(orig.Transport) != nil {
+		t.Error("WrapClient modified the original client")
+	}
+	if client.CheckRedirect == nil {
+		t.Error("CheckRedirect is nil, want checkRedirect")
+	}
+	if err := VerifyChain(client.Transport); err != nil {
+		t.Errorf("VerifyChain = %v, want nil", err)
+	}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("client.Get = %v", err)
+	}
+	resp.Body.Close()
+	if len(logged) != 1 || !strings.HasPrefix(logged[0], "> curl") || !strings.Contains(logged[0], "Authorization: <REDACTED>") {
+		t.Errorf("logged = %#v, want a dump with the redacted Authorization header", logged)
+	}
+
+	again := WrapClient(client, WithPrefix("! "))
+	if again.Transport != client.Transport {
+		t.Errorf("WrapClient of a wrapped client wrapped its transport again")
+	}
+}
+
+func TestWrapClient_Nil(t *testing.T) {
+	client := WrapClient(nil)
+	ct, ok := client.Transport.(*CurlTransport)
+	if !ok || ct.Unwrap() != http.DefaultTransport {
+		t.Errorf("Transport = %#v, want a CurlTransport wrapping http.DefaultTransport", client.Transport)
+	}
+
+	redirect := func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	client = WrapClient(&http.Client{CheckRedirect: redirect})
+	if err := client.CheckRedirect(nil, nil); err != http.ErrUseLastResponse {
+		t.Errorf("CheckRedirect = %v, want the client's own", err)
+	}
+}
+
+func TestWrapDefaultTransport(t *testing.T) {
+	orig := http.DefaultTransport
+	defer func() { http.DefaultTransport = orig }()
+
+	ct := WrapDefaultTransport(WithPrefix("> "))
+	if http.DefaultTransport != ct || ct.Unwrap() != orig || ct.Prefix != "> " {
+		t.Errorf("DefaultTransport = %#v, want a CurlTransport wrapping the original", http.DefaultTransport)
+	}
+	if got := WrapDefaultTransport(); got != ct || http.DefaultTransport != ct {
+		t.Errorf("WrapDefaultTransport again = %p, want %p", got, ct)
+	}
+	if got := WrapClient(&http.Client{}).Transport; got != ct {
+		t.Errorf("WrapClient with the wrapped DefaultTransport = %#v, want it as it is", got)
+	}
+}
+
+func TestWrapDefaultTransport_Unwrapped(t *testing.T) {
+	orig := http.DefaultTransport
+	defer func() { http.DefaultTransport = orig }()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	oldLogger := logger
+	defer func() { logger = oldLogger }()
+	var logged []string
+	logger = func(v ...interface{}) { logged = append(logged, fmt.Sprint(v...)) }
+
+	ct := WrapDefaultTransport(WithPrefix("default: "))
+
+	// Callers asserting the type must use the comma-ok form and Unwrap.
+	if _, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.Error("DefaultTransport is still an *http.Transport")
+	}
+	tr, ok := ct.Unwrap().(*http.Transport)
+	if !ok || tr != orig {
+		t.Fatalf("Unwrap = %#v, want the original *http.Transport", ct.Unwrap())
+	}
+	tr.Clone()
+
+	// Another CurlTransport without a Transport sends with the original,
+	// and sees it as an *http.Transport.
+	other := New()
+	if got, ok := other.httpTransport(); !ok || got != orig {
+		t.Errorf("httpTransport = %#v, %v, want the original", got, ok)
+	}
+	resp, err := other.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Get = %v", err)
+	}
+	resp.Body.Close()
+	if len(logged) != 1 || strings.HasPrefix(logged[0], "default: ") {
+		t.Errorf("logged = %#v, want a single dump by the other transport", logged)
+	}
+}